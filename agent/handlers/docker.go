@@ -1,18 +1,33 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/image"
 	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/registry"
 	"github.com/docker/docker/api/types/volume"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/moby/patternmatcher/ignorefile"
 )
 
 type DockerHandler struct {
@@ -62,12 +77,134 @@ func (h *DockerHandler) GetVersion(c *gin.Context) {
 	c.JSON(http.StatusOK, version)
 }
 
+type PruneRequest struct {
+	Containers bool `json:"containers"`
+	Images     bool `json:"images"`    // dangling images only
+	AllImages  bool `json:"allImages"` // every image not used by a container
+	BuildCache bool `json:"buildCache"`
+	Networks   bool `json:"networks"`
+	Volumes    bool `json:"volumes"` // anonymous volumes only
+	AllVolumes bool `json:"allVolumes"`
+}
+
+type PruneReport struct {
+	Deleted        []string `json:"deleted"`
+	SpaceReclaimed uint64   `json:"spaceReclaimed"`
+	Error          string   `json:"error,omitempty"`
+}
+
+type PruneResult struct {
+	Containers     *PruneReport `json:"containers,omitempty"`
+	Images         *PruneReport `json:"images,omitempty"`
+	BuildCache     *PruneReport `json:"buildCache,omitempty"`
+	Networks       *PruneReport `json:"networks,omitempty"`
+	Volumes        *PruneReport `json:"volumes,omitempty"`
+	SpaceReclaimed uint64       `json:"spaceReclaimed"`
+}
+
+// Prune removes unused resources. Containers go first so the images, networks
+// and volumes they held can be reclaimed in the same run.
+func (h *DockerHandler) Prune(c *gin.Context) {
+	var req PruneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := PruneResult{}
+	newReport := func() *PruneReport { return &PruneReport{Deleted: make([]string, 0)} }
+
+	if req.Containers {
+		report := newReport()
+		resp, err := h.client.ContainersPrune(h.ctx, filters.NewArgs())
+		if err != nil {
+			report.Error = err.Error()
+		} else {
+			for _, id := range resp.ContainersDeleted {
+				report.Deleted = append(report.Deleted, id[:12])
+			}
+			report.SpaceReclaimed = resp.SpaceReclaimed
+		}
+		result.Containers = report
+	}
+
+	if req.Images || req.AllImages {
+		report := newReport()
+		args := filters.NewArgs()
+		if req.AllImages {
+			args.Add("dangling", "false")
+		}
+		resp, err := h.client.ImagesPrune(h.ctx, args)
+		if err != nil {
+			report.Error = err.Error()
+		} else {
+			for _, item := range resp.ImagesDeleted {
+				if item.Deleted != "" {
+					report.Deleted = append(report.Deleted, item.Deleted)
+				} else if item.Untagged != "" {
+					report.Deleted = append(report.Deleted, item.Untagged)
+				}
+			}
+			report.SpaceReclaimed = resp.SpaceReclaimed
+		}
+		result.Images = report
+	}
+
+	if req.Networks {
+		report := newReport()
+		resp, err := h.client.NetworksPrune(h.ctx, filters.NewArgs())
+		if err != nil {
+			report.Error = err.Error()
+		} else {
+			report.Deleted = append(report.Deleted, resp.NetworksDeleted...)
+		}
+		result.Networks = report
+	}
+
+	if req.Volumes || req.AllVolumes {
+		report := newReport()
+		args := filters.NewArgs()
+		if req.AllVolumes {
+			args.Add("all", "true")
+		}
+		resp, err := h.client.VolumesPrune(h.ctx, args)
+		if err != nil {
+			report.Error = err.Error()
+		} else {
+			report.Deleted = append(report.Deleted, resp.VolumesDeleted...)
+			report.SpaceReclaimed = resp.SpaceReclaimed
+		}
+		result.Volumes = report
+	}
+
+	if req.BuildCache {
+		report := newReport()
+		resp, err := h.client.BuildCachePrune(h.ctx, types.BuildCachePruneOptions{All: req.AllImages})
+		if err != nil {
+			report.Error = err.Error()
+		} else {
+			report.Deleted = append(report.Deleted, resp.CachesDeleted...)
+			report.SpaceReclaimed = resp.SpaceReclaimed
+		}
+		result.BuildCache = report
+	}
+
+	for _, report := range []*PruneReport{result.Containers, result.Images, result.BuildCache, result.Networks, result.Volumes} {
+		if report != nil {
+			result.SpaceReclaimed += report.SpaceReclaimed
+		}
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
 // ==================== Containers ====================
 
 type ContainerInfo struct {
 	ID      string            `json:"id"`
 	Name    string            `json:"name"`
 	Image   string            `json:"image"`
+	ImageID string            `json:"imageId"`
 	Status  string            `json:"status"`
 	State   string            `json:"state"`
 	Created int64             `json:"created"`
@@ -111,6 +248,7 @@ func (h *DockerHandler) ListContainers(c *gin.Context) {
 			ID:      ctr.ID[:12],
 			Name:    name,
 			Image:   ctr.Image,
+			ImageID: ctr.ImageID,
 			Status:  ctr.Status,
 			State:   ctr.State,
 			Created: ctr.Created,
@@ -132,6 +270,76 @@ func (h *DockerHandler) GetContainer(c *gin.Context) {
 	c.JSON(http.StatusOK, ctr)
 }
 
+type CreateContainerRequest struct {
+	Name             string                    `json:"name"`
+	Config           *container.Config         `json:"config" binding:"required"`
+	HostConfig       *container.HostConfig     `json:"hostConfig"`
+	NetworkingConfig *network.NetworkingConfig `json:"networkingConfig"`
+	RegistryAuth     string                    `json:"registryAuth"` // base64 X-Registry-Auth used if the image must be pulled
+}
+
+// CreateContainer creates a container from native Docker config, pulling the image if missing.
+// Only the primary network (NetworkMode) is attached at creation; other networks are
+// connected afterwards for compatibility with older Docker API versions.
+func (h *DockerHandler) CreateContainer(c *gin.Context) {
+	var req CreateContainerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Config.Image == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "image is required"})
+		return
+	}
+
+	primary, extra := splitEndpoints(req.HostConfig, req.NetworkingConfig)
+
+	resp, err := h.client.ContainerCreate(h.ctx, req.Config, req.HostConfig, primary, nil, req.Name)
+	if err != nil && client.IsErrNotFound(err) {
+		if pullErr := h.pullImage(req.Config.Image, req.RegistryAuth); pullErr != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": pullErr.Error()})
+			return
+		}
+		resp, err = h.client.ContainerCreate(h.ctx, req.Config, req.HostConfig, primary, nil, req.Name)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	for name, endpoint := range extra {
+		if err := h.client.NetworkConnect(h.ctx, name, resp.ID, endpoint); err != nil {
+			h.client.ContainerRemove(h.ctx, resp.ID, container.RemoveOptions{Force: true})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"id": resp.ID[:12], "warnings": resp.Warnings})
+}
+
+func splitEndpoints(hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig) (*network.NetworkingConfig, map[string]*network.EndpointSettings) {
+	if networkingConfig == nil || len(networkingConfig.EndpointsConfig) <= 1 {
+		return networkingConfig, nil
+	}
+
+	primaryName := ""
+	if hostConfig != nil {
+		primaryName = string(hostConfig.NetworkMode)
+	}
+
+	primary := &network.NetworkingConfig{EndpointsConfig: map[string]*network.EndpointSettings{}}
+	extra := make(map[string]*network.EndpointSettings)
+	for name, endpoint := range networkingConfig.EndpointsConfig {
+		if name == primaryName {
+			primary.EndpointsConfig[name] = endpoint
+		} else {
+			extra[name] = endpoint
+		}
+	}
+	return primary, extra
+}
+
 func (h *DockerHandler) StartContainer(c *gin.Context) {
 	id := c.Param("id")
 	if err := h.client.ContainerStart(h.ctx, id, container.StartOptions{}); err != nil {
@@ -141,10 +349,28 @@ func (h *DockerHandler) StartContainer(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Container started"})
 }
 
+// stopOptions reads ?timeout= in seconds (-1 waits forever). Without it Docker uses
+// the container's own stop timeout.
+func stopOptions(c *gin.Context) (container.StopOptions, bool) {
+	value := c.Query("timeout")
+	if value == "" {
+		return container.StopOptions{}, true
+	}
+	timeout, err := strconv.Atoi(value)
+	if err != nil || timeout < -1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid timeout"})
+		return container.StopOptions{}, false
+	}
+	return container.StopOptions{Timeout: &timeout}, true
+}
+
 func (h *DockerHandler) StopContainer(c *gin.Context) {
 	id := c.Param("id")
-	timeout := 10
-	if err := h.client.ContainerStop(h.ctx, id, container.StopOptions{Timeout: &timeout}); err != nil {
+	opts, ok := stopOptions(c)
+	if !ok {
+		return
+	}
+	if err := h.client.ContainerStop(h.ctx, id, opts); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -153,14 +379,116 @@ func (h *DockerHandler) StopContainer(c *gin.Context) {
 
 func (h *DockerHandler) RestartContainer(c *gin.Context) {
 	id := c.Param("id")
-	timeout := 10
-	if err := h.client.ContainerRestart(h.ctx, id, container.StopOptions{Timeout: &timeout}); err != nil {
+	opts, ok := stopOptions(c)
+	if !ok {
+		return
+	}
+	if err := h.client.ContainerRestart(h.ctx, id, opts); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Container restarted"})
 }
 
+// UpdateContainer changes resource limits and the restart policy of a container
+// without recreating it ("docker update")
+func (h *DockerHandler) UpdateContainer(c *gin.Context) {
+	var update container.UpdateConfig
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	resp, err := h.client.ContainerUpdate(h.ctx, c.Param("id"), update)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if client.IsErrNotFound(err) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// KillContainer sends a signal (default SIGKILL) to the container's main process
+func (h *DockerHandler) KillContainer(c *gin.Context) {
+	id := c.Param("id")
+	var req struct {
+		Signal string `json:"signal"`
+	}
+	// The body is optional; ContentLength is -1 for chunked requests
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.Signal == "" {
+		req.Signal = "SIGKILL"
+	}
+	if err := h.client.ContainerKill(h.ctx, id, req.Signal); err != nil {
+		status := http.StatusInternalServerError
+		if errdefs.IsInvalidParameter(err) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Signal sent"})
+}
+
+func (h *DockerHandler) PauseContainer(c *gin.Context) {
+	id := c.Param("id")
+	if err := h.client.ContainerPause(h.ctx, id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Container paused"})
+}
+
+func (h *DockerHandler) UnpauseContainer(c *gin.Context) {
+	id := c.Param("id")
+	if err := h.client.ContainerUnpause(h.ctx, id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Container unpaused"})
+}
+
+// TopContainer lists the processes running in the container (?ps_args= is passed to ps)
+func (h *DockerHandler) TopContainer(c *gin.Context) {
+	var args []string
+	if psArgs := c.Query("ps_args"); psArgs != "" {
+		args = []string{psArgs}
+	}
+	top, err := h.client.ContainerTop(h.ctx, c.Param("id"), args)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if client.IsErrNotFound(err) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, top)
+}
+
+func (h *DockerHandler) RenameContainer(c *gin.Context) {
+	id := c.Param("id")
+	var req struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.client.ContainerRename(h.ctx, id, req.Name); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Container renamed"})
+}
+
 func (h *DockerHandler) RemoveContainer(c *gin.Context) {
 	id := c.Param("id")
 	force := c.Query("force") == "true"
@@ -175,6 +503,12 @@ func (h *DockerHandler) GetContainerLogs(c *gin.Context) {
 	id := c.Param("id")
 	tail := c.DefaultQuery("tail", "100")
 
+	ctr, err := h.client.ContainerInspect(h.ctx, id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
 	reader, err := h.client.ContainerLogs(h.ctx, id, container.LogsOptions{
 		ShowStdout: true,
 		ShowStderr: true,
@@ -187,13 +521,158 @@ func (h *DockerHandler) GetContainerLogs(c *gin.Context) {
 	}
 	defer reader.Close()
 
-	logs, err := io.ReadAll(reader)
+	// Non-TTY containers produce a multiplexed stream with 8-byte frame headers
+	var logs bytes.Buffer
+	if ctr.Config != nil && ctr.Config.Tty {
+		_, err = io.Copy(&logs, reader)
+	} else {
+		_, err = stdcopy.StdCopy(&logs, &logs, reader)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"logs": logs.String()})
+}
+
+// StreamContainerLogs streams the raw Docker log stream so the backend can relay it
+// over WebSocket. Supports follow, tail and since query parameters.
+func (h *DockerHandler) StreamContainerLogs(c *gin.Context) {
+	id := c.Param("id")
+
+	reader, err := h.client.ContainerLogs(c.Request.Context(), id, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     c.Query("follow") == "true",
+		Tail:       c.DefaultQuery("tail", "100"),
+		Since:      c.Query("since"),
+		Timestamps: true,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer reader.Close()
+
+	c.Header("Content-Type", "application/octet-stream")
+	c.Status(http.StatusOK)
+	streamResponse(c, reader)
+}
+
+// StreamContainerStats streams Docker's stats for a container, one JSON object per
+// sample (about every second), until the client disconnects.
+func (h *DockerHandler) StreamContainerStats(c *gin.Context) {
+	stats, err := h.client.ContainerStats(c.Request.Context(), c.Param("id"), true)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if client.IsErrNotFound(err) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	defer stats.Body.Close()
+
+	c.Header("Content-Type", "application/json")
+	c.Status(http.StatusOK)
+	streamResponse(c, stats.Body)
+}
+
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// Requests are already authenticated by API key
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// ExecContainer starts an interactive command in the container and bridges it over WebSocket.
+// Query params: cmd (repeatable, one per argument; default /bin/sh), user, workdir, env (repeatable KEY=VALUE).
+// Binary messages carry raw terminal input/output, text messages carry JSON control
+// messages such as {"type":"resize","cols":80,"rows":24}.
+func (h *DockerHandler) ExecContainer(c *gin.Context) {
+	id := c.Param("id")
+
+	// Each argument is sent as its own cmd parameter so arguments may contain spaces
+	cmd := c.QueryArray("cmd")
+	if len(cmd) == 0 {
+		cmd = []string{"/bin/sh"}
+	}
+
+	execResp, err := h.client.ContainerExecCreate(h.ctx, id, container.ExecOptions{
+		AttachStdin:  true,
+		AttachStdout: true,
+		AttachStderr: true,
+		Tty:          true,
+		Cmd:          cmd,
+		User:         c.Query("user"),
+		WorkingDir:   c.Query("workdir"),
+		Env:          c.QueryArray("env"),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	hijacked, err := h.client.ContainerExecAttach(h.ctx, execResp.ID, container.ExecStartOptions{Tty: true})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	defer hijacked.Close()
 
-	c.JSON(http.StatusOK, gin.H{"logs": string(logs)})
+	conn, err := wsUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	// Container output -> WebSocket
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, err := hijacked.Reader.Read(buf)
+			if n > 0 {
+				if werr := conn.WriteMessage(websocket.BinaryMessage, buf[:n]); werr != nil {
+					return
+				}
+			}
+			if err != nil {
+				conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				conn.Close()
+				return
+			}
+		}
+	}()
+
+	// WebSocket -> container input / control
+	for {
+		messageType, message, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		if messageType == websocket.TextMessage {
+			var ctrl struct {
+				Type string `json:"type"`
+				Cols uint   `json:"cols"`
+				Rows uint   `json:"rows"`
+			}
+			if json.Unmarshal(message, &ctrl) == nil && ctrl.Type == "resize" {
+				if ctrl.Cols > 0 && ctrl.Rows > 0 {
+					h.client.ContainerExecResize(h.ctx, execResp.ID, container.ResizeOptions{
+						Height: ctrl.Rows,
+						Width:  ctrl.Cols,
+					})
+				}
+				continue
+			}
+		}
+
+		if _, err := hijacked.Conn.Write(message); err != nil {
+			return
+		}
+	}
 }
 
 type ContainerStats struct {
@@ -203,6 +682,9 @@ type ContainerStats struct {
 	MemoryPercent float64 `json:"memoryPercent"`
 	NetworkRx     uint64  `json:"networkRx"`
 	NetworkTx     uint64  `json:"networkTx"`
+	BlockRead     uint64  `json:"blockRead"`
+	BlockWrite    uint64  `json:"blockWrite"`
+	PIDs          uint64  `json:"pids"`
 }
 
 type StatsJSON struct {
@@ -212,6 +694,7 @@ type StatsJSON struct {
 			PercpuUsage []uint64 `json:"percpu_usage"`
 		} `json:"cpu_usage"`
 		SystemUsage uint64 `json:"system_cpu_usage"`
+		OnlineCPUs  uint32 `json:"online_cpus"`
 	} `json:"cpu_stats"`
 	PreCPUStats struct {
 		CPUUsage struct {
@@ -228,6 +711,15 @@ type StatsJSON struct {
 		RxBytes uint64 `json:"rx_bytes"`
 		TxBytes uint64 `json:"tx_bytes"`
 	} `json:"networks"`
+	BlkioStats struct {
+		IoServiceBytesRecursive []struct {
+			Op    string `json:"op"`
+			Value uint64 `json:"value"`
+		} `json:"io_service_bytes_recursive"`
+	} `json:"blkio_stats"`
+	PidsStats struct {
+		Current uint64 `json:"current"`
+	} `json:"pids_stats"`
 }
 
 func (h *DockerHandler) GetContainerStats(c *gin.Context) {
@@ -248,7 +740,11 @@ func (h *DockerHandler) GetContainerStats(c *gin.Context) {
 	cpuDelta := float64(statsJSON.CPUStats.CPUUsage.TotalUsage - statsJSON.PreCPUStats.CPUUsage.TotalUsage)
 	systemDelta := float64(statsJSON.CPUStats.SystemUsage - statsJSON.PreCPUStats.SystemUsage)
 	cpuPercent := 0.0
-	numCPUs := len(statsJSON.CPUStats.CPUUsage.PercpuUsage)
+	// cgroup v2 has no percpu_usage, use online_cpus
+	numCPUs := int(statsJSON.CPUStats.OnlineCPUs)
+	if numCPUs == 0 {
+		numCPUs = len(statsJSON.CPUStats.CPUUsage.PercpuUsage)
+	}
 	if numCPUs == 0 {
 		numCPUs = 1
 	}
@@ -267,6 +763,17 @@ func (h *DockerHandler) GetContainerStats(c *gin.Context) {
 		networkTx += net.TxBytes
 	}
 
+	// cgroup v1 reports "Read"/"Write", v2 "read"/"write"
+	var blockRead, blockWrite uint64
+	for _, entry := range statsJSON.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(entry.Op) {
+		case "read":
+			blockRead += entry.Value
+		case "write":
+			blockWrite += entry.Value
+		}
+	}
+
 	c.JSON(http.StatusOK, ContainerStats{
 		CPUPercent:    cpuPercent,
 		MemoryUsage:   statsJSON.MemoryStats.Usage,
@@ -274,17 +781,92 @@ func (h *DockerHandler) GetContainerStats(c *gin.Context) {
 		MemoryPercent: memPercent,
 		NetworkRx:     networkRx,
 		NetworkTx:     networkTx,
+		BlockRead:     blockRead,
+		BlockWrite:    blockWrite,
+		PIDs:          statsJSON.PidsStats.Current,
 	})
 }
 
+// GetArchive streams a tar of a file or directory inside the container (?path=).
+// The entry is rooted at the base name of the path, as with "docker cp", and its stat
+// is returned in the X-Docker-Container-Path-Stat header.
+func (h *DockerHandler) GetArchive(c *gin.Context) {
+	path := c.Query("path")
+	if path == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "path is required"})
+		return
+	}
+
+	reader, stat, err := h.client.CopyFromContainer(c.Request.Context(), c.Param("id"), path)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if client.IsErrNotFound(err) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	defer reader.Close()
+
+	if data, err := json.Marshal(stat); err == nil {
+		c.Header("X-Docker-Container-Path-Stat", base64.StdEncoding.EncodeToString(data))
+	}
+	c.Header("Content-Type", "application/x-tar")
+	c.Status(http.StatusOK)
+	streamResponse(c, reader)
+}
+
+// PutArchive extracts the tar in the request body into the directory ?path= inside
+// the container. Owners recorded in the archive are kept unless copyUIDGID=true, which
+// chowns the files to the container's user as "docker cp -a" does.
+func (h *DockerHandler) PutArchive(c *gin.Context) {
+	path := c.Query("path")
+	if path == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "path is required"})
+		return
+	}
+
+	err := h.client.CopyToContainer(c.Request.Context(), c.Param("id"), path, c.Request.Body, container.CopyToContainerOptions{
+		CopyUIDGID: c.Query("copyUIDGID") == "true",
+	})
+	if err != nil {
+		status := http.StatusInternalServerError
+		if client.IsErrNotFound(err) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Archive extracted"})
+}
+
+// GetContainerChanges lists files added, modified or deleted in the container's
+// filesystem compared to its image ("docker diff").
+func (h *DockerHandler) GetContainerChanges(c *gin.Context) {
+	changes, err := h.client.ContainerDiff(h.ctx, c.Param("id"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if client.IsErrNotFound(err) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	if changes == nil {
+		changes = []container.FilesystemChange{}
+	}
+	c.JSON(http.StatusOK, changes)
+}
+
 // ==================== Images ====================
 
 type ImageInfo struct {
-	ID         string            `json:"id"`
-	RepoTags   []string          `json:"repoTags"`
-	Created    int64             `json:"created"`
-	Size       int64             `json:"size"`
-	Labels     map[string]string `json:"labels"`
+	ID          string            `json:"id"`
+	RepoTags    []string          `json:"repoTags"`
+	RepoDigests []string          `json:"repoDigests"`
+	Created     int64             `json:"created"`
+	Size        int64             `json:"size"`
+	Labels      map[string]string `json:"labels"`
 }
 
 func (h *DockerHandler) ListImages(c *gin.Context) {
@@ -297,11 +879,12 @@ func (h *DockerHandler) ListImages(c *gin.Context) {
 	result := make([]ImageInfo, 0, len(images))
 	for _, img := range images {
 		result = append(result, ImageInfo{
-			ID:       img.ID[7:19],
-			RepoTags: img.RepoTags,
-			Created:  img.Created,
-			Size:     img.Size,
-			Labels:   img.Labels,
+			ID:          img.ID[7:19],
+			RepoTags:    img.RepoTags,
+			RepoDigests: img.RepoDigests,
+			Created:     img.Created,
+			Size:        img.Size,
+			Labels:      img.Labels,
 		})
 	}
 
@@ -318,6 +901,265 @@ func (h *DockerHandler) GetImage(c *gin.Context) {
 	c.JSON(http.StatusOK, img)
 }
 
+func (h *DockerHandler) GetImageHistory(c *gin.Context) {
+	history, err := h.client.ImageHistory(h.ctx, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, history)
+}
+
+// SaveImage streams the tar produced by "docker save" for one or more ?image= references
+func (h *DockerHandler) SaveImage(c *gin.Context) {
+	refs := c.QueryArray("image")
+	if len(refs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "image is required"})
+		return
+	}
+
+	reader, err := h.client.ImageSave(c.Request.Context(), refs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer reader.Close()
+
+	c.Header("Content-Type", "application/x-tar")
+	c.Status(http.StatusOK)
+	streamResponse(c, reader)
+}
+
+// LoadImage runs "docker load" on the tar in the request body (optionally gzip-compressed)
+// and streams the Docker JSON output.
+func (h *DockerHandler) LoadImage(c *gin.Context) {
+	resp, err := h.client.ImageLoad(c.Request.Context(), c.Request.Body, true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer resp.Body.Close()
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Status(http.StatusOK)
+	streamResponse(c, resp.Body)
+}
+
+type PullImageRequest struct {
+	Image        string `json:"image" binding:"required"`
+	RegistryAuth string `json:"registryAuth"` // base64 X-Registry-Auth for private registries
+}
+
+func (h *DockerHandler) PullImage(c *gin.Context) {
+	var req PullImageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.pullImage(req.Image, req.RegistryAuth); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Image pulled"})
+}
+
+// StreamPullImage pulls an image and relays Docker's JSON progress messages
+// (one per line) as they arrive, so the caller can show layer progress.
+func (h *DockerHandler) StreamPullImage(c *gin.Context) {
+	var req PullImageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reader, err := h.client.ImagePull(c.Request.Context(), req.Image, image.PullOptions{RegistryAuth: req.RegistryAuth})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer reader.Close()
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Status(http.StatusOK)
+	streamResponse(c, reader)
+}
+
+type TagImageRequest struct {
+	Source string `json:"source" binding:"required"`
+	Target string `json:"target" binding:"required"`
+}
+
+func (h *DockerHandler) TagImage(c *gin.Context) {
+	var req TagImageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.client.ImageTag(h.ctx, req.Source, req.Target); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Image tagged"})
+}
+
+type PushImageRequest struct {
+	Image        string `json:"image" binding:"required"`
+	RegistryAuth string `json:"registryAuth"` // base64 X-Registry-Auth, required by the daemon even when empty
+}
+
+// StreamPushImage pushes an image and streams the Docker JSON progress messages
+func (h *DockerHandler) StreamPushImage(c *gin.Context) {
+	var req PushImageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reader, err := h.client.ImagePush(c.Request.Context(), req.Image, image.PushOptions{RegistryAuth: req.RegistryAuth})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer reader.Close()
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Status(http.StatusOK)
+	streamResponse(c, reader)
+}
+
+// BuildImage builds an image from the tar context in the request body, or from a
+// directory on this host when ?path= is set, and streams the Docker build output.
+// Registry credentials for base images come in X-Registry-Config, as with the Docker API.
+func (h *DockerHandler) BuildImage(c *gin.Context) {
+	opts := types.ImageBuildOptions{
+		Tags:       c.QueryArray("tag"),
+		Dockerfile: c.Query("dockerfile"),
+		Target:     c.Query("target"),
+		NoCache:    c.Query("noCache") == "true",
+		PullParent: c.Query("pull") == "true",
+		Remove:     true,
+		BuildArgs:  make(map[string]*string),
+	}
+	for _, arg := range c.QueryArray("buildArg") {
+		key, value, _ := strings.Cut(arg, "=")
+		if key != "" {
+			opts.BuildArgs[key] = &value
+		}
+	}
+	if header := c.GetHeader("X-Registry-Config"); header != "" {
+		data, err := base64.URLEncoding.DecodeString(header)
+		if err != nil || json.Unmarshal(data, &opts.AuthConfigs) != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid X-Registry-Config header"})
+			return
+		}
+	}
+
+	var buildContext io.Reader = c.Request.Body
+	if path := c.Query("path"); path != "" {
+		contextTar, err := tarBuildContext(path, opts.Dockerfile)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		defer contextTar.Close()
+		buildContext = contextTar
+	}
+
+	resp, err := h.client.ImageBuild(c.Request.Context(), buildContext, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer resp.Body.Close()
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Status(http.StatusOK)
+	streamResponse(c, resp.Body)
+}
+
+// tarBuildContext packs a host directory as a build context, honouring .dockerignore
+func tarBuildContext(dir, dockerfile string) (io.ReadCloser, error) {
+	if !filepath.IsAbs(dir) {
+		return nil, errors.New("build context path must be absolute")
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, errors.New("build context path must be a directory")
+	}
+
+	var excludes []string
+	if f, err := os.Open(filepath.Join(dir, ".dockerignore")); err == nil {
+		excludes, err = ignorefile.ReadAll(f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	if len(excludes) > 0 {
+		// The daemon needs the Dockerfile and .dockerignore even when they are ignored
+		if dockerfile == "" {
+			dockerfile = "Dockerfile"
+		}
+		excludes = append(excludes, "!.dockerignore", "!"+filepath.ToSlash(filepath.Clean(dockerfile)))
+	}
+
+	return archive.TarWithOptions(dir, &archive.TarOptions{ExcludePatterns: excludes})
+}
+
+// pullImage pulls an image and waits for the pull to complete.
+// Errors reported inside the progress stream are returned as well.
+func (h *DockerHandler) pullImage(ref, registryAuth string) error {
+	reader, err := h.client.ImagePull(h.ctx, ref, image.PullOptions{RegistryAuth: registryAuth})
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	decoder := json.NewDecoder(reader)
+	for {
+		var msg jsonmessage.JSONMessage
+		if err := decoder.Decode(&msg); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if msg.Error != nil {
+			return msg.Error
+		}
+	}
+}
+
+// RegistryLogin checks registry credentials from this host's Docker daemon
+func (h *DockerHandler) RegistryLogin(c *gin.Context) {
+	var req struct {
+		Username      string `json:"username" binding:"required"`
+		Password      string `json:"password" binding:"required"`
+		ServerAddress string `json:"serverAddress" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.client.RegistryLogin(h.ctx, registry.AuthConfig{
+		Username:      req.Username,
+		Password:      req.Password,
+		ServerAddress: req.ServerAddress,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": resp.Status})
+}
+
 func (h *DockerHandler) RemoveImage(c *gin.Context) {
 	id := c.Param("id")
 	force := c.Query("force") == "true"
@@ -329,6 +1171,43 @@ func (h *DockerHandler) RemoveImage(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Image removed"})
 }
 
+type RemoveImagesRequest struct {
+	IDs   []string `json:"ids" binding:"required"`
+	Force bool     `json:"force"`
+}
+
+type FailedItem struct {
+	ID    string `json:"id"`
+	Error string `json:"error"`
+}
+
+// RemoveImages deletes several images, reporting failures per image instead of
+// aborting on the first one.
+func (h *DockerHandler) RemoveImages(c *gin.Context) {
+	var req RemoveImagesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	success := make([]string, 0)
+	failed := make([]FailedItem, 0)
+	for _, id := range req.IDs {
+		if _, err := h.client.ImageRemove(h.ctx, id, image.RemoveOptions{Force: req.Force}); err != nil {
+			failed = append(failed, FailedItem{ID: id, Error: err.Error()})
+		} else {
+			success = append(success, id)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": success,
+		"failed":  failed,
+		"total":   len(req.IDs),
+		"deleted": len(success),
+	})
+}
+
 // ==================== Networks ====================
 
 type NetworkInfo struct {
@@ -375,10 +1254,11 @@ func (h *DockerHandler) GetNetwork(c *gin.Context) {
 }
 
 type CreateNetworkRequest struct {
-	Name       string `json:"name" binding:"required"`
-	Driver     string `json:"driver"`
-	Internal   bool   `json:"internal"`
-	Attachable bool   `json:"attachable"`
+	Name       string            `json:"name" binding:"required"`
+	Driver     string            `json:"driver"`
+	Internal   bool              `json:"internal"`
+	Attachable bool              `json:"attachable"`
+	Labels     map[string]string `json:"labels"`
 }
 
 func (h *DockerHandler) CreateNetwork(c *gin.Context) {
@@ -397,6 +1277,7 @@ func (h *DockerHandler) CreateNetwork(c *gin.Context) {
 		Driver:     driver,
 		Internal:   req.Internal,
 		Attachable: req.Attachable,
+		Labels:     req.Labels,
 	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -497,6 +1378,24 @@ func (h *DockerHandler) RemoveVolume(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Volume removed"})
 }
 
+// streamResponse copies r to the response, flushing after every chunk so the
+// client receives data as soon as Docker produces it.
+func streamResponse(c *gin.Context, r io.Reader) {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if _, werr := c.Writer.Write(buf[:n]); werr != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
 func parseInt(s string, def int) int {
 	if i, err := strconv.Atoi(s); err == nil {
 		return i
@@ -76,22 +76,48 @@ func main() {
 			{
 				docker.GET("/info", dockerHandler.GetInfo)
 				docker.GET("/version", dockerHandler.GetVersion)
+				docker.POST("/prune", dockerHandler.Prune)
 
 				// Containers
 				docker.GET("/containers", dockerHandler.ListContainers)
 				docker.GET("/containers/:id", dockerHandler.GetContainer)
+				docker.POST("/containers", dockerHandler.CreateContainer)
 				docker.POST("/containers/:id/start", dockerHandler.StartContainer)
 				docker.POST("/containers/:id/stop", dockerHandler.StopContainer)
 				docker.POST("/containers/:id/restart", dockerHandler.RestartContainer)
+				docker.POST("/containers/:id/update", dockerHandler.UpdateContainer)
+				docker.POST("/containers/:id/kill", dockerHandler.KillContainer)
+				docker.POST("/containers/:id/pause", dockerHandler.PauseContainer)
+				docker.POST("/containers/:id/unpause", dockerHandler.UnpauseContainer)
+				docker.GET("/containers/:id/top", dockerHandler.TopContainer)
+				docker.POST("/containers/:id/rename", dockerHandler.RenameContainer)
 				docker.DELETE("/containers/:id", dockerHandler.RemoveContainer)
 				docker.GET("/containers/:id/logs", dockerHandler.GetContainerLogs)
+				docker.GET("/containers/:id/logs/stream", dockerHandler.StreamContainerLogs)
+				docker.GET("/containers/:id/exec", dockerHandler.ExecContainer)
 				docker.GET("/containers/:id/stats", dockerHandler.GetContainerStats)
+				docker.GET("/containers/:id/stats/stream", dockerHandler.StreamContainerStats)
+				docker.GET("/containers/:id/archive", dockerHandler.GetArchive)
+				docker.PUT("/containers/:id/archive", dockerHandler.PutArchive)
+				docker.GET("/containers/:id/changes", dockerHandler.GetContainerChanges)
 
 				// Images
 				docker.GET("/images", dockerHandler.ListImages)
+				docker.POST("/images/pull", dockerHandler.PullImage)
+				docker.POST("/images/pull/stream", dockerHandler.StreamPullImage)
+				docker.POST("/images/build", dockerHandler.BuildImage)
+				docker.POST("/images/tag", dockerHandler.TagImage)
+				docker.POST("/images/push/stream", dockerHandler.StreamPushImage)
+				docker.GET("/images/save", dockerHandler.SaveImage)
+				docker.POST("/images/load", dockerHandler.LoadImage)
 				docker.GET("/images/:id", dockerHandler.GetImage)
+				docker.GET("/images/:id/history", dockerHandler.GetImageHistory)
+				docker.DELETE("/images/bulk", dockerHandler.RemoveImages) // must be registered before /images/:id
 				docker.DELETE("/images/:id", dockerHandler.RemoveImage)
 
+				// Registries
+				docker.POST("/registry/login", dockerHandler.RegistryLogin)
+
 				// Networks
 				docker.GET("/networks", dockerHandler.ListNetworks)
 				docker.GET("/networks/:id", dockerHandler.GetNetwork)
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"appdock/internal/models"
+	"appdock/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AutoUpdateHandler struct {
+	store   *services.AutoUpdateStore
+	updater *services.AutoUpdater
+}
+
+func NewAutoUpdateHandler(store *services.AutoUpdateStore, updater *services.AutoUpdater) *AutoUpdateHandler {
+	return &AutoUpdateHandler{
+		store:   store,
+		updater: updater,
+	}
+}
+
+func (h *AutoUpdateHandler) available(c *gin.Context) bool {
+	if h.store == nil || h.updater == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Auto-update không khả dụng"})
+		return false
+	}
+	return true
+}
+
+// GetSettings trả về cấu hình auto-update (khung giờ, healthcheck, thông báo)
+func (h *AutoUpdateHandler) GetSettings(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	c.JSON(http.StatusOK, h.store.Settings())
+}
+
+// UpdateSettings cập nhật cấu hình auto-update
+func (h *AutoUpdateHandler) UpdateSettings(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	var req models.AutoUpdateSettings
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dữ liệu không hợp lệ"})
+		return
+	}
+
+	settings, err := h.store.UpdateSettings(req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidScheduleWindow) || errors.Is(err, services.ErrInvalidTimezone) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, settings)
+}
+
+// ListContainers trả về các container đang chạy kèm chính sách auto-update hiệu lực
+func (h *AutoUpdateHandler) ListContainers(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	containers, err := h.updater.Containers(GetServerIDFromRequest(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, containers)
+}
+
+// SetPolicy bật/tắt auto-update cho container (theo tên), ghi đè label appdock.autoupdate
+func (h *AutoUpdateHandler) SetPolicy(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	var req models.SetAutoUpdatePolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dữ liệu không hợp lệ"})
+		return
+	}
+
+	policy, err := h.store.SetPolicy(GetServerIDFromRequest(c), c.Param("name"), req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidScheduleWindow) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, policy)
+}
+
+// DeletePolicy xóa chính sách API, container quay lại theo label
+func (h *AutoUpdateHandler) DeletePolicy(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	if err := h.store.DeletePolicy(GetServerIDFromRequest(c), c.Param("name")); err != nil {
+		if errors.Is(err, services.ErrAutoUpdatePolicyNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Đã xóa chính sách auto-update"})
+}
+
+// RunNow cập nhật ngay các container đã bật auto-update trên server hiện tại (bỏ qua khung giờ)
+func (h *AutoUpdateHandler) RunNow(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	events, err := h.updater.RunNow(GetServerIDFromRequest(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, events)
+}
+
+// GetHistory trả về lịch sử auto-update, ?all=true cho mọi server
+func (h *AutoUpdateHandler) GetHistory(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	serverID := ""
+	if c.Query("all") != "true" {
+		serverID = GetServerIDFromRequest(c)
+		if serverID == "" {
+			serverID = "local"
+		}
+	}
+	c.JSON(http.StatusOK, h.store.History(serverID))
+}
+
+// TestNotification gửi thông báo thử tới webhook đã cấu hình
+func (h *AutoUpdateHandler) TestNotification(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	if err := h.updater.TestNotification(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Đã gửi thông báo thử"})
+}
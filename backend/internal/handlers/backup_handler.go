@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"appdock/internal/models"
+	"appdock/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BackupHandler struct {
+	serverManager *services.ServerManager
+	store         *services.BackupStore
+	scheduler     *services.BackupScheduler
+}
+
+func NewBackupHandler(sm *services.ServerManager, store *services.BackupStore, scheduler *services.BackupScheduler) *BackupHandler {
+	return &BackupHandler{
+		serverManager: sm,
+		store:         store,
+		scheduler:     scheduler,
+	}
+}
+
+func (h *BackupHandler) available(c *gin.Context) bool {
+	if h.store == nil || h.scheduler == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Volume backup không khả dụng"})
+		return false
+	}
+	return true
+}
+
+func backupErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrInvalidBackupName):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrBackupNotFound), errors.Is(err, services.ErrBackupScheduleNotFound),
+		errors.Is(err, services.ErrVolumeNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ListBackups trả về các bản backup đã lưu của volume, mới nhất trước
+func (h *BackupHandler) ListBackups(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	backups, err := h.store.ListBackups(GetServerIDFromRequest(c), c.Param("name"))
+	if err != nil {
+		c.JSON(backupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, backups)
+}
+
+// CreateBackup backup volume ngay vào data dir của AppDock
+func (h *BackupHandler) CreateBackup(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	backup, err := h.scheduler.Backup(GetServerIDFromRequest(c), c.Param("name"))
+	if err != nil {
+		c.JSON(backupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, backup)
+}
+
+// DownloadBackup tải về một bản backup đã lưu
+func (h *BackupHandler) DownloadBackup(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	file, err := h.store.OpenBackup(GetServerIDFromRequest(c), c.Param("name"), c.Param("file"))
+	if err != nil {
+		c.JSON(backupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.DataFromReader(http.StatusOK, info.Size(), "application/gzip", file, map[string]string{
+		"Content-Disposition": `attachment; filename="` + c.Param("file") + `"`,
+	})
+}
+
+// DeleteBackup xóa một bản backup đã lưu
+func (h *BackupHandler) DeleteBackup(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	if err := h.store.DeleteBackup(GetServerIDFromRequest(c), c.Param("name"), c.Param("file")); err != nil {
+		c.JSON(backupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Đã xóa bản backup"})
+}
+
+// RestoreBackup restore bản backup đã lưu vào volume gốc, hoặc volume khác (tạo mới nếu chưa có)
+func (h *BackupHandler) RestoreBackup(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	serverID := GetServerIDFromRequest(c)
+	var req struct {
+		Volume string `json:"volume"` // để trống = volume gốc
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Dữ liệu không hợp lệ"})
+			return
+		}
+	}
+	target := req.Volume
+	if target == "" {
+		target = c.Param("name")
+	}
+
+	file, err := h.store.OpenBackup(serverID, c.Param("name"), c.Param("file"))
+	if err != nil {
+		c.JSON(backupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	created, err := h.serverManager.RestoreVolume(serverID, target, file)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Volume đã được restore", "volume": target, "created": created})
+}
+
+// ListSchedules trả về lịch backup của server hiện tại, ?all=true cho mọi server
+func (h *BackupHandler) ListSchedules(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	serverID := ""
+	if c.Query("all") != "true" {
+		serverID = GetServerIDFromRequest(c)
+		if serverID == "" {
+			serverID = "local"
+		}
+	}
+	c.JSON(http.StatusOK, h.store.ListSchedules(serverID))
+}
+
+// CreateSchedule tạo lịch backup định kỳ cho volume trên server hiện tại
+func (h *BackupHandler) CreateSchedule(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	var req models.CreateBackupScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Vui lòng cung cấp tên volume"})
+		return
+	}
+
+	schedule, err := h.store.CreateSchedule(GetServerIDFromRequest(c), req)
+	if err != nil {
+		c.JSON(backupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, schedule)
+}
+
+// UpdateSchedule cập nhật chu kỳ, retention hoặc bật/tắt lịch backup
+func (h *BackupHandler) UpdateSchedule(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	var req models.UpdateBackupScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dữ liệu không hợp lệ"})
+		return
+	}
+
+	schedule, err := h.store.UpdateSchedule(c.Param("id"), req)
+	if err != nil {
+		c.JSON(backupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, schedule)
+}
+
+// DeleteSchedule xóa lịch backup, các bản backup đã lưu được giữ lại
+func (h *BackupHandler) DeleteSchedule(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	if err := h.store.DeleteSchedule(c.Param("id")); err != nil {
+		c.JSON(backupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Đã xóa lịch backup"})
+}
+
+// RunSchedule chạy lịch backup ngay
+func (h *BackupHandler) RunSchedule(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	backup, err := h.scheduler.RunSchedule(c.Param("id"))
+	if err != nil {
+		c.JSON(backupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, backup)
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http"
+
+	"appdock/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ComposeHandler struct {
+	serverManager *services.ServerManager
+}
+
+func NewComposeHandler(sm *services.ServerManager) *ComposeHandler {
+	return &ComposeHandler{serverManager: sm}
+}
+
+// ListProjects trả về danh sách compose projects (nhóm theo nhãn com.docker.compose.project)
+func (h *ComposeHandler) ListProjects(c *gin.Context) {
+	serverID := GetServerIDFromRequest(c)
+	projects, err := h.serverManager.ListComposeProjects(serverID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, projects)
+}
+
+// StartProject khởi động toàn bộ containers của project
+func (h *ComposeHandler) StartProject(c *gin.Context) {
+	h.runAction(c, services.ComposeActionStart)
+}
+
+// StopProject dừng toàn bộ containers của project
+func (h *ComposeHandler) StopProject(c *gin.Context) {
+	h.runAction(c, services.ComposeActionStop)
+}
+
+// RestartProject khởi động lại toàn bộ containers của project
+func (h *ComposeHandler) RestartProject(c *gin.Context) {
+	h.runAction(c, services.ComposeActionRestart)
+}
+
+// PullProject pull image của tất cả services trong project
+func (h *ComposeHandler) PullProject(c *gin.Context) {
+	h.runAction(c, services.ComposeActionPull)
+}
+
+// RemoveProject xóa toàn bộ containers của project
+func (h *ComposeHandler) RemoveProject(c *gin.Context) {
+	h.runAction(c, services.ComposeActionRemove)
+}
+
+func (h *ComposeHandler) runAction(c *gin.Context, action string) {
+	serverID := GetServerIDFromRequest(c)
+	project := c.Param("project")
+	result, err := h.serverManager.ComposeProjectAction(serverID, project, action)
+	if err != nil {
+		if err == services.ErrComposeProjectNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
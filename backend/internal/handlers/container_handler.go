@@ -1,11 +1,15 @@
 package handlers
 
 import (
-	"bufio"
+	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
+	"path"
+	"strconv"
 	"strings"
+	"time"
 
 	"appdock/internal/middleware"
 	"appdock/internal/services"
@@ -46,6 +50,33 @@ func (h *ContainerHandler) GetContainer(c *gin.Context) {
 	c.JSON(http.StatusOK, container)
 }
 
+// CreateContainer tạo container mới (và chạy ngay nếu start = true)
+func (h *ContainerHandler) CreateContainer(c *gin.Context) {
+	serverID := GetServerIDFromRequest(c)
+	var req services.CreateContainerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dữ liệu không hợp lệ"})
+		return
+	}
+
+	if req.Image == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Vui lòng cung cấp tên image"})
+		return
+	}
+
+	result, err := h.serverManager.CreateContainer(serverID, req)
+	if err != nil {
+		if result != nil {
+			// Container đã được tạo nhưng không start được
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "id": result.ID})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, result)
+}
+
 // StartContainer khởi động một container
 func (h *ContainerHandler) StartContainer(c *gin.Context) {
 	serverID := GetServerIDFromRequest(c)
@@ -57,28 +88,140 @@ func (h *ContainerHandler) StartContainer(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Container đã được khởi động"})
 }
 
-// StopContainer dừng một container
+// stopTimeout đọc ?timeout= (giây, -1 = chờ không giới hạn). Không có thì dùng stop timeout của container.
+func stopTimeout(c *gin.Context) (*int, bool) {
+	value := c.Query("timeout")
+	if value == "" {
+		return nil, true
+	}
+	timeout, err := strconv.Atoi(value)
+	if err != nil || timeout < -1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "timeout không hợp lệ"})
+		return nil, false
+	}
+	return &timeout, true
+}
+
+// StopContainer dừng một container (?timeout= số giây chờ trước khi kill)
 func (h *ContainerHandler) StopContainer(c *gin.Context) {
 	serverID := GetServerIDFromRequest(c)
 	id := c.Param("id")
-	if err := h.serverManager.StopContainer(serverID, id); err != nil {
+	timeout, ok := stopTimeout(c)
+	if !ok {
+		return
+	}
+	if err := h.serverManager.StopContainer(serverID, id, timeout); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Container đã được dừng"})
 }
 
-// RestartContainer khởi động lại một container
+// RestartContainer khởi động lại một container (?timeout= như StopContainer)
 func (h *ContainerHandler) RestartContainer(c *gin.Context) {
 	serverID := GetServerIDFromRequest(c)
 	id := c.Param("id")
-	if err := h.serverManager.RestartContainer(serverID, id); err != nil {
+	timeout, ok := stopTimeout(c)
+	if !ok {
+		return
+	}
+	if err := h.serverManager.RestartContainer(serverID, id, timeout); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Container đã được khởi động lại"})
 }
 
+// UpdateContainer đổi giới hạn tài nguyên và restart policy mà không cần tạo lại container
+func (h *ContainerHandler) UpdateContainer(c *gin.Context) {
+	serverID := GetServerIDFromRequest(c)
+	id := c.Param("id")
+	var req services.UpdateContainerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dữ liệu không hợp lệ"})
+		return
+	}
+
+	warnings, err := h.serverManager.UpdateContainer(serverID, id, req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrInvalidContainerUpdate) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	if warnings == nil {
+		warnings = []string{}
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Container đã được cập nhật", "warnings": warnings})
+}
+
+// KillContainer gửi signal tới process chính của container ({signal}, mặc định SIGKILL),
+// e.g. SIGHUP để reload config
+func (h *ContainerHandler) KillContainer(c *gin.Context) {
+	serverID := GetServerIDFromRequest(c)
+	id := c.Param("id")
+	var req struct {
+		Signal string `json:"signal"`
+	}
+	// Body không bắt buộc (ContentLength = -1 khi gửi chunked)
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Dữ liệu không hợp lệ"})
+			return
+		}
+	}
+	signal := strings.ToUpper(strings.TrimSpace(req.Signal))
+	if signal == "" {
+		signal = "SIGKILL"
+	}
+
+	if err := h.serverManager.KillContainer(serverID, id, signal); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrInvalidSignal) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Đã gửi " + signal + " tới container", "signal": signal})
+}
+
+// PauseContainer tạm dừng mọi process của container (cgroup freezer)
+func (h *ContainerHandler) PauseContainer(c *gin.Context) {
+	serverID := GetServerIDFromRequest(c)
+	id := c.Param("id")
+	if err := h.serverManager.PauseContainer(serverID, id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Container đã được tạm dừng"})
+}
+
+// UnpauseContainer tiếp tục container đang tạm dừng
+func (h *ContainerHandler) UnpauseContainer(c *gin.Context) {
+	serverID := GetServerIDFromRequest(c)
+	id := c.Param("id")
+	if err := h.serverManager.UnpauseContainer(serverID, id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Container đã được tiếp tục"})
+}
+
+// TopContainer trả về các process đang chạy trong container (?psArgs= thay tham số ps mặc định)
+func (h *ContainerHandler) TopContainer(c *gin.Context) {
+	serverID := GetServerIDFromRequest(c)
+	id := c.Param("id")
+	top, err := h.serverManager.TopContainer(serverID, id, c.Query("psArgs"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, top)
+}
+
 // RemoveContainer xóa một container
 func (h *ContainerHandler) RemoveContainer(c *gin.Context) {
 	serverID := GetServerIDFromRequest(c)
@@ -91,6 +234,54 @@ func (h *ContainerHandler) RemoveContainer(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Container đã được xóa"})
 }
 
+// RecreateContainer tạo lại container với image mới (pull, giữ nguyên cấu hình, rollback khi lỗi)
+func (h *ContainerHandler) RecreateContainer(c *gin.Context) {
+	serverID := GetServerIDFromRequest(c)
+	id := c.Param("id")
+	var opts services.RecreateOptions
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&opts); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Dữ liệu không hợp lệ"})
+			return
+		}
+	}
+
+	result, err := h.serverManager.RecreateContainer(serverID, id, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// MigrateContainer chuyển container (cấu hình, image, dữ liệu volume) sang server khác.
+// dryRun = true chỉ trả về kế hoạch: image, volume, network sẽ chuyển, cảnh báo và xung đột.
+func (h *ContainerHandler) MigrateContainer(c *gin.Context) {
+	serverID := GetServerIDFromRequest(c)
+	id := c.Param("id")
+	var opts services.MigrateOptions
+	if err := c.ShouldBindJSON(&opts); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dữ liệu không hợp lệ"})
+		return
+	}
+
+	result, err := h.serverManager.MigrateContainer(serverID, id, opts)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrSameServerTransfer):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrMigrationConflict):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "plan": result.MigrationPlan})
+		case errors.Is(err, services.ErrServerNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
 // GetContainerLogs trả về logs của một container
 func (h *ContainerHandler) GetContainerLogs(c *gin.Context) {
 	serverID := GetServerIDFromRequest(c)
@@ -116,6 +307,103 @@ func (h *ContainerHandler) GetContainerStats(c *gin.Context) {
 	c.JSON(http.StatusOK, stats)
 }
 
+// DownloadArchive tải về file hoặc thư mục trong container dạng tar (?path=, giống "docker cp" ra ngoài)
+func (h *ContainerHandler) DownloadArchive(c *gin.Context) {
+	serverID := GetServerIDFromRequest(c)
+	id := c.Param("id")
+	srcPath := c.Query("path")
+	if srcPath == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Vui lòng cung cấp path"})
+		return
+	}
+
+	reader, stat, err := h.serverManager.CopyFromContainer(serverID, id, srcPath)
+	if err != nil {
+		c.JSON(archiveErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	defer reader.Close()
+
+	name := path.Base(srcPath)
+	if stat != nil && stat.Name != "" {
+		name = stat.Name
+	}
+	if name == "/" || name == "." {
+		name = "root"
+	}
+	c.DataFromReader(http.StatusOK, -1, "application/x-tar", reader, map[string]string{
+		"Content-Disposition": `attachment; filename="` + strings.ReplaceAll(name, `"`, "_") + `.tar"`,
+	})
+}
+
+// UploadArchive ghi vào thư mục ?path= trong container. Body là tar (có thể nén gzip) được
+// giải nén vào thư mục, hoặc multipart/form-data với một hay nhiều field "file".
+// copyUIDGID=true chown file cho user của container (giống "docker cp -a").
+func (h *ContainerHandler) UploadArchive(c *gin.Context) {
+	serverID := GetServerIDFromRequest(c)
+	id := c.Param("id")
+	dstPath := c.Query("path")
+	copyUIDGID := c.Query("copyUIDGID") == "true"
+	if dstPath == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Vui lòng cung cấp path thư mục đích"})
+		return
+	}
+
+	if !strings.HasPrefix(c.ContentType(), "multipart/") {
+		if err := h.serverManager.CopyToContainer(serverID, id, dstPath, c.Request.Body, copyUIDGID); err != nil {
+			c.JSON(archiveErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Đã upload vào container"})
+		return
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil || len(form.File["file"]) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Vui lòng upload file (field \"file\")"})
+		return
+	}
+	files := make([]services.ContainerUploadFile, 0, len(form.File["file"]))
+	for _, header := range form.File["file"] {
+		file, err := header.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		defer file.Close()
+		files = append(files, services.ContainerUploadFile{
+			Name:    path.Base(header.Filename),
+			Size:    header.Size,
+			Content: file,
+		})
+	}
+
+	if err := h.serverManager.UploadToContainer(serverID, id, dstPath, files, copyUIDGID); err != nil {
+		c.JSON(archiveErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Đã upload vào container", "files": len(files)})
+}
+
+func archiveErrorStatus(err error) int {
+	if errors.Is(err, services.ErrContainerPathNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// GetContainerChanges trả về các file đã thêm, sửa, xóa trong container so với image
+func (h *ContainerHandler) GetContainerChanges(c *gin.Context) {
+	serverID := GetServerIDFromRequest(c)
+	id := c.Param("id")
+	changes, err := h.serverManager.GetContainerChanges(serverID, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, changes)
+}
+
 var upgrader = websocket.Upgrader{
 	CheckOrigin:     middleware.WebSocketCheckOrigin(),
 	ReadBufferSize:  1024,
@@ -132,15 +420,16 @@ func upgradeWebSocket(c *gin.Context) (*websocket.Conn, error) {
 	return upgrader.Upgrade(c.Writer, c.Request, hdr)
 }
 
-// StreamLogs stream logs qua WebSocket (only supports local server)
+// StreamLogs stream logs qua WebSocket (local và remote server qua agent)
+// Query params: follow (mặc định true), tail (mặc định 100), since
 func (h *ContainerHandler) StreamLogs(c *gin.Context) {
 	serverID := GetServerIDFromRequest(c)
-	if !h.serverManager.IsLocal(serverID) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "WebSocket streaming only supported for local server"})
-		return
-	}
-
 	id := c.Param("id")
+	opts := services.LogStreamOptions{
+		Follow: c.DefaultQuery("follow", "true") != "false",
+		Tail:   c.DefaultQuery("tail", "100"),
+		Since:  c.Query("since"),
+	}
 
 	conn, err := upgradeWebSocket(c)
 	if err != nil {
@@ -148,7 +437,13 @@ func (h *ContainerHandler) StreamLogs(c *gin.Context) {
 	}
 	defer conn.Close()
 
-	reader, err := h.serverManager.GetLocalDocker().StreamContainerLogs(id)
+	tty, err := h.serverManager.ContainerHasTTY(serverID, id)
+	if err != nil {
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"error":"`+err.Error()+`"}`))
+		return
+	}
+
+	reader, err := h.serverManager.StreamContainerLogs(serverID, id, opts)
 	if err != nil {
 		conn.WriteMessage(websocket.TextMessage, []byte(`{"error":"`+err.Error()+`"}`))
 		return
@@ -174,22 +469,19 @@ func (h *ContainerHandler) StreamLogs(c *gin.Context) {
 	}()
 
 	// Channel để nhận log lines
-	logChan := make(chan string, 100)
+	logChan := make(chan services.LogLine, 100)
 
-	// Goroutine để đọc logs từ Docker
+	// Goroutine để demux logs từ Docker (tách stdout/stderr, giữ timestamp)
 	go func() {
-		scanner := bufio.NewScanner(reader)
-		buf := make([]byte, 0, 64*1024)
-		scanner.Buffer(buf, 1024*1024)
-
-		for scanner.Scan() {
+		services.DemuxLogs(reader, tty, func(line services.LogLine) error {
 			select {
 			case <-done:
-				return
-			case logChan <- scanner.Text():
+				return services.ErrStreamClosed
+			case logChan <- line:
+				return nil
 			}
-		}
-		// Scanner kết thúc (container dừng hoặc lỗi)
+		})
+		// Stream kết thúc (container dừng hoặc lỗi)
 		close(logChan)
 	}()
 
@@ -205,30 +497,33 @@ func (h *ContainerHandler) StreamLogs(c *gin.Context) {
 				conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"closed","data":"Container logs stream ended"}`))
 				return
 			}
-			cleanLine := cleanLogLine(line)
-			if cleanLine != "" {
-				msg := map[string]string{
-					"type": "log",
-					"data": cleanLine,
-				}
-				jsonMsg, _ := json.Marshal(msg)
-				if err := conn.WriteMessage(websocket.TextMessage, jsonMsg); err != nil {
-					return
-				}
+			if strings.TrimSpace(line.Data) == "" {
+				continue
+			}
+			msg := map[string]string{
+				"type":      "log",
+				"stream":    line.Stream,
+				"timestamp": line.Timestamp,
+				"data":      line.Data,
+			}
+			jsonMsg, _ := json.Marshal(msg)
+			if err := conn.WriteMessage(websocket.TextMessage, jsonMsg); err != nil {
+				return
 			}
 		}
 	}
 }
 
-// ExecTerminal tạo terminal session qua WebSocket (only supports local server)
-func (h *ContainerHandler) ExecTerminal(c *gin.Context) {
+// StreamStats stream stats qua WebSocket cho một container (/ws/containers/:id/stats) hoặc
+// mọi container đang chạy trên server (/ws/containers/stats).
+// Query params: interval (giây giữa hai lần gửi, mặc định 1)
+func (h *ContainerHandler) StreamStats(c *gin.Context) {
 	serverID := GetServerIDFromRequest(c)
-	if !h.serverManager.IsLocal(serverID) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "WebSocket terminal only supported for local server"})
-		return
-	}
-
 	id := c.Param("id")
+	interval := time.Second
+	if seconds, err := strconv.Atoi(c.Query("interval")); err == nil && seconds > 0 {
+		interval = time.Duration(seconds) * time.Second
+	}
 
 	conn, err := upgradeWebSocket(c)
 	if err != nil {
@@ -236,24 +531,68 @@ func (h *ContainerHandler) ExecTerminal(c *gin.Context) {
 	}
 	defer conn.Close()
 
-	// Tạo exec session
-	execID, err := h.serverManager.GetLocalDocker().CreateExec(id)
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+
+	// Goroutine để đọc từ client, hủy stream khi client đóng kết nối
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	err = h.serverManager.WatchContainerStats(ctx, serverID, id, interval, func(batch services.ContainerStatsBatch) error {
+		jsonMsg, _ := json.Marshal(struct {
+			Type string `json:"type"`
+			services.ContainerStatsBatch
+		}{"stats", batch})
+		return conn.WriteMessage(websocket.TextMessage, jsonMsg)
+	})
 	if err != nil {
-		msg := map[string]string{"type": "error", "data": err.Error()}
-		jsonMsg, _ := json.Marshal(msg)
+		jsonMsg, _ := json.Marshal(gin.H{"error": err.Error()})
 		conn.WriteMessage(websocket.TextMessage, jsonMsg)
 		return
 	}
+	if ctx.Err() == nil {
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"closed","data":"Container stats stream ended"}`))
+	}
+}
 
-	// Attach vào exec session
-	hijackedResp, err := h.serverManager.GetLocalDocker().AttachExec(execID)
+// ExecTerminal tạo terminal session qua WebSocket (local và remote server qua agent)
+// Query params: cmd (lặp lại, mỗi tham số một argument; một giá trị duy nhất được tách theo
+// khoảng trắng, e.g. cmd=/bin/bash), user, workdir, env (lặp lại KEY=VALUE)
+func (h *ContainerHandler) ExecTerminal(c *gin.Context) {
+	serverID := GetServerIDFromRequest(c)
+	id := c.Param("id")
+	cmd := c.QueryArray("cmd")
+	if len(cmd) == 1 {
+		cmd = strings.Fields(cmd[0])
+	}
+	opts := services.ExecOptions{
+		Cmd:        cmd,
+		User:       c.Query("user"),
+		WorkingDir: c.Query("workdir"),
+		Env:        c.QueryArray("env"),
+	}
+
+	conn, err := upgradeWebSocket(c)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	// Tạo exec session và attach
+	session, err := h.serverManager.OpenExecSession(serverID, id, opts)
 	if err != nil {
 		msg := map[string]string{"type": "error", "data": err.Error()}
 		jsonMsg, _ := json.Marshal(msg)
 		conn.WriteMessage(websocket.TextMessage, jsonMsg)
 		return
 	}
-	defer hijackedResp.Close()
+	defer session.Close()
 
 	// Send welcome message
 	welcomeMsg := map[string]string{
@@ -268,8 +607,11 @@ func (h *ContainerHandler) ExecTerminal(c *gin.Context) {
 	go func() {
 		defer close(done)
 		buf := make([]byte, 4096)
+		// Exec luôn chạy với TTY nên output là raw stream (không có frame header);
+		// chỉ cần tránh cắt giữa ký tự UTF-8 khi một rune nằm ở hai lần đọc
+		var chunker services.UTF8Chunker
 		for {
-			n, err := hijackedResp.Reader.Read(buf)
+			n, err := session.Read(buf)
 			if err != nil {
 				if err != io.EOF {
 					msg := map[string]string{"type": "error", "data": "Connection closed"}
@@ -279,13 +621,14 @@ func (h *ContainerHandler) ExecTerminal(c *gin.Context) {
 				return
 			}
 			if n > 0 {
-				// Loại bỏ header bytes từ Docker multiplexed stream (8 bytes đầu)
-				data := buf[:n]
-				// Docker stream có thể có header, cố gắng parse
-				output := cleanTerminalOutput(data)
+				output := chunker.Next(buf[:n])
+				if output == "" {
+					continue
+				}
 				msg := map[string]string{
-					"type": "output",
-					"data": output,
+					"type":   "output",
+					"stream": services.StreamStdout,
+					"data":   output,
 				}
 				jsonMsg, _ := json.Marshal(msg)
 				if err := conn.WriteMessage(websocket.TextMessage, jsonMsg); err != nil {
@@ -309,58 +652,20 @@ func (h *ContainerHandler) ExecTerminal(c *gin.Context) {
 			var input struct {
 				Type string `json:"type"`
 				Data string `json:"data"`
+				Cols uint   `json:"cols"`
+				Rows uint   `json:"rows"`
 			}
 			if err := json.Unmarshal(message, &input); err != nil {
 				// Nếu không parse được JSON, gửi trực tiếp
-				hijackedResp.Conn.Write(message)
+				session.Write(message)
 				continue
 			}
 
 			if input.Type == "input" {
-				hijackedResp.Conn.Write([]byte(input.Data))
-			} else if input.Type == "resize" {
-				// Handle terminal resize (optional)
-				// Cần parse width, height từ data
-			}
-		}
-	}
-}
-
-// cleanLogLine loại bỏ các ký tự control từ Docker logs
-func cleanLogLine(line string) string {
-	// Docker logs có thể có header 8 bytes
-	if len(line) > 8 {
-		// Kiểm tra nếu có Docker stream header
-		header := line[0]
-		if header == 0x01 || header == 0x02 { // stdout hoặc stderr
-			return strings.TrimSpace(line[8:])
-		}
-	}
-	return strings.TrimSpace(line)
-} // cleanTerminalOutput xử lý output từ Docker exec
-func cleanTerminalOutput(data []byte) string {
-	// Docker multiplexed stream có header 8 bytes cho mỗi frame
-	// [type][0][0][0][size1][size2][size3][size4][payload]
-	result := ""
-	i := 0
-	for i < len(data) {
-		if i+8 <= len(data) {
-			// Kiểm tra nếu có header
-			header := data[i]
-			if header == 0x01 || header == 0x02 { // stdout hoặc stderr
-				// Đọc size từ bytes 4-7 (big endian)
-				size := int(data[i+4])<<24 | int(data[i+5])<<16 | int(data[i+6])<<8 | int(data[i+7])
-				i += 8
-				if i+size <= len(data) {
-					result += string(data[i : i+size])
-					i += size
-					continue
-				}
+				session.Write([]byte(input.Data))
+			} else if input.Type == "resize" && input.Cols > 0 && input.Rows > 0 {
+				session.Resize(input.Rows, input.Cols)
 			}
 		}
-		// Nếu không có header, lấy hết data còn lại
-		result += string(data[i:])
-		break
 	}
-	return result
 }
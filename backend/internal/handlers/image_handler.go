@@ -1,19 +1,30 @@
 package handlers
 
 import (
+	"errors"
+	"io"
 	"net/http"
+	"strings"
+	"time"
 
+	"appdock/internal/models"
 	"appdock/internal/services"
 
+	"github.com/docker/docker/api/types/registry"
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
 )
 
 type ImageHandler struct {
 	serverManager *services.ServerManager
+	buildContexts *services.BuildContextStore
 }
 
-func NewImageHandler(sm *services.ServerManager) *ImageHandler {
-	return &ImageHandler{serverManager: sm}
+func NewImageHandler(sm *services.ServerManager, buildContexts *services.BuildContextStore) *ImageHandler {
+	return &ImageHandler{
+		serverManager: sm,
+		buildContexts: buildContexts,
+	}
 }
 
 // ListImages trả về danh sách tất cả images
@@ -39,6 +50,18 @@ func (h *ImageHandler) GetImage(c *gin.Context) {
 	c.JSON(http.StatusOK, image)
 }
 
+// GetImageDetails trả về cấu hình đầy đủ (entrypoint, cmd, env, ports, healthcheck)
+// và lịch sử layer kèm lệnh tạo ra và kích thước của từng layer
+func (h *ImageHandler) GetImageDetails(c *gin.Context) {
+	serverID := GetServerIDFromRequest(c)
+	details, err := h.serverManager.GetImageDetails(serverID, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, details)
+}
+
 // RemoveImage xóa một image
 func (h *ImageHandler) RemoveImage(c *gin.Context) {
 	serverID := GetServerIDFromRequest(c)
@@ -51,37 +74,291 @@ func (h *ImageHandler) RemoveImage(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Image đã được xóa"})
 }
 
-// PullImage pull một image từ registry (only local server)
+// PullImage pull một image từ registry (local và remote server qua agent).
+// Request chờ tới khi pull xong; dùng WebSocket /ws/images/pull để theo dõi tiến trình.
 func (h *ImageHandler) PullImage(c *gin.Context) {
 	serverID := GetServerIDFromRequest(c)
-	if !h.serverManager.IsLocal(serverID) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Pull image only supported for local server"})
+	var req struct {
+		Image string `json:"image" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Vui lòng cung cấp tên image"})
 		return
 	}
 
+	if err := h.serverManager.PullImage(serverID, req.Image); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Image đã được tải về"})
+}
+
+// PullImageStream pull image và gửi tiến trình từng layer qua WebSocket.
+// Query params: image (bắt buộc).
+// Messages: {"type":"progress", id, status, progress, current, total},
+// {"type":"complete","image"} hoặc {"type":"error","error"}.
+func (h *ImageHandler) PullImageStream(c *gin.Context) {
+	serverID := GetServerIDFromRequest(c)
+	ref := strings.TrimSpace(c.Query("image"))
+
+	conn, err := upgradeWebSocket(c)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	if ref == "" {
+		conn.WriteJSON(gin.H{"type": "error", "error": "Vui lòng cung cấp tên image"})
+		return
+	}
+
+	reader, err := h.serverManager.StreamPullImage(serverID, ref)
+	if err != nil {
+		conn.WriteJSON(gin.H{"type": "error", "error": err.Error()})
+		return
+	}
+	defer reader.Close()
+
+	if _, err := streamProgress(conn, reader); err != nil {
+		conn.WriteJSON(gin.H{"type": "error", "error": err.Error()})
+		return
+	}
+	conn.WriteJSON(gin.H{"type": "complete", "image": ref})
+}
+
+// UploadBuildContext nhận build context (tar hoặc tar.gz) để build qua WS /ws/images/build.
+// Body là file tar (application/x-tar) hoặc multipart/form-data với field "context".
+func (h *ImageHandler) UploadBuildContext(c *gin.Context) {
+	if h.buildContexts == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Build context store không khả dụng"})
+		return
+	}
+
+	var body io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		file, _, err := c.Request.FormFile("context")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Vui lòng upload build context (field \"context\")"})
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	id, size, err := h.buildContexts.Save(body)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if size == 0 {
+		if reader, err := h.buildContexts.Take(id); err == nil {
+			reader.Close()
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Build context rỗng"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"contextId": id, "size": size})
+}
+
+// BuildImageStream build image và gửi output qua WebSocket.
+// Query params: context (ID từ UploadBuildContext) hoặc path (thư mục trên server),
+// tag (lặp lại được), buildArg=KEY=VALUE (lặp lại được), dockerfile, target, noCache, pull.
+// Messages: {"type":"progress", stream, id, status, ...},
+// {"type":"complete","imageId","tags"} hoặc {"type":"error","error"}.
+func (h *ImageHandler) BuildImageStream(c *gin.Context) {
+	serverID := GetServerIDFromRequest(c)
+	opts := services.BuildImageOptions{
+		Tags:        c.QueryArray("tag"),
+		Dockerfile:  c.Query("dockerfile"),
+		Target:      c.Query("target"),
+		NoCache:     c.Query("noCache") == "true",
+		Pull:        c.Query("pull") == "true",
+		ContextPath: strings.TrimSpace(c.Query("path")),
+		BuildArgs:   make(map[string]string),
+	}
+	for _, arg := range c.QueryArray("buildArg") {
+		key, value, _ := strings.Cut(arg, "=")
+		if key != "" {
+			opts.BuildArgs[key] = value
+		}
+	}
+	contextID := c.Query("context")
+
+	conn, err := upgradeWebSocket(c)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	if (contextID == "") == (opts.ContextPath == "") {
+		conn.WriteJSON(gin.H{"type": "error", "error": "Vui lòng cung cấp context (đã upload) hoặc path trên server"})
+		return
+	}
+
+	// buildContext để nil khi build từ thư mục trên server
+	var buildContext io.Reader
+	if contextID != "" {
+		if h.buildContexts == nil {
+			conn.WriteJSON(gin.H{"type": "error", "error": services.ErrBuildContextNotFound.Error()})
+			return
+		}
+		reader, err := h.buildContexts.Take(contextID)
+		if err != nil {
+			conn.WriteJSON(gin.H{"type": "error", "error": err.Error()})
+			return
+		}
+		defer reader.Close()
+		buildContext = reader
+	}
+
+	reader, err := h.serverManager.BuildImage(serverID, buildContext, opts)
+	if err != nil {
+		conn.WriteJSON(gin.H{"type": "error", "error": err.Error()})
+		return
+	}
+	defer reader.Close()
+
+	imageID, err := streamProgress(conn, reader)
+	if err != nil {
+		conn.WriteJSON(gin.H{"type": "error", "error": err.Error()})
+		return
+	}
+	conn.WriteJSON(gin.H{"type": "complete", "imageId": imageID, "tags": opts.Tags})
+}
+
+// TagImage gắn thêm tag cho image, vd. trước khi push lên registry riêng
+func (h *ImageHandler) TagImage(c *gin.Context) {
+	serverID := GetServerIDFromRequest(c)
 	var req struct {
-		Image string `json:"image" binding:"required"`
+		Target string `json:"target" binding:"required"` // e.g., "registry.example.com/team/app:1.0"
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Vui lòng cung cấp tag mới"})
+		return
+	}
+
+	if err := h.serverManager.TagImage(serverID, c.Param("id"), req.Target); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Image đã được gắn tag", "image": req.Target})
+}
+
+// PushImage push image lên registry và chờ tới khi hoàn tất.
+// username/password là tùy chọn; bỏ trống thì dùng registry đã lưu theo host của image.
+func (h *ImageHandler) PushImage(c *gin.Context) {
+	serverID := GetServerIDFromRequest(c)
+	var req pushCredentials
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Image) == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Vui lòng cung cấp tên image"})
 		return
 	}
 
-	if err := h.serverManager.GetLocalDocker().PullImage(req.Image); err != nil {
+	if err := h.serverManager.PushImage(serverID, req.Image, req.authConfig()); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "Image đã được tải về"})
+	c.JSON(http.StatusOK, gin.H{"message": "Image đã được push", "image": req.Image})
 }
 
-// RemoveImages xóa nhiều images cùng lúc (only local server)
-func (h *ImageHandler) RemoveImages(c *gin.Context) {
+// PushImageStream push image và gửi tiến trình từng layer qua WebSocket.
+// Query params: image (bắt buộc), credentials=true nếu client gửi
+// {"username","password"} ngay sau khi kết nối thay vì dùng registry đã lưu.
+// Messages giống PullImageStream.
+func (h *ImageHandler) PushImageStream(c *gin.Context) {
 	serverID := GetServerIDFromRequest(c)
-	if !h.serverManager.IsLocal(serverID) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Bulk remove images only supported for local server"})
+	req := pushCredentials{Image: strings.TrimSpace(c.Query("image"))}
+
+	conn, err := upgradeWebSocket(c)
+	if err != nil {
 		return
 	}
+	defer conn.Close()
 
+	if req.Image == "" {
+		conn.WriteJSON(gin.H{"type": "error", "error": "Vui lòng cung cấp tên image"})
+		return
+	}
+
+	// Credentials không đi qua query string để không bị ghi vào log
+	if c.Query("credentials") == "true" {
+		conn.SetReadDeadline(time.Now().Add(30 * time.Second))
+		if err := conn.ReadJSON(&req); err != nil {
+			conn.WriteJSON(gin.H{"type": "error", "error": "Không nhận được thông tin đăng nhập registry"})
+			return
+		}
+		conn.SetReadDeadline(time.Time{})
+		req.Image = strings.TrimSpace(c.Query("image"))
+	}
+
+	reader, err := h.serverManager.StreamPushImage(serverID, req.Image, req.authConfig())
+	if err != nil {
+		conn.WriteJSON(gin.H{"type": "error", "error": err.Error()})
+		return
+	}
+	defer reader.Close()
+
+	if _, err := streamProgress(conn, reader); err != nil {
+		conn.WriteJSON(gin.H{"type": "error", "error": err.Error()})
+		return
+	}
+	conn.WriteJSON(gin.H{"type": "complete", "image": req.Image})
+}
+
+// pushCredentials là image cần push kèm credentials tùy chọn cho riêng lần push này
+type pushCredentials struct {
+	Image    string `json:"image"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+func (p pushCredentials) authConfig() *registry.AuthConfig {
+	if p.Username == "" {
+		return nil
+	}
+	auth := services.RegistryAuthConfig(&models.Registry{
+		Host:     services.RegistryHostFromImage(p.Image),
+		Username: p.Username,
+		Password: p.Password,
+	})
+	return &auth
+}
+
+// streamProgress gửi từng message của stream JSON progress (pull, push, build) qua WebSocket
+// và trả về image ID nếu stream có báo (build). Client đóng kết nối thì dừng stream,
+// Docker sẽ hủy thao tác đang chạy.
+func streamProgress(conn *websocket.Conn, reader io.Reader) (string, error) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	var imageID string
+	err := services.DecodeProgress(reader, func(msg services.ProgressMessage) error {
+		select {
+		case <-done:
+			return services.ErrStreamClosed
+		default:
+		}
+		if msg.ImageID != "" {
+			imageID = msg.ImageID
+		}
+		return conn.WriteJSON(struct {
+			Type string `json:"type"`
+			services.ProgressMessage
+		}{"progress", msg})
+	})
+	return imageID, err
+}
+
+// RemoveImages xóa nhiều images cùng lúc
+func (h *ImageHandler) RemoveImages(c *gin.Context) {
+	serverID := GetServerIDFromRequest(c)
 	var req struct {
 		IDs   []string `json:"ids" binding:"required"`
 		Force bool     `json:"force"`
@@ -96,10 +373,92 @@ func (h *ImageHandler) RemoveImages(c *gin.Context) {
 		return
 	}
 
-	result, err := h.serverManager.GetLocalDocker().RemoveImages(req.IDs, req.Force)
+	result, err := h.serverManager.RemoveImages(serverID, req.IDs, req.Force)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, result)
 }
+
+// SaveImage tải về tar của một hoặc nhiều image (?image= lặp lại được), giống "docker save"
+func (h *ImageHandler) SaveImage(c *gin.Context) {
+	serverID := GetServerIDFromRequest(c)
+	refs := c.QueryArray("image")
+	if len(refs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Vui lòng cung cấp tên image"})
+		return
+	}
+
+	reader, err := h.serverManager.SaveImage(serverID, refs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer reader.Close()
+
+	c.DataFromReader(http.StatusOK, -1, "application/x-tar", reader, map[string]string{
+		"Content-Disposition": `attachment; filename="` + imageArchiveName(refs) + `"`,
+	})
+}
+
+// imageArchiveName đặt tên file tar theo image, e.g. "nginx_1.27.tar"
+func imageArchiveName(refs []string) string {
+	if len(refs) != 1 {
+		return "images.tar"
+	}
+	name := strings.TrimPrefix(refs[0], "sha256:")
+	name = strings.NewReplacer("/", "_", ":", "_", "@", "_").Replace(name)
+	return name + ".tar"
+}
+
+// LoadImage import image từ tar của "docker save" (có thể nén gzip), giống "docker load".
+// Body là file tar (application/x-tar) hoặc multipart/form-data với field "file".
+func (h *ImageHandler) LoadImage(c *gin.Context) {
+	serverID := GetServerIDFromRequest(c)
+
+	var body io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		file, _, err := c.Request.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Vui lòng upload file tar (field \"file\")"})
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	images, err := h.serverManager.LoadImage(serverID, body)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Image đã được load", "images": images})
+}
+
+// TransferImage chuyển image từ server hiện tại sang server khác (stream trực tiếp, không lưu tạm)
+func (h *ImageHandler) TransferImage(c *gin.Context) {
+	serverID := GetServerIDFromRequest(c)
+	var req struct {
+		Images       []string `json:"images" binding:"required"`
+		TargetServer string   `json:"targetServer"` // để trống = local
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.Images) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Vui lòng cung cấp danh sách image"})
+		return
+	}
+
+	result, err := h.serverManager.TransferImage(serverID, req.TargetServer, req.Images)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrSameServerTransfer):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrServerNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+
+	"appdock/internal/models"
+	"appdock/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RegistryHandler struct {
+	store         *services.RegistryStore
+	serverManager *services.ServerManager
+}
+
+func NewRegistryHandler(store *services.RegistryStore, sm *services.ServerManager) *RegistryHandler {
+	return &RegistryHandler{
+		store:         store,
+		serverManager: sm,
+	}
+}
+
+func (h *RegistryHandler) available(c *gin.Context) bool {
+	if h.store == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Registry không khả dụng"})
+		return false
+	}
+	return true
+}
+
+// ListRegistries trả về các registry đã lưu (không kèm password)
+func (h *RegistryHandler) ListRegistries(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	registries := h.store.List()
+	sort.Slice(registries, func(i, j int) bool { return registries[i].Host < registries[j].Host })
+	response := make([]models.RegistryResponse, len(registries))
+	for i, reg := range registries {
+		response[i] = reg.ToResponse()
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetRegistry trả về một registry
+func (h *RegistryHandler) GetRegistry(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	reg, err := h.store.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, reg.ToResponse())
+}
+
+// CreateRegistry lưu thông tin đăng nhập registry mới
+func (h *RegistryHandler) CreateRegistry(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	var req models.CreateRegistryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Vui lòng cung cấp host, username và password"})
+		return
+	}
+
+	reg, err := h.store.Create(req)
+	if err != nil {
+		if err == services.ErrRegistryAlreadyExists {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusCreated, reg.ToResponse())
+}
+
+// UpdateRegistry cập nhật registry, password để trống sẽ giữ nguyên
+func (h *RegistryHandler) UpdateRegistry(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	var req models.UpdateRegistryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dữ liệu không hợp lệ"})
+		return
+	}
+
+	reg, err := h.store.Update(c.Param("id"), req)
+	if err != nil {
+		switch err {
+		case services.ErrRegistryNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case services.ErrRegistryAlreadyExists:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, reg.ToResponse())
+}
+
+// DeleteRegistry xóa registry
+func (h *RegistryHandler) DeleteRegistry(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	if err := h.store.Delete(c.Param("id")); err != nil {
+		if err == services.ErrRegistryNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Registry đã được xóa"})
+}
+
+// TestRegistry thử đăng nhập registry đã lưu từ server hiện tại
+func (h *RegistryHandler) TestRegistry(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	reg, err := h.store.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	h.testLogin(c, reg)
+}
+
+// TestCredentials thử đăng nhập với thông tin chưa lưu (dùng trước khi tạo registry)
+func (h *RegistryHandler) TestCredentials(c *gin.Context) {
+	var req models.CreateRegistryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Vui lòng cung cấp host, username và password"})
+		return
+	}
+	req.Host = services.NormalizeRegistryHost(req.Host)
+	h.testLogin(c, models.NewRegistry(req))
+}
+
+func (h *RegistryHandler) testLogin(c *gin.Context, reg *models.Registry) {
+	serverID := GetServerIDFromRequest(c)
+	status, err := h.serverManager.RegistryLogin(serverID, services.RegistryAuthConfig(reg))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "status": status})
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"appdock/internal/models"
+	"appdock/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ScannerHandler struct {
+	vulnDB        *services.VulnDatabase
+	serverManager *services.ServerManager
+}
+
+func NewScannerHandler(vulnDB *services.VulnDatabase, sm *services.ServerManager) *ScannerHandler {
+	return &ScannerHandler{
+		vulnDB:        vulnDB,
+		serverManager: sm,
+	}
+}
+
+type ImportVulnDBRequest struct {
+	Path    string `json:"path"`    // file .json/.zip hoặc thư mục OSV trên server
+	Replace bool   `json:"replace"` // xóa dữ liệu cũ trước khi import
+}
+
+// GetDatabase trả về thông tin vulnerability DB (số advisory, thời điểm import)
+func (h *ScannerHandler) GetDatabase(c *gin.Context) {
+	if h.vulnDB == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": services.ErrScannerNotConfigured.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, h.vulnDB.Stats())
+}
+
+// ImportDatabase import dữ liệu OSV vào vulnerability DB, từ đường dẫn trên server
+// (JSON {"path","replace"}) hoặc file upload (multipart field "file", query replace=true)
+func (h *ScannerHandler) ImportDatabase(c *gin.Context) {
+	if h.vulnDB == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": services.ErrScannerNotConfigured.Error()})
+		return
+	}
+
+	var req ImportVulnDBRequest
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		file, header, err := c.Request.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Vui lòng upload file OSV .json hoặc .zip (field \"file\")"})
+			return
+		}
+		defer file.Close()
+
+		// Giữ phần mở rộng để biết file là .json hay .zip
+		tmpFile, err := os.CreateTemp("", "appdock-osv-*"+strings.ToLower(filepath.Ext(header.Filename)))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		defer os.Remove(tmpFile.Name())
+		_, err = io.Copy(tmpFile, file)
+		tmpFile.Close()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		req.Path = tmpFile.Name()
+		req.Replace = c.Query("replace") == "true"
+	} else if err := c.ShouldBindJSON(&req); err != nil || req.Path == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Vui lòng cung cấp path hoặc upload file"})
+		return
+	}
+
+	count, err := h.vulnDB.Import(req.Path, req.Replace)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Import vulnerability database thành công",
+		"imported": count,
+		"database": h.vulnDB.Stats(),
+	})
+}
+
+// ClearDatabase xóa toàn bộ vulnerability DB
+func (h *ScannerHandler) ClearDatabase(c *gin.Context) {
+	if h.vulnDB == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": services.ErrScannerNotConfigured.Error()})
+		return
+	}
+	if err := h.vulnDB.Clear(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Đã xóa vulnerability database"})
+}
+
+// ScanImage scan image và trả về kết quả đầy đủ (package, lỗ hổng, summary)
+func (h *ScannerHandler) ScanImage(c *gin.Context) {
+	serverID := GetServerIDFromRequest(c)
+	result, err := h.serverManager.ScanImage(serverID, c.Param("id"))
+	if err != nil {
+		if errors.Is(err, services.ErrScannerNotConfigured) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// GetScanResult trả về kết quả scan gần nhất, format=csv để export danh sách lỗ hổng
+func (h *ScannerHandler) GetScanResult(c *gin.Context) {
+	result, ok := h.scanResult(c)
+	if !ok {
+		return
+	}
+
+	switch format := c.DefaultQuery("format", "json"); format {
+	case "json":
+		c.JSON(http.StatusOK, result)
+	case "csv":
+		c.Header("Content-Disposition", `attachment; filename="`+scanFileName(result.ImageID, "vulnerabilities", "csv")+`"`)
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Status(http.StatusOK)
+		// Header đã gửi nên chỉ có thể ghi log khi lỗi
+		if err := services.WriteVulnerabilitiesCSV(c.Writer, result); err != nil {
+			log.Printf("Export vulnerabilities CSV of image %s failed: %v", result.ImageID, err)
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format phải là json hoặc csv"})
+	}
+}
+
+// GetSBOM export danh sách package của lần scan gần nhất, format=spdx (mặc định) hoặc cyclonedx
+func (h *ScannerHandler) GetSBOM(c *gin.Context) {
+	format := c.DefaultQuery("format", services.SBOMFormatSPDX)
+	if format != services.SBOMFormatSPDX && format != services.SBOMFormatCycloneDX {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format phải là spdx hoặc cyclonedx"})
+		return
+	}
+
+	result, ok := h.scanResult(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+scanFileName(result.ImageID, format, "json")+`"`)
+	c.Header("Content-Type", "application/json")
+	c.Status(http.StatusOK)
+	if err := services.WriteSBOM(c.Writer, result, format); err != nil {
+		log.Printf("Export %s SBOM of image %s failed: %v", format, result.ImageID, err)
+	}
+}
+
+func (h *ScannerHandler) scanResult(c *gin.Context) (*models.ScanResult, bool) {
+	serverID := GetServerIDFromRequest(c)
+	result, err := h.serverManager.GetScanResult(serverID, c.Param("id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrScanNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrScannerNotConfigured):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return nil, false
+	}
+	return result, true
+}
+
+func scanFileName(imageID, kind, ext string) string {
+	return imageID + "-" + kind + "." + ext
+}
@@ -0,0 +1,279 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+
+	"appdock/internal/models"
+	"appdock/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StackHandler struct {
+	store         *services.StackStore
+	serverManager *services.ServerManager
+}
+
+func NewStackHandler(store *services.StackStore, sm *services.ServerManager) *StackHandler {
+	return &StackHandler{
+		store:         store,
+		serverManager: sm,
+	}
+}
+
+func (h *StackHandler) available(c *gin.Context) bool {
+	if h.store == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Stack không khả dụng"})
+		return false
+	}
+	return true
+}
+
+// ListStacks trả về các stack đã lưu của server hiện tại
+func (h *StackHandler) ListStacks(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	stacks := h.store.List(GetServerIDFromRequest(c))
+	sort.Slice(stacks, func(i, j int) bool { return stacks[i].Name < stacks[j].Name })
+	c.JSON(http.StatusOK, stacks)
+}
+
+// GetStack trả về một stack kèm compose file
+func (h *StackHandler) GetStack(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	stack, err := h.store.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, stack)
+}
+
+// CreateStack lưu compose file thành stack mới, deploy ngay nếu deploy = true
+func (h *StackHandler) CreateStack(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	var req models.CreateStackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dữ liệu không hợp lệ"})
+		return
+	}
+
+	// Kiểm tra compose file trước khi lưu
+	plan, err := services.BuildStackPlan(req.Name, req.Compose, req.Env)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	stack, err := h.store.Create(GetServerIDFromRequest(c), req)
+	if err != nil {
+		switch err {
+		case services.ErrInvalidStackName:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case services.ErrStackAlreadyExists:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if !req.Deploy {
+		c.JSON(http.StatusCreated, stack)
+		return
+	}
+	h.deploy(c, stack, plan, http.StatusCreated)
+}
+
+// UpdateStack sửa compose file/biến môi trường, redeploy nếu deploy = true
+func (h *StackHandler) UpdateStack(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	stack, err := h.store.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req models.UpdateStackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dữ liệu không hợp lệ"})
+		return
+	}
+
+	compose, env := stack.Compose, stack.Env
+	if req.Compose != nil {
+		compose = *req.Compose
+	}
+	if req.Env != nil {
+		env = req.Env
+	}
+	plan, err := services.BuildStackPlan(stack.Name, compose, env)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	stack, err = h.store.Update(stack.ID, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !req.Deploy {
+		c.JSON(http.StatusOK, stack)
+		return
+	}
+	h.deploy(c, stack, plan, http.StatusOK)
+}
+
+// DeployStack deploy (hoặc redeploy) stack theo compose file đã lưu
+func (h *StackHandler) DeployStack(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	stack, err := h.store.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	plan, err := services.BuildStackPlan(stack.Name, stack.Compose, stack.Env)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.deploy(c, stack, plan, http.StatusOK)
+}
+
+// DiffStack so sánh compose file (đã lưu hoặc bản nháp gửi lên) với trạng thái trên server
+func (h *StackHandler) DiffStack(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	stack, err := h.store.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	compose, env := stack.Compose, stack.Env
+	if c.Request.ContentLength > 0 {
+		var req models.UpdateStackRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Dữ liệu không hợp lệ"})
+			return
+		}
+		if req.Compose != nil {
+			compose = *req.Compose
+		}
+		if req.Env != nil {
+			env = req.Env
+		}
+	}
+
+	plan, err := services.BuildStackPlan(stack.Name, compose, env)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	diff, err := h.serverManager.DiffStack(stack.ServerID, plan)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"changes":        diff,
+		"hasChanges":     diff.HasChanges(),
+		"composeChanged": compose != stack.Compose,
+	})
+}
+
+// DownStack xóa containers và networks của stack nhưng giữ lại định nghĩa.
+// Thêm ?volumes=true để xóa cả volumes.
+func (h *StackHandler) DownStack(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	stack, err := h.store.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.serverManager.RemoveStack(stack.ServerID, stack.Name, c.Query("volumes") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	stack, err = h.store.SetStatus(stack.ID, models.StackStatusInactive, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"stack": stack, "result": result})
+}
+
+// DeleteStack teardown stack trên server rồi xóa định nghĩa khỏi AppDock
+func (h *StackHandler) DeleteStack(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	stack, err := h.store.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.serverManager.RemoveStack(stack.ServerID, stack.Name, c.Query("volumes") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(result.Failed) > 0 {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":  "Không thể xóa toàn bộ tài nguyên của stack",
+			"result": result,
+		})
+		return
+	}
+
+	if err := h.store.Delete(stack.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Stack đã được xóa", "result": result})
+}
+
+func (h *StackHandler) deploy(c *gin.Context, stack *models.Stack, plan *services.StackPlan, status int) {
+	diff, deployErr := h.serverManager.DeployStack(stack.ServerID, plan)
+
+	newStatus := models.StackStatusDeployed
+	if deployErr != nil {
+		newStatus = models.StackStatusFailed
+	}
+	stack, err := h.store.SetStatus(stack.ID, newStatus, deployErr)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if deployErr != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   deployErr.Error(),
+			"stack":   stack,
+			"changes": diff,
+		})
+		return
+	}
+	c.JSON(status, gin.H{"stack": stack, "changes": diff})
+}
@@ -97,3 +97,40 @@ func (h *SystemHandler) GetStatsHistory(c *gin.Context) {
 	history := h.statsHistoryService.GetHistory()
 	c.JSON(http.StatusOK, history)
 }
+
+// Prune dọn tài nguyên không dùng trên server hiện tại và trả về dung lượng thu hồi
+func (h *SystemHandler) Prune(c *gin.Context) {
+	var opts services.PruneOptions
+	if err := c.ShouldBindJSON(&opts); err != nil || opts.IsEmpty() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Vui lòng chọn ít nhất một loại tài nguyên cần dọn"})
+		return
+	}
+
+	result, err := h.serverManager.Prune(GetServerIDFromRequest(c), opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// PruneAllServers dọn tài nguyên không dùng trên mọi server
+func (h *SystemHandler) PruneAllServers(c *gin.Context) {
+	var opts services.PruneOptions
+	if err := c.ShouldBindJSON(&opts); err != nil || opts.IsEmpty() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Vui lòng chọn ít nhất một loại tài nguyên cần dọn"})
+		return
+	}
+
+	results := h.serverManager.PruneAllServers(opts)
+	var total uint64
+	for _, r := range results {
+		if r.Result != nil {
+			total += r.Result.SpaceReclaimed
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"servers":        results,
+		"spaceReclaimed": total,
+	})
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"net/http"
+
+	"appdock/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UpdateHandler struct {
+	checker *services.UpdateChecker
+	store   *services.ServerStore
+}
+
+func NewUpdateHandler(checker *services.UpdateChecker, store *services.ServerStore) *UpdateHandler {
+	return &UpdateHandler{
+		checker: checker,
+		store:   store,
+	}
+}
+
+func (h *UpdateHandler) available(c *gin.Context) bool {
+	if h.checker == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Image update checker không khả dụng"})
+		return false
+	}
+	return true
+}
+
+// ListUpdates trả về kết quả kiểm tra bản mới gần nhất của server hiện tại
+func (h *UpdateHandler) ListUpdates(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	c.JSON(http.StatusOK, h.checker.List(GetServerIDFromRequest(c)))
+}
+
+// ListAllUpdates trả về kết quả kiểm tra gần nhất của tất cả servers
+func (h *UpdateHandler) ListAllUpdates(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	servers := h.store.List()
+	results := make([]services.ServerUpdateResult, 0, len(servers))
+	for _, server := range servers {
+		results = append(results, services.ServerUpdateResult{
+			ServerID:   server.ID,
+			ServerName: server.Name,
+			Updates:    h.checker.List(server.ID),
+		})
+	}
+	c.JSON(http.StatusOK, results)
+}
+
+// CheckUpdates kiểm tra ngay image của các container đang chạy trên server hiện tại
+func (h *UpdateHandler) CheckUpdates(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	updates, err := h.checker.CheckServer(GetServerIDFromRequest(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, updates)
+}
+
+// CheckAllUpdates kiểm tra ngay trên tất cả servers
+func (h *UpdateHandler) CheckAllUpdates(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	c.JSON(http.StatusOK, h.checker.CheckAll())
+}
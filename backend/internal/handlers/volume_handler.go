@@ -1,7 +1,12 @@
 package handlers
 
 import (
+	"errors"
+	"io"
 	"net/http"
+	"path"
+	"strings"
+	"time"
 
 	"appdock/internal/services"
 
@@ -72,3 +77,141 @@ func (h *VolumeHandler) RemoveVolume(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Volume đã được xóa"})
 }
+
+// BackupVolume tải về nội dung volume dạng tar.gz (stream, không lưu tạm)
+func (h *VolumeHandler) BackupVolume(c *gin.Context) {
+	serverID := GetServerIDFromRequest(c)
+	name := c.Param("name")
+	reader, err := h.serverManager.OpenVolumeBackup(serverID, name)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrVolumeNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	defer reader.Close()
+
+	fileName := name + "_" + time.Now().Format("20060102-150405") + ".tar.gz"
+	c.DataFromReader(http.StatusOK, -1, "application/gzip", reader, map[string]string{
+		"Content-Disposition": `attachment; filename="` + fileName + `"`,
+	})
+}
+
+// RestoreVolume giải nén tar hoặc tar.gz vào volume, tạo volume nếu chưa có.
+// Body là file tar (application/x-tar, application/gzip) hoặc multipart/form-data với field "file".
+func (h *VolumeHandler) RestoreVolume(c *gin.Context) {
+	serverID := GetServerIDFromRequest(c)
+	name := c.Param("name")
+
+	var body io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		file, _, err := c.Request.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Vui lòng upload file backup (field \"file\")"})
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	created, err := h.serverManager.RestoreVolume(serverID, name, body)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Volume đã được restore", "volume": name, "created": created})
+}
+
+func volumeFileErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrInvalidVolumePath), errors.Is(err, services.ErrNotADirectory), errors.Is(err, services.ErrIsADirectory):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrVolumeNotFound), errors.Is(err, services.ErrVolumePathNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrVolumeFileTooLarge):
+		return http.StatusRequestEntityTooLarge
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ListVolumeFiles liệt kê thư mục trong volume (?path=, mặc định là gốc volume)
+func (h *VolumeHandler) ListVolumeFiles(c *gin.Context) {
+	dir, err := h.serverManager.ListVolumeFiles(GetServerIDFromRequest(c), c.Param("name"), c.DefaultQuery("path", "/"))
+	if err != nil {
+		c.JSON(volumeFileErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, dir)
+}
+
+// ReadVolumeFile trả về nội dung file nhỏ (tối đa 1 MB) trong volume
+func (h *VolumeHandler) ReadVolumeFile(c *gin.Context) {
+	content, err := h.serverManager.ReadVolumeFile(GetServerIDFromRequest(c), c.Param("name"), c.Query("path"))
+	if err != nil {
+		c.JSON(volumeFileErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, content)
+}
+
+// DownloadVolumeFile tải về một file trong volume
+func (h *VolumeHandler) DownloadVolumeFile(c *gin.Context) {
+	reader, entry, err := h.serverManager.OpenVolumeFile(GetServerIDFromRequest(c), c.Param("name"), c.Query("path"))
+	if err != nil {
+		c.JSON(volumeFileErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	defer reader.Close()
+
+	c.DataFromReader(http.StatusOK, entry.Size, "application/octet-stream", reader, map[string]string{
+		"Content-Disposition": `attachment; filename="` + strings.ReplaceAll(entry.Name, `"`, "_") + `"`,
+	})
+}
+
+// UploadVolumeFile ghi file vào volume (?path=), ghi đè nếu đã có.
+// Body là nội dung file (cần Content-Length) hoặc multipart/form-data với field "file";
+// với multipart, path trống hoặc kết thúc bằng "/" sẽ dùng tên file upload.
+func (h *VolumeHandler) UploadVolumeFile(c *gin.Context) {
+	filePath := c.Query("path")
+
+	var body io.Reader = c.Request.Body
+	size := c.Request.ContentLength
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		file, header, err := c.Request.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Vui lòng upload file (field \"file\")"})
+			return
+		}
+		defer file.Close()
+		body = file
+		size = header.Size
+		if filePath == "" || strings.HasSuffix(filePath, "/") {
+			filePath += path.Base(header.Filename)
+		}
+	} else if size < 0 {
+		c.JSON(http.StatusLengthRequired, gin.H{"error": "Thiếu Content-Length"})
+		return
+	}
+
+	if filePath == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Vui lòng cung cấp path của file"})
+		return
+	}
+	if err := h.serverManager.WriteVolumeFile(GetServerIDFromRequest(c), c.Param("name"), filePath, body, size); err != nil {
+		c.JSON(volumeFileErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "File đã được upload", "path": filePath})
+}
+
+// DeleteVolumeFile xóa file hoặc thư mục (đệ quy) trong volume
+func (h *VolumeHandler) DeleteVolumeFile(c *gin.Context) {
+	if err := h.serverManager.DeleteVolumeFile(GetServerIDFromRequest(c), c.Param("name"), c.Query("path")); err != nil {
+		c.JSON(volumeFileErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Đã xóa"})
+}
@@ -0,0 +1,79 @@
+package models
+
+import "time"
+
+// Label bật/tắt auto-update cho container, e.g. appdock.autoupdate=true
+const AutoUpdateLabel = "appdock.autoupdate"
+
+// Kết quả một lần auto-update
+const (
+	AutoUpdateStatusUpdated    = "updated"
+	AutoUpdateStatusRolledBack = "rolled_back" // container mới lỗi/unhealthy, đã khôi phục container cũ
+	AutoUpdateStatusFailed     = "failed"
+)
+
+// ScheduleWindow là khung giờ được phép auto-update. End nhỏ hơn Start nghĩa là qua nửa đêm.
+type ScheduleWindow struct {
+	Days  []string `json:"days"`  // "mon".."sun", để trống = mọi ngày (tính theo ngày bắt đầu khung giờ)
+	Start string   `json:"start"` // "HH:MM"
+	End   string   `json:"end"`   // "HH:MM"
+}
+
+// NotificationSettings cấu hình gửi thông báo kết quả qua webhook
+type NotificationSettings struct {
+	WebhookURL string `json:"webhookUrl"` // nhận JSON có "text"/"content" nên dùng được với Slack, Discord, Mattermost
+	OnSuccess  bool   `json:"onSuccess"`
+	OnFailure  bool   `json:"onFailure"`
+}
+
+// AutoUpdateSettings cấu hình chung cho auto-update
+type AutoUpdateSettings struct {
+	Enabled       bool                 `json:"enabled"`
+	CheckInterval int                  `json:"checkInterval"` // số phút giữa hai lần kiểm tra một container
+	Timezone      string               `json:"timezone"`      // e.g. "Asia/Ho_Chi_Minh", mặc định giờ của server AppDock
+	Windows       []ScheduleWindow     `json:"windows"`       // để trống = bất kỳ lúc nào
+	HealthTimeout int                  `json:"healthTimeout"` // số giây chờ healthcheck của container mới
+	Cleanup       bool                 `json:"cleanup"`       // xóa image cũ sau khi cập nhật thành công
+	Notifications NotificationSettings `json:"notifications"`
+}
+
+// AutoUpdatePolicy là chính sách đặt qua API cho một container, ưu tiên hơn label.
+// Container được nhận diện theo tên vì ID thay đổi sau mỗi lần cập nhật.
+type AutoUpdatePolicy struct {
+	ServerID  string           `json:"serverId"`
+	Container string           `json:"container"`
+	Enabled   bool             `json:"enabled"`
+	Windows   []ScheduleWindow `json:"windows,omitempty"` // ghi đè khung giờ chung
+	UpdatedAt time.Time        `json:"updatedAt"`
+}
+
+type SetAutoUpdatePolicyRequest struct {
+	Enabled bool             `json:"enabled"`
+	Windows []ScheduleWindow `json:"windows"`
+}
+
+// AutoUpdateEvent là lịch sử một lần cập nhật
+type AutoUpdateEvent struct {
+	ServerID    string    `json:"serverId"`
+	ServerName  string    `json:"serverName"`
+	Container   string    `json:"container"`
+	Image       string    `json:"image"`
+	OldDigest   string    `json:"oldDigest,omitempty"`
+	NewDigest   string    `json:"newDigest,omitempty"`
+	ContainerID string    `json:"containerId,omitempty"` // ID container mới khi thành công
+	Status      string    `json:"status"`
+	Error       string    `json:"error,omitempty"`
+	StartedAt   time.Time `json:"startedAt"`
+	FinishedAt  time.Time `json:"finishedAt"`
+}
+
+// AutoUpdateContainer là trạng thái auto-update của một container đang chạy
+type AutoUpdateContainer struct {
+	ID              string           `json:"id"`
+	Name            string           `json:"name"`
+	Image           string           `json:"image"`
+	Enabled         bool             `json:"enabled"`
+	Source          string           `json:"source"` // "label", "api" hoặc "" khi chưa bật
+	Windows         []ScheduleWindow `json:"windows"`
+	UpdateAvailable bool             `json:"updateAvailable"`
+}
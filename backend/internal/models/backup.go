@@ -0,0 +1,74 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Kết quả lần chạy gần nhất của lịch backup
+const (
+	BackupStatusSuccess = "success"
+	BackupStatusFailed  = "failed"
+)
+
+// BackupSchedule là lịch backup định kỳ một volume vào data dir của AppDock
+type BackupSchedule struct {
+	ID         string     `json:"id"`
+	ServerID   string     `json:"serverId"`
+	Volume     string     `json:"volume"`
+	Interval   int        `json:"interval"`  // số phút giữa hai lần backup
+	Retention  int        `json:"retention"` // số bản backup giữ lại, bản cũ hơn bị xóa
+	Enabled    bool       `json:"enabled"`
+	LastRunAt  *time.Time `json:"lastRunAt,omitempty"`
+	LastStatus string     `json:"lastStatus,omitempty"`
+	LastError  string     `json:"lastError,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+}
+
+type CreateBackupScheduleRequest struct {
+	Volume    string `json:"volume" binding:"required"`
+	Interval  int    `json:"interval"`  // mặc định 1440 (mỗi ngày)
+	Retention int    `json:"retention"` // mặc định 7
+	Enabled   *bool  `json:"enabled"`   // mặc định true
+}
+
+type UpdateBackupScheduleRequest struct {
+	Interval  int   `json:"interval"`
+	Retention int   `json:"retention"`
+	Enabled   *bool `json:"enabled"`
+}
+
+func NewBackupSchedule(serverID string, req CreateBackupScheduleRequest) *BackupSchedule {
+	now := time.Now()
+	schedule := &BackupSchedule{
+		ID:        uuid.New().String(),
+		ServerID:  serverID,
+		Volume:    req.Volume,
+		Interval:  req.Interval,
+		Retention: req.Retention,
+		Enabled:   true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if schedule.Interval <= 0 {
+		schedule.Interval = 1440
+	}
+	if schedule.Retention <= 0 {
+		schedule.Retention = 7
+	}
+	if req.Enabled != nil {
+		schedule.Enabled = *req.Enabled
+	}
+	return schedule
+}
+
+// VolumeBackup là một bản backup (tar.gz) đã lưu trong data dir
+type VolumeBackup struct {
+	File      string    `json:"file"` // e.g. "pgdata_20250101-030000.tar.gz"
+	ServerID  string    `json:"serverId"`
+	Volume    string    `json:"volume"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"createdAt"`
+}
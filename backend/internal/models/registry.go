@@ -0,0 +1,69 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Registry là thông tin đăng nhập một Docker registry riêng (GitLab, Harbor, ...)
+type Registry struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Host      string    `json:"host"` // e.g., "registry.gitlab.com", "harbor.example.com:8443"
+	Username  string    `json:"username"`
+	Password  string    `json:"password"` // được mã hóa khi lưu xuống đĩa, không trả về client
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type RegistryResponse struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Host      string    `json:"host"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func (r *Registry) ToResponse() RegistryResponse {
+	return RegistryResponse{
+		ID:        r.ID,
+		Name:      r.Name,
+		Host:      r.Host,
+		Username:  r.Username,
+		CreatedAt: r.CreatedAt,
+		UpdatedAt: r.UpdatedAt,
+	}
+}
+
+type CreateRegistryRequest struct {
+	Name     string `json:"name"`
+	Host     string `json:"host" binding:"required"`
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type UpdateRegistryRequest struct {
+	Name     string `json:"name"`
+	Host     string `json:"host"`
+	Username string `json:"username"`
+	Password string `json:"password"` // để trống nếu không đổi
+}
+
+func NewRegistry(req CreateRegistryRequest) *Registry {
+	now := time.Now()
+	name := req.Name
+	if name == "" {
+		name = req.Host
+	}
+	return &Registry{
+		ID:        uuid.New().String(),
+		Name:      name,
+		Host:      req.Host,
+		Username:  req.Username,
+		Password:  req.Password,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
@@ -0,0 +1,96 @@
+package models
+
+import "time"
+
+// Loại package, trùng với type trong purl
+const (
+	PackageTypeDeb    = "deb"
+	PackageTypeApk    = "apk"
+	PackageTypeGolang = "golang"
+	PackageTypeNpm    = "npm"
+	PackageTypePypi   = "pypi"
+)
+
+type Severity string
+
+const (
+	SeverityCritical Severity = "CRITICAL"
+	SeverityHigh     Severity = "HIGH"
+	SeverityMedium   Severity = "MEDIUM"
+	SeverityLow      Severity = "LOW"
+	SeverityUnknown  Severity = "UNKNOWN"
+)
+
+// Package là một package tìm thấy trong các layer của image
+type Package struct {
+	Name     string `json:"name"`
+	Version  string `json:"version"`
+	Type     string `json:"type"`             // deb, apk, golang, npm, pypi
+	Source   string `json:"source,omitempty"` // source package (deb) hoặc origin (apk), advisory của distro dùng tên này
+	PURL     string `json:"purl"`
+	Location string `json:"location"` // file trong image chứa thông tin package
+}
+
+// Vulnerability là một advisory khớp với package trong image
+type Vulnerability struct {
+	ID           string   `json:"id"` // e.g., "CVE-2024-1234", "GHSA-xxxx", "DSA-5555-1"
+	Aliases      []string `json:"aliases,omitempty"`
+	Package      string   `json:"package"`
+	Version      string   `json:"version"`
+	Type         string   `json:"type"`
+	PURL         string   `json:"purl"`
+	FixedVersion string   `json:"fixedVersion,omitempty"`
+	Severity     Severity `json:"severity"`
+	Score        float64  `json:"score,omitempty"` // CVSS v3 base score
+	Summary      string   `json:"summary,omitempty"`
+	Location     string   `json:"location"`
+}
+
+// ImageOS là distro của image, đọc từ /etc/os-release
+type ImageOS struct {
+	ID      string `json:"id"`      // e.g., "debian", "alpine"
+	Version string `json:"version"` // VERSION_ID
+	Name    string `json:"name"`    // PRETTY_NAME
+}
+
+// ScanSummary là kết quả scan rút gọn, được gắn vào danh sách image
+type ScanSummary struct {
+	ScannedAt time.Time `json:"scannedAt"`
+	Packages  int       `json:"packages"`
+	Critical  int       `json:"critical"`
+	High      int       `json:"high"`
+	Medium    int       `json:"medium"`
+	Low       int       `json:"low"`
+	Unknown   int       `json:"unknown"`
+	Total     int       `json:"total"`
+}
+
+// ScanResult là kết quả scan đầy đủ của một image trên một server
+type ScanResult struct {
+	ServerID        string          `json:"serverId"`
+	ImageID         string          `json:"imageId"` // 12 ký tự, giống ImageInfo.ID
+	RepoTags        []string        `json:"repoTags"`
+	OS              *ImageOS        `json:"os,omitempty"`
+	Packages        []Package       `json:"packages"`
+	Vulnerabilities []Vulnerability `json:"vulnerabilities"`
+	Summary         ScanSummary     `json:"summary"`
+	DatabaseDate    *time.Time      `json:"databaseDate,omitempty"` // thời điểm import vulnerability DB đã dùng
+	Warnings        []string        `json:"warnings,omitempty"`
+}
+
+// Count đếm lỗ hổng theo mức độ
+func (s *ScanSummary) Count(severity Severity) {
+	switch severity {
+	case SeverityCritical:
+		s.Critical++
+	case SeverityHigh:
+		s.High++
+	case SeverityMedium:
+		s.Medium++
+	case SeverityLow:
+		s.Low++
+	default:
+		s.Unknown++
+	}
+	s.Total++
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type StackStatus string
+
+const (
+	StackStatusInactive StackStatus = "inactive" // chưa deploy hoặc đã teardown
+	StackStatusDeployed StackStatus = "deployed"
+	StackStatusFailed   StackStatus = "failed"
+)
+
+// Stack là một file docker-compose được AppDock lưu và deploy lên một server
+type Stack struct {
+	ID         string            `json:"id"`
+	Name       string            `json:"name"` // dùng làm compose project name
+	ServerID   string            `json:"serverId"`
+	Compose    string            `json:"compose"`
+	Env        map[string]string `json:"env"` // biến dùng khi interpolate ${VAR}
+	Status     StackStatus       `json:"status"`
+	LastError  string            `json:"lastError,omitempty"`
+	DeployedAt *time.Time        `json:"deployedAt,omitempty"`
+	CreatedAt  time.Time         `json:"createdAt"`
+	UpdatedAt  time.Time         `json:"updatedAt"`
+}
+
+type CreateStackRequest struct {
+	Name    string            `json:"name" binding:"required"`
+	Compose string            `json:"compose" binding:"required"`
+	Env     map[string]string `json:"env"`
+	Deploy  bool              `json:"deploy"` // deploy ngay sau khi lưu
+}
+
+type UpdateStackRequest struct {
+	Compose *string           `json:"compose,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+	Deploy  bool              `json:"deploy"` // redeploy sau khi lưu
+}
+
+func NewStack(serverID string, req CreateStackRequest) *Stack {
+	now := time.Now()
+	env := req.Env
+	if env == nil {
+		env = map[string]string{}
+	}
+	return &Stack{
+		ID:        uuid.New().String(),
+		Name:      req.Name,
+		ServerID:  serverID,
+		Compose:   req.Compose,
+		Env:       env,
+		Status:    StackStatusInactive,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
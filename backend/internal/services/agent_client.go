@@ -2,17 +2,29 @@ package services
 
 import (
 	"bytes"
+	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/registry"
+	"github.com/gorilla/websocket"
 )
 
 type AgentClient struct {
-	baseURL    string
-	apiKey     string
-	httpClient *http.Client
+	baseURL      string
+	apiKey       string
+	httpClient   *http.Client
+	streamClient *http.Client // no timeout, used for long-lived streams
 }
 
 func NewAgentClient(host, apiKey string) *AgentClient {
@@ -22,10 +34,16 @@ func NewAgentClient(host, apiKey string) *AgentClient {
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		streamClient: &http.Client{},
 	}
 }
 
 func (c *AgentClient) doRequest(method, path string, body interface{}) ([]byte, error) {
+	return c.doRequestWith(context.Background(), c.httpClient, method, path, body)
+}
+
+// doRequestWith sends a JSON request with the given client, bounded by ctx
+func (c *AgentClient) doRequestWith(ctx context.Context, httpClient *http.Client, method, path string, body interface{}) ([]byte, error) {
 	url := c.baseURL + path
 
 	var reqBody io.Reader
@@ -37,7 +55,7 @@ func (c *AgentClient) doRequest(method, path string, body interface{}) ([]byte,
 		reqBody = bytes.NewBuffer(jsonBody)
 	}
 
-	req, err := http.NewRequest(method, url, reqBody)
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
 	if err != nil {
 		return nil, err
 	}
@@ -45,7 +63,7 @@ func (c *AgentClient) doRequest(method, path string, body interface{}) ([]byte,
 	req.Header.Set("X-API-Key", c.apiKey)
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -57,18 +75,109 @@ func (c *AgentClient) doRequest(method, path string, body interface{}) ([]byte,
 	}
 
 	if resp.StatusCode >= 400 {
-		var errResp struct {
-			Error string `json:"error"`
-		}
-		if json.Unmarshal(respBody, &errResp) == nil && errResp.Error != "" {
-			return nil, fmt.Errorf("%s", errResp.Error)
-		}
-		return nil, fmt.Errorf("agent returned status %d", resp.StatusCode)
+		return nil, newAgentError(resp.StatusCode, respBody)
 	}
 
 	return respBody, nil
 }
 
+// AgentError is an error response from the agent, keeping the HTTP status so callers
+// can tell e.g. a missing resource apart from other failures
+type AgentError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *AgentError) Error() string {
+	return e.Message
+}
+
+func newAgentError(statusCode int, body []byte) error {
+	var errResp struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(body, &errResp) == nil && errResp.Error != "" {
+		return &AgentError{StatusCode: statusCode, Message: errResp.Error}
+	}
+	return &AgentError{StatusCode: statusCode, Message: fmt.Sprintf("agent returned status %d", statusCode)}
+}
+
+// isAgentNotFound reports whether the agent answered 404
+func isAgentNotFound(err error) bool {
+	var agentErr *AgentError
+	return errors.As(err, &agentErr) && agentErr.StatusCode == http.StatusNotFound
+}
+
+// doStreamRequest performs a request without timeout and returns the response body
+// for the caller to consume. The caller must close the returned reader.
+func (c *AgentClient) doStreamRequest(method, path string, body io.Reader, contentType string) (io.ReadCloser, error) {
+	req, err := http.NewRequest(method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return c.doStream(req)
+}
+
+// doStream sends a prepared request without timeout and returns the response body
+func (c *AgentClient) doStream(req *http.Request) (io.ReadCloser, error) {
+	resp, err := c.doStreamResponse(req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// doStreamResponse is doStream for callers that also need the response headers
+func (c *AgentClient) doStreamResponse(req *http.Request) (*http.Response, error) {
+	req.Header.Set("X-API-Key", c.apiKey)
+
+	resp, err := c.streamClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, newAgentError(resp.StatusCode, respBody)
+	}
+
+	return resp, nil
+}
+
+// dialWebSocket opens a WebSocket connection to the agent
+func (c *AgentClient) dialWebSocket(path string) (*websocket.Conn, error) {
+	wsURL := c.baseURL + path
+	if strings.HasPrefix(wsURL, "https://") {
+		wsURL = "wss://" + strings.TrimPrefix(wsURL, "https://")
+	} else if strings.HasPrefix(wsURL, "http://") {
+		wsURL = "ws://" + strings.TrimPrefix(wsURL, "http://")
+	}
+
+	header := http.Header{}
+	header.Set("X-API-Key", c.apiKey)
+
+	dialer := websocket.Dialer{HandshakeTimeout: 30 * time.Second}
+	conn, resp, err := dialer.Dial(wsURL, header)
+	if err != nil {
+		if resp != nil && resp.Body != nil {
+			defer resp.Body.Close()
+			var errResp struct {
+				Error string `json:"error"`
+			}
+			if json.NewDecoder(resp.Body).Decode(&errResp) == nil && errResp.Error != "" {
+				return nil, fmt.Errorf("%s", errResp.Error)
+			}
+		}
+		return nil, err
+	}
+	return conn, nil
+}
+
 // ==================== Health ====================
 
 func (c *AgentClient) Health() error {
@@ -164,18 +273,77 @@ func (c *AgentClient) GetContainer(id string) (json.RawMessage, error) {
 	return c.doRequest("GET", "/api/docker/containers/"+id, nil)
 }
 
+// CreateContainer creates a container on the agent. registryAuth is used if the
+// agent has to pull the image first.
+func (c *AgentClient) CreateContainer(spec *ContainerSpec, registryAuth string) (json.RawMessage, error) {
+	body := struct {
+		*ContainerSpec
+		RegistryAuth string `json:"registryAuth,omitempty"`
+	}{spec, registryAuth}
+	return c.doRequestWithTimeout("POST", "/api/docker/containers", body, 10*time.Minute)
+}
+
 func (c *AgentClient) StartContainer(id string) error {
 	_, err := c.doRequest("POST", "/api/docker/containers/"+id+"/start", nil)
 	return err
 }
 
-func (c *AgentClient) StopContainer(id string) error {
-	_, err := c.doRequest("POST", "/api/docker/containers/"+id+"/stop", nil)
+// stopTimeoutQuery returns the "?timeout=N" query for stop/restart, empty to use the container's own timeout
+func stopTimeoutQuery(timeout *int) string {
+	if timeout == nil {
+		return ""
+	}
+	return "?timeout=" + strconv.Itoa(*timeout)
+}
+
+// stopRequestTimeout is how long to wait for the agent to answer stop/restart; it must exceed the stop timeout
+func stopRequestTimeout(timeout *int) time.Duration {
+	switch {
+	case timeout == nil:
+		// The stop timeout configured on the container is not known here
+		return 2 * time.Minute
+	case *timeout < 0:
+		return 0 // wait without limit
+	default:
+		return time.Duration(*timeout)*time.Second + 30*time.Second
+	}
+}
+
+func (c *AgentClient) StopContainer(id string, timeout *int) error {
+	_, err := c.doRequestWithTimeout("POST", "/api/docker/containers/"+id+"/stop"+stopTimeoutQuery(timeout), nil, stopRequestTimeout(timeout))
+	return err
+}
+
+func (c *AgentClient) RestartContainer(id string, timeout *int) error {
+	_, err := c.doRequestWithTimeout("POST", "/api/docker/containers/"+id+"/restart"+stopTimeoutQuery(timeout), nil, stopRequestTimeout(timeout))
+	return err
+}
+
+func (c *AgentClient) UpdateContainer(id string, update container.UpdateConfig) (json.RawMessage, error) {
+	return c.doRequest("POST", "/api/docker/containers/"+id+"/update", update)
+}
+
+func (c *AgentClient) KillContainer(id, signal string) error {
+	_, err := c.doRequest("POST", "/api/docker/containers/"+id+"/kill", map[string]string{"signal": signal})
 	return err
 }
 
-func (c *AgentClient) RestartContainer(id string) error {
-	_, err := c.doRequest("POST", "/api/docker/containers/"+id+"/restart", nil)
+func (c *AgentClient) PauseContainer(id string) error {
+	_, err := c.doRequest("POST", "/api/docker/containers/"+id+"/pause", nil)
+	return err
+}
+
+func (c *AgentClient) UnpauseContainer(id string) error {
+	_, err := c.doRequest("POST", "/api/docker/containers/"+id+"/unpause", nil)
+	return err
+}
+
+func (c *AgentClient) TopContainer(id, psArgs string) (json.RawMessage, error) {
+	return c.doRequest("GET", "/api/docker/containers/"+id+"/top?"+url.Values{"ps_args": {psArgs}}.Encode(), nil)
+}
+
+func (c *AgentClient) RenameContainer(id, name string) error {
+	_, err := c.doRequest("POST", "/api/docker/containers/"+id+"/rename", map[string]string{"name": name})
 	return err
 }
 
@@ -192,10 +360,150 @@ func (c *AgentClient) GetContainerLogs(id string, tail string) (json.RawMessage,
 	return c.doRequest("GET", "/api/docker/containers/"+id+"/logs?tail="+tail, nil)
 }
 
+func (c *AgentClient) StreamContainerLogs(id string, follow bool, tail, since string) (io.ReadCloser, error) {
+	query := url.Values{}
+	if follow {
+		query.Set("follow", "true")
+	}
+	if tail != "" {
+		query.Set("tail", tail)
+	}
+	if since != "" {
+		query.Set("since", since)
+	}
+	return c.doStreamRequest("GET", "/api/docker/containers/"+id+"/logs/stream?"+query.Encode(), nil, "")
+}
+
+// StreamContainerStats streams Docker's stats for a container on the agent
+func (c *AgentClient) StreamContainerStats(id string) (io.ReadCloser, error) {
+	return c.doStreamRequest("GET", "/api/docker/containers/"+id+"/stats/stream", nil, "")
+}
+
+// agentExecSession adapts an agent exec WebSocket to ExecSession.
+// Binary messages carry raw terminal input/output, text messages carry JSON control messages.
+type agentExecSession struct {
+	conn    *websocket.Conn
+	pending []byte
+	writeMu sync.Mutex
+}
+
+func (s *agentExecSession) Read(p []byte) (int, error) {
+	for len(s.pending) == 0 {
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				return 0, io.EOF
+			}
+			return 0, err
+		}
+		s.pending = data
+	}
+	n := copy(p, s.pending)
+	s.pending = s.pending[n:]
+	return n, nil
+}
+
+func (s *agentExecSession) Write(p []byte) (int, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if err := s.conn.WriteMessage(websocket.BinaryMessage, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (s *agentExecSession) Resize(height, width uint) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return s.conn.WriteJSON(map[string]interface{}{
+		"type": "resize",
+		"rows": height,
+		"cols": width,
+	})
+}
+
+func (s *agentExecSession) Close() error {
+	return s.conn.Close()
+}
+
+func (c *AgentClient) OpenExec(id string, opts ExecOptions) (ExecSession, error) {
+	query := url.Values{}
+	// One cmd parameter per argument so arguments containing spaces survive
+	for _, arg := range opts.Cmd {
+		query.Add("cmd", arg)
+	}
+	if opts.User != "" {
+		query.Set("user", opts.User)
+	}
+	if opts.WorkingDir != "" {
+		query.Set("workdir", opts.WorkingDir)
+	}
+	for _, env := range opts.Env {
+		query.Add("env", env)
+	}
+	conn, err := c.dialWebSocket("/api/docker/containers/" + id + "/exec?" + query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	return &agentExecSession{conn: conn}, nil
+}
+
 func (c *AgentClient) GetContainerStats(id string) (json.RawMessage, error) {
 	return c.doRequest("GET", "/api/docker/containers/"+id+"/stats", nil)
 }
 
+// CopyFromContainer streams a tar of a path inside a container on the agent
+func (c *AgentClient) CopyFromContainer(id, path string) (io.ReadCloser, *container.PathStat, error) {
+	req, err := http.NewRequest("GET", c.baseURL+"/api/docker/containers/"+id+"/archive?"+url.Values{"path": {path}}.Encode(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := c.doStreamResponse(req)
+	if err != nil {
+		if isAgentNotFound(err) {
+			return nil, nil, fmt.Errorf("%w: %v", ErrContainerPathNotFound, err)
+		}
+		return nil, nil, err
+	}
+
+	var stat container.PathStat
+	if header := resp.Header.Get("X-Docker-Container-Path-Stat"); header != "" {
+		if data, err := base64.StdEncoding.DecodeString(header); err == nil {
+			json.Unmarshal(data, &stat)
+		}
+	}
+	return resp.Body, &stat, nil
+}
+
+// CopyToContainer extracts a tar into a directory inside a container on the agent
+func (c *AgentClient) CopyToContainer(id, path string, content io.Reader, copyUIDGID bool) error {
+	query := url.Values{"path": {path}}
+	if copyUIDGID {
+		query.Set("copyUIDGID", "true")
+	}
+	body, err := c.doStreamRequest("PUT", "/api/docker/containers/"+id+"/archive?"+query.Encode(), content, "application/x-tar")
+	if err != nil {
+		if isAgentNotFound(err) {
+			return fmt.Errorf("%w: %v", ErrContainerPathNotFound, err)
+		}
+		return err
+	}
+	return body.Close()
+}
+
+// GetContainerChanges lists filesystem changes of a container on the agent
+func (c *AgentClient) GetContainerChanges(id string) ([]container.FilesystemChange, error) {
+	data, err := c.doRequest("GET", "/api/docker/containers/"+id+"/changes", nil)
+	if err != nil {
+		return nil, err
+	}
+	var changes []container.FilesystemChange
+	if err := json.Unmarshal(data, &changes); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
 // Images
 
 func (c *AgentClient) ListImages() (json.RawMessage, error) {
@@ -206,6 +514,96 @@ func (c *AgentClient) GetImage(id string) (json.RawMessage, error) {
 	return c.doRequest("GET", "/api/docker/images/"+id, nil)
 }
 
+// PullImage pulls an image on the agent and waits until the pull completes.
+// Progress is streamed so large images are not bound by a request timeout.
+func (c *AgentClient) PullImage(ref, registryAuth string) error {
+	reader, err := c.StreamPullImage(ref, registryAuth)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	return DecodeProgress(reader, nil)
+}
+
+// StreamPullImage returns the Docker JSON progress stream of a pull running on the agent
+func (c *AgentClient) StreamPullImage(ref, registryAuth string) (io.ReadCloser, error) {
+	body, err := json.Marshal(map[string]string{"image": ref, "registryAuth": registryAuth})
+	if err != nil {
+		return nil, err
+	}
+	return c.doStreamRequest("POST", "/api/docker/images/pull/stream", bytes.NewReader(body), "application/json")
+}
+
+func (c *AgentClient) GetImageHistory(id string) (json.RawMessage, error) {
+	return c.doRequest("GET", "/api/docker/images/"+id+"/history", nil)
+}
+
+// SaveImage streams the "docker save" tar of the given images from the agent
+func (c *AgentClient) SaveImage(refs []string) (io.ReadCloser, error) {
+	query := url.Values{"image": refs}
+	return c.doStreamRequest("GET", "/api/docker/images/save?"+query.Encode(), nil, "")
+}
+
+// LoadImage streams a "docker save" tar to the agent's "docker load" and returns the Docker output
+func (c *AgentClient) LoadImage(input io.Reader) (io.ReadCloser, error) {
+	return c.doStreamRequest("POST", "/api/docker/images/load", input, "application/x-tar")
+}
+
+func (c *AgentClient) TagImage(source, target string) error {
+	_, err := c.doRequest("POST", "/api/docker/images/tag", map[string]string{"source": source, "target": target})
+	return err
+}
+
+// StreamPushImage returns the Docker JSON progress stream of a push running on the agent
+func (c *AgentClient) StreamPushImage(ref, registryAuth string) (io.ReadCloser, error) {
+	body, err := json.Marshal(map[string]string{"image": ref, "registryAuth": registryAuth})
+	if err != nil {
+		return nil, err
+	}
+	return c.doStreamRequest("POST", "/api/docker/images/push/stream", bytes.NewReader(body), "application/json")
+}
+
+// BuildImage streams a build context to the agent and returns the Docker build output.
+// buildContext may be nil when opts.ContextPath points to a directory on the agent host.
+func (c *AgentClient) BuildImage(buildContext io.Reader, opts BuildImageOptions, authConfigs map[string]registry.AuthConfig) (io.ReadCloser, error) {
+	req, err := http.NewRequest("POST", c.baseURL+"/api/docker/images/build?"+opts.query().Encode(), buildContext)
+	if err != nil {
+		return nil, err
+	}
+	if buildContext != nil {
+		req.Header.Set("Content-Type", "application/x-tar")
+	}
+	if len(authConfigs) > 0 {
+		data, err := json.Marshal(authConfigs)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("X-Registry-Config", base64.URLEncoding.EncodeToString(data))
+	}
+	return c.doStream(req)
+}
+
+// RemoveImages deletes several images on the agent and reports failures per image
+func (c *AgentClient) RemoveImages(ids []string, force bool) (json.RawMessage, error) {
+	body := map[string]interface{}{"ids": ids, "force": force}
+	return c.doRequestWithTimeout("DELETE", "/api/docker/images/bulk", body, 5*time.Minute)
+}
+
+// Prune removes unused Docker resources on the agent and reports reclaimed space
+func (c *AgentClient) Prune(opts PruneOptions) (json.RawMessage, error) {
+	return c.doRequestWithTimeout("POST", "/api/docker/prune", opts, 30*time.Minute)
+}
+
+// RegistryLogin checks registry credentials from the agent's Docker daemon
+func (c *AgentClient) RegistryLogin(auth registry.AuthConfig) (json.RawMessage, error) {
+	body := map[string]string{
+		"username":      auth.Username,
+		"password":      auth.Password,
+		"serverAddress": auth.ServerAddress,
+	}
+	return c.doRequestWithTimeout("POST", "/api/docker/registry/login", body, 60*time.Second)
+}
+
 func (c *AgentClient) RemoveImage(id string, force bool) error {
 	path := "/api/docker/images/" + id
 	if force {
@@ -341,10 +739,14 @@ func (c *AgentClient) RevokeNginxCertificate(domain string) error {
 	return err
 }
 
-// doRequestWithTimeout is like doRequest but with a custom timeout
+// doRequestWithTimeout is like doRequest but with a custom timeout; 0 waits without limit.
+// The timeout is applied per request so concurrent requests keep their own deadlines.
 func (c *AgentClient) doRequestWithTimeout(method, path string, body interface{}, timeout time.Duration) ([]byte, error) {
-	oldTimeout := c.httpClient.Timeout
-	c.httpClient.Timeout = timeout
-	defer func() { c.httpClient.Timeout = oldTimeout }()
-	return c.doRequest(method, path, body)
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return c.doRequestWith(ctx, c.streamClient, method, path, body)
 }
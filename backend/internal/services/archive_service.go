@@ -0,0 +1,187 @@
+package services
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+)
+
+// ErrContainerPathNotFound khi container hoặc path trong container không tồn tại
+var ErrContainerPathNotFound = errors.New("path not found in container")
+
+// ContainerChange là một thay đổi trong filesystem của container so với image
+type ContainerChange struct {
+	Path string `json:"path"`
+	Kind string `json:"kind"` // "added", "modified" hoặc "deleted"
+}
+
+// ContainerUploadFile là một file được upload vào container
+type ContainerUploadFile struct {
+	Name    string
+	Size    int64
+	Content io.Reader
+}
+
+// CopyFromContainer trả về tar của file/thư mục trong container (giống "docker cp" ra ngoài).
+// Entry trong tar mang tên base name của path.
+func (d *DockerService) CopyFromContainer(id, path string) (result io.ReadCloser, stat *container.PathStat, err error) {
+	if !d.IsConnected() {
+		return nil, nil, ErrDockerNotConnected
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			d.markDisconnected()
+			result = nil
+			stat = nil
+			err = ErrDockerNotConnected
+		}
+	}()
+	reader, pathStat, err := d.client.CopyFromContainer(d.ctx, id, path)
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			return nil, nil, fmt.Errorf("%w: %v", ErrContainerPathNotFound, err)
+		}
+		return nil, nil, d.handleError(err)
+	}
+	return reader, &pathStat, nil
+}
+
+// CopyToContainer giải nén tar vào thư mục path (phải tồn tại) trong container.
+// Owner ghi trong tar được giữ nguyên, trừ khi copyUIDGID = true và container có User:
+// khi đó file được chown cho user của container (giống "docker cp -a").
+func (d *DockerService) CopyToContainer(id, path string, content io.Reader, copyUIDGID bool) (err error) {
+	if !d.IsConnected() {
+		return ErrDockerNotConnected
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			d.markDisconnected()
+			err = ErrDockerNotConnected
+		}
+	}()
+	err = d.client.CopyToContainer(d.ctx, id, path, content, container.CopyToContainerOptions{
+		CopyUIDGID: copyUIDGID,
+	})
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			return fmt.Errorf("%w: %v", ErrContainerPathNotFound, err)
+		}
+		return d.handleError(err)
+	}
+	return nil
+}
+
+// ContainerDiff trả về các file đã thêm, sửa, xóa trong container so với image ("docker diff")
+func (d *DockerService) ContainerDiff(id string) (result []container.FilesystemChange, err error) {
+	if !d.IsConnected() {
+		return nil, ErrDockerNotConnected
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			d.markDisconnected()
+			result = nil
+			err = ErrDockerNotConnected
+		}
+	}()
+	changes, err := d.client.ContainerDiff(d.ctx, id)
+	if err != nil {
+		return nil, d.handleError(err)
+	}
+	return changes, nil
+}
+
+// CopyFromContainer trả về tar của path trong container trên server (local và agent)
+func (m *ServerManager) CopyFromContainer(serverID, containerID, path string) (io.ReadCloser, *container.PathStat, error) {
+	if m.IsLocal(serverID) {
+		return m.localDocker.CopyFromContainer(containerID, path)
+	}
+
+	client := m.getAgentClient(serverID)
+	if client == nil {
+		return nil, nil, ErrServerNotFound
+	}
+	return client.CopyFromContainer(containerID, path)
+}
+
+// CopyToContainer giải nén tar vào thư mục trong container trên server (local và agent)
+func (m *ServerManager) CopyToContainer(serverID, containerID, path string, content io.Reader, copyUIDGID bool) error {
+	if m.IsLocal(serverID) {
+		return m.localDocker.CopyToContainer(containerID, path, content, copyUIDGID)
+	}
+
+	client := m.getAgentClient(serverID)
+	if client == nil {
+		return ErrServerNotFound
+	}
+	return client.CopyToContainer(containerID, path, content, copyUIDGID)
+}
+
+// UploadToContainer ghi các file vào thư mục dir trong container, ghi đè file đã có.
+// dir phải tồn tại, nếu không trả về ErrContainerPathNotFound.
+// copyUIDGID = true chown file cho user của container.
+func (m *ServerManager) UploadToContainer(serverID, containerID, dir string, files []ContainerUploadFile, copyUIDGID bool) error {
+	pr, pw := io.Pipe()
+	go func() {
+		tw := tar.NewWriter(pw)
+		var err error
+		for _, file := range files {
+			err = tw.WriteHeader(&tar.Header{
+				Typeflag: tar.TypeReg,
+				Name:     file.Name,
+				Mode:     0644,
+				Size:     file.Size,
+				ModTime:  time.Now(),
+			})
+			if err == nil {
+				_, err = io.Copy(tw, file.Content)
+			}
+			if err != nil {
+				break
+			}
+		}
+		if closeErr := tw.Close(); err == nil {
+			err = closeErr
+		}
+		pw.CloseWithError(err)
+	}()
+
+	err := m.CopyToContainer(serverID, containerID, dir, pr, copyUIDGID)
+	pr.Close()
+	return err
+}
+
+// GetContainerChanges trả về thay đổi filesystem của container trên server (local và agent)
+func (m *ServerManager) GetContainerChanges(serverID, containerID string) ([]ContainerChange, error) {
+	var changes []container.FilesystemChange
+	var err error
+	if m.IsLocal(serverID) {
+		changes, err = m.localDocker.ContainerDiff(containerID)
+	} else {
+		client := m.getAgentClient(serverID)
+		if client == nil {
+			return nil, ErrServerNotFound
+		}
+		changes, err = client.GetContainerChanges(containerID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]ContainerChange, 0, len(changes))
+	for _, change := range changes {
+		kind := "modified"
+		switch change.Kind {
+		case container.ChangeAdd:
+			kind = "added"
+		case container.ChangeDelete:
+			kind = "deleted"
+		}
+		result = append(result, ContainerChange{Path: change.Path, Kind: kind})
+	}
+	return result, nil
+}
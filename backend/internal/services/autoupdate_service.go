@@ -0,0 +1,538 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"appdock/internal/models"
+)
+
+// Số sự kiện auto-update được giữ lại trong lịch sử
+const autoUpdateHistoryLimit = 200
+
+var (
+	ErrAutoUpdatePolicyNotFound = errors.New("auto-update policy not found")
+	ErrInvalidScheduleWindow    = errors.New(`schedule window must have start and end as "HH:MM" and days in mon..sun`)
+	ErrInvalidTimezone          = errors.New("unknown timezone")
+)
+
+var weekdayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// AutoUpdateStore lưu cấu hình, chính sách theo container và lịch sử auto-update trong autoupdate.json
+type AutoUpdateStore struct {
+	settings models.AutoUpdateSettings
+	policies map[string]*models.AutoUpdatePolicy // serverID|container -> policy
+	history  []models.AutoUpdateEvent
+	filePath string
+	mu       sync.RWMutex
+}
+
+type autoUpdateFile struct {
+	Settings models.AutoUpdateSettings  `json:"settings"`
+	Policies []*models.AutoUpdatePolicy `json:"policies"`
+	History  []models.AutoUpdateEvent   `json:"history"`
+}
+
+func NewAutoUpdateStore(dataDir string) (*AutoUpdateStore, error) {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, err
+	}
+
+	store := &AutoUpdateStore{
+		settings: models.AutoUpdateSettings{
+			Enabled:       true,
+			CheckInterval: 60,
+			HealthTimeout: int(defaultRecreateHealthTimeout / time.Second),
+			Notifications: models.NotificationSettings{OnFailure: true},
+		},
+		policies: make(map[string]*models.AutoUpdatePolicy),
+		filePath: filepath.Join(dataDir, "autoupdate.json"),
+	}
+
+	if err := store.load(); err != nil {
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	return store, nil
+}
+
+func (s *AutoUpdateStore) load() error {
+	data, err := os.ReadFile(s.filePath)
+	if err != nil {
+		return err
+	}
+
+	// Giữ giá trị mặc định cho các trường không có trong file
+	stored := autoUpdateFile{Settings: s.settings}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return err
+	}
+
+	s.settings = stored.Settings
+	s.history = stored.History
+	for _, policy := range stored.Policies {
+		s.policies[policyKey(policy.ServerID, policy.Container)] = policy
+	}
+	return nil
+}
+
+func (s *AutoUpdateStore) save() error {
+	stored := autoUpdateFile{
+		Settings: s.settings,
+		Policies: make([]*models.AutoUpdatePolicy, 0, len(s.policies)),
+		History:  s.history,
+	}
+	for _, policy := range s.policies {
+		stored.Policies = append(stored.Policies, policy)
+	}
+
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.filePath, data, 0644)
+}
+
+func policyKey(serverID, container string) string {
+	return normalizeServerID(serverID) + "|" + container
+}
+
+func (s *AutoUpdateStore) Settings() models.AutoUpdateSettings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.settings
+}
+
+func (s *AutoUpdateStore) UpdateSettings(settings models.AutoUpdateSettings) (models.AutoUpdateSettings, error) {
+	if err := validateScheduleWindows(settings.Windows); err != nil {
+		return settings, err
+	}
+	if settings.Timezone != "" {
+		if _, err := time.LoadLocation(settings.Timezone); err != nil {
+			return settings, ErrInvalidTimezone
+		}
+	}
+	if settings.CheckInterval <= 0 {
+		settings.CheckInterval = 60
+	}
+	if settings.HealthTimeout <= 0 {
+		settings.HealthTimeout = int(defaultRecreateHealthTimeout / time.Second)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous := s.settings
+	s.settings = settings
+	if err := s.save(); err != nil {
+		s.settings = previous
+		return previous, err
+	}
+	return settings, nil
+}
+
+// Policy trả về chính sách đặt qua API, nil nếu container dùng label
+func (s *AutoUpdateStore) Policy(serverID, container string) *models.AutoUpdatePolicy {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	policy, exists := s.policies[policyKey(serverID, container)]
+	if !exists {
+		return nil
+	}
+	copied := *policy
+	return &copied
+}
+
+func (s *AutoUpdateStore) SetPolicy(serverID, container string, req models.SetAutoUpdatePolicyRequest) (*models.AutoUpdatePolicy, error) {
+	if err := validateScheduleWindows(req.Windows); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := policyKey(serverID, container)
+	previous := s.policies[key]
+	policy := &models.AutoUpdatePolicy{
+		ServerID:  normalizeServerID(serverID),
+		Container: container,
+		Enabled:   req.Enabled,
+		Windows:   req.Windows,
+		UpdatedAt: time.Now(),
+	}
+	s.policies[key] = policy
+	if err := s.save(); err != nil {
+		if previous != nil {
+			s.policies[key] = previous
+		} else {
+			delete(s.policies, key)
+		}
+		return nil, err
+	}
+	return policy, nil
+}
+
+// DeletePolicy xóa chính sách API, container quay lại dùng label
+func (s *AutoUpdateStore) DeletePolicy(serverID, container string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := policyKey(serverID, container)
+	policy, exists := s.policies[key]
+	if !exists {
+		return ErrAutoUpdatePolicyNotFound
+	}
+	delete(s.policies, key)
+	if err := s.save(); err != nil {
+		s.policies[key] = policy
+		return err
+	}
+	return nil
+}
+
+func (s *AutoUpdateStore) AddEvent(event models.AutoUpdateEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.history = append(s.history, event)
+	if len(s.history) > autoUpdateHistoryLimit {
+		s.history = s.history[len(s.history)-autoUpdateHistoryLimit:]
+	}
+	if err := s.save(); err != nil {
+		log.Printf("Could not save auto-update history: %v", err)
+	}
+}
+
+// History trả về lịch sử mới nhất trước; serverID rỗng = mọi server
+func (s *AutoUpdateStore) History(serverID string) []models.AutoUpdateEvent {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	events := make([]models.AutoUpdateEvent, 0, len(s.history))
+	for i := len(s.history) - 1; i >= 0; i-- {
+		if serverID == "" || s.history[i].ServerID == serverID {
+			events = append(events, s.history[i])
+		}
+	}
+	return events
+}
+
+// ==================== Schedule windows ====================
+
+func validateScheduleWindows(windows []models.ScheduleWindow) error {
+	for _, w := range windows {
+		if _, err := parseClock(w.Start); err != nil {
+			return ErrInvalidScheduleWindow
+		}
+		if _, err := parseClock(w.End); err != nil {
+			return ErrInvalidScheduleWindow
+		}
+		for _, day := range w.Days {
+			if weekdayIndex(day) < 0 {
+				return ErrInvalidScheduleWindow
+			}
+		}
+	}
+	return nil
+}
+
+// parseClock chuyển "HH:MM" thành số phút trong ngày
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func weekdayIndex(day string) int {
+	day = strings.ToLower(strings.TrimSpace(day))
+	for i, name := range weekdayNames {
+		if strings.HasPrefix(day, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// inScheduleWindows kiểm tra now có nằm trong một trong các khung giờ không, không có khung giờ = luôn đúng
+func inScheduleWindows(windows []models.ScheduleWindow, now time.Time) bool {
+	if len(windows) == 0 {
+		return true
+	}
+
+	minute := now.Hour()*60 + now.Minute()
+	for _, w := range windows {
+		start, errStart := parseClock(w.Start)
+		end, errEnd := parseClock(w.End)
+		if errStart != nil || errEnd != nil {
+			continue
+		}
+		switch {
+		case start < end:
+			if minute >= start && minute < end && windowHasDay(w, now.Weekday()) {
+				return true
+			}
+		case start > end:
+			// Khung giờ qua nửa đêm thuộc về ngày bắt đầu
+			if minute >= start && windowHasDay(w, now.Weekday()) {
+				return true
+			}
+			if minute < end && windowHasDay(w, now.AddDate(0, 0, -1).Weekday()) {
+				return true
+			}
+		default:
+			if windowHasDay(w, now.Weekday()) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func windowHasDay(w models.ScheduleWindow, weekday time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, day := range w.Days {
+		if weekdayIndex(day) == int(weekday) {
+			return true
+		}
+	}
+	return false
+}
+
+// ==================== Auto updater ====================
+
+// AutoUpdater cập nhật các container đã bật auto-update (label appdock.autoupdate=true hoặc qua API)
+// khi tag của image có digest mới, trong khung giờ cho phép. Container mới không khởi động được
+// hoặc healthcheck báo unhealthy sẽ được rollback về container cũ.
+type AutoUpdater struct {
+	store         *AutoUpdateStore
+	serverManager *ServerManager
+	checker       *UpdateChecker
+	lastChecked   map[string]time.Time // serverID|container -> lần kiểm tra gần nhất
+	runMu         sync.Mutex
+	mu            sync.Mutex
+}
+
+func NewAutoUpdater(store *AutoUpdateStore, sm *ServerManager, checker *UpdateChecker) *AutoUpdater {
+	updater := &AutoUpdater{
+		store:         store,
+		serverManager: sm,
+		checker:       checker,
+		lastChecked:   make(map[string]time.Time),
+	}
+	go updater.loop()
+	return updater
+}
+
+func (a *AutoUpdater) loop() {
+	time.Sleep(updateCheckStartDelay)
+
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		if a.store.Settings().Enabled {
+			for _, server := range a.serverManager.store.List() {
+				if _, err := a.run(server.ID, false); err != nil {
+					log.Printf("Auto-update failed for server %s: %v", server.Name, err)
+				}
+			}
+		}
+		<-ticker.C
+	}
+}
+
+// Containers trả về trạng thái auto-update của các container đang chạy trên server
+func (a *AutoUpdater) Containers(serverID string) ([]models.AutoUpdateContainer, error) {
+	containers, err := a.serverManager.ListContainers(serverID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := a.store.Settings()
+	result := make([]models.AutoUpdateContainer, 0, len(containers))
+	for _, c := range containers {
+		enabled, source, windows := a.policyFor(serverID, c, settings)
+		if windows == nil {
+			windows = make([]models.ScheduleWindow, 0)
+		}
+		result = append(result, models.AutoUpdateContainer{
+			ID:              c.ID,
+			Name:            c.Name,
+			Image:           c.Image,
+			Enabled:         enabled,
+			Source:          source,
+			Windows:         windows,
+			UpdateAvailable: c.UpdateAvailable,
+		})
+	}
+	return result, nil
+}
+
+// policyFor trả về chính sách hiệu lực của container: API ưu tiên hơn label
+func (a *AutoUpdater) policyFor(serverID string, c ContainerInfo, settings models.AutoUpdateSettings) (bool, string, []models.ScheduleWindow) {
+	if policy := a.store.Policy(serverID, c.Name); policy != nil {
+		windows := settings.Windows
+		if len(policy.Windows) > 0 {
+			windows = policy.Windows
+		}
+		return policy.Enabled, "api", windows
+	}
+	if strings.EqualFold(c.Labels[models.AutoUpdateLabel], "true") {
+		return true, "label", settings.Windows
+	}
+	return false, "", settings.Windows
+}
+
+// RunNow kiểm tra và cập nhật ngay các container đã bật auto-update trên server, bỏ qua khung giờ
+func (a *AutoUpdater) RunNow(serverID string) ([]models.AutoUpdateEvent, error) {
+	return a.run(serverID, true)
+}
+
+func (a *AutoUpdater) run(serverID string, force bool) ([]models.AutoUpdateEvent, error) {
+	a.runMu.Lock()
+	defer a.runMu.Unlock()
+
+	serverID = normalizeServerID(serverID)
+	settings := a.store.Settings()
+	now := time.Now()
+	if settings.Timezone != "" {
+		if loc, err := time.LoadLocation(settings.Timezone); err == nil {
+			now = now.In(loc)
+		}
+	}
+
+	containers, err := a.serverManager.listContainerInfos(serverID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// Chọn container đã bật, đang trong khung giờ và đã tới lượt kiểm tra
+	interval := time.Duration(settings.CheckInterval) * time.Minute
+	candidates := make([]ContainerInfo, 0)
+	a.mu.Lock()
+	for _, c := range containers {
+		enabled, _, windows := a.policyFor(serverID, c, settings)
+		if !enabled || normalizeImageTag(c.Image) == nil {
+			continue
+		}
+		key := policyKey(serverID, c.Name)
+		if !force && (!inScheduleWindows(windows, now) || time.Since(a.lastChecked[key]) < interval) {
+			continue
+		}
+		a.lastChecked[key] = time.Now()
+		candidates = append(candidates, c)
+	}
+	a.mu.Unlock()
+
+	events := make([]models.AutoUpdateEvent, 0)
+	if len(candidates) == 0 {
+		return events, nil
+	}
+
+	updates, err := a.checker.CheckServer(serverID)
+	if err != nil {
+		return nil, err
+	}
+	updatesByImage := make(map[string]ImageUpdate, len(updates))
+	for _, update := range updates {
+		updatesByImage[update.Image] = update
+	}
+
+	for _, c := range candidates {
+		update, ok := updatesByImage[normalizeImageTag(c.Image).String()]
+		if !ok || !update.UpdateAvailable {
+			continue
+		}
+		event := a.updateContainer(serverID, c, update, settings)
+		a.store.AddEvent(event)
+		a.notify(event, settings.Notifications)
+		events = append(events, event)
+	}
+
+	// Làm mới cờ updateAvailable sau khi đã cập nhật
+	if len(events) > 0 {
+		a.checker.CheckServer(serverID)
+	}
+	return events, nil
+}
+
+func (a *AutoUpdater) updateContainer(serverID string, c ContainerInfo, update ImageUpdate, settings models.AutoUpdateSettings) models.AutoUpdateEvent {
+	event := models.AutoUpdateEvent{
+		ServerID:   serverID,
+		ServerName: serverID,
+		Container:  c.Name,
+		Image:      c.Image,
+		OldDigest:  update.LocalDigest,
+		NewDigest:  update.RemoteDigest,
+		StartedAt:  time.Now(),
+	}
+	if server, err := a.serverManager.store.Get(serverID); err == nil {
+		event.ServerName = server.Name
+	}
+
+	result, err := a.serverManager.RecreateContainer(serverID, c.ID, RecreateOptions{HealthTimeout: settings.HealthTimeout})
+	event.FinishedAt = time.Now()
+	switch {
+	case err == nil:
+		event.Status = models.AutoUpdateStatusUpdated
+		event.ContainerID = result.ID
+		if settings.Cleanup && c.ImageID != "" {
+			// Image cũ vẫn được container khác dùng thì Docker sẽ từ chối xóa
+			a.serverManager.RemoveImage(serverID, c.ImageID, false)
+		}
+	case errors.Is(err, ErrRecreateRolledBack):
+		event.Status = models.AutoUpdateStatusRolledBack
+		event.Error = err.Error()
+	default:
+		// Gồm cả trường hợp không khôi phục được container cũ, lỗi khôi phục nằm trong err
+		event.Status = models.AutoUpdateStatusFailed
+		event.Error = err.Error()
+	}
+
+	log.Printf("Auto-update %s/%s (%s): %s %s", event.ServerName, c.Name, c.Image, event.Status, event.Error)
+	return event
+}
+
+func (a *AutoUpdater) notify(event models.AutoUpdateEvent, settings models.NotificationSettings) {
+	if settings.WebhookURL == "" {
+		return
+	}
+	success := event.Status == models.AutoUpdateStatusUpdated
+	if (success && !settings.OnSuccess) || (!success && !settings.OnFailure) {
+		return
+	}
+
+	text := fmt.Sprintf("AppDock: container %s on %s updated to the latest %s", event.Container, event.ServerName, event.Image)
+	switch event.Status {
+	case models.AutoUpdateStatusRolledBack:
+		text = fmt.Sprintf("AppDock: update of %s on %s (%s) failed and was rolled back: %s", event.Container, event.ServerName, event.Image, event.Error)
+	case models.AutoUpdateStatusFailed:
+		text = fmt.Sprintf("AppDock: update of %s on %s (%s) failed: %s", event.Container, event.ServerName, event.Image, event.Error)
+	}
+
+	if err := SendWebhook(settings.WebhookURL, "autoupdate."+event.Status, text, event); err != nil {
+		log.Printf("Could not send auto-update notification: %v", err)
+	}
+}
+
+// TestNotification gửi thông báo thử tới webhook đã cấu hình
+func (a *AutoUpdater) TestNotification() error {
+	settings := a.store.Settings().Notifications
+	if settings.WebhookURL == "" {
+		return errors.New("webhook URL is not configured")
+	}
+	return SendWebhook(settings.WebhookURL, "autoupdate.test", "AppDock: test notification for automatic container updates", nil)
+}
@@ -0,0 +1,404 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"appdock/internal/models"
+)
+
+var (
+	ErrBackupScheduleNotFound = errors.New("backup schedule not found")
+	ErrBackupNotFound         = errors.New("backup not found")
+	ErrInvalidBackupName      = errors.New("invalid volume or backup file name")
+)
+
+const backupFileExt = ".tar.gz"
+
+// Định dạng thời gian trong tên file backup, e.g. pgdata_20250101-030000.tar.gz
+const backupTimeLayout = "20060102-150405"
+
+// BackupStore lưu lịch backup trong volume_backups.json và các bản backup trong
+// <dataDir>/backups/<serverID>/<volume>/
+type BackupStore struct {
+	schedules map[string]*models.BackupSchedule
+	filePath  string
+	backupDir string
+	mu        sync.RWMutex
+}
+
+func NewBackupStore(dataDir string) (*BackupStore, error) {
+	backupDir := filepath.Join(dataDir, "backups")
+	if err := os.MkdirAll(backupDir, 0755); err != nil {
+		return nil, err
+	}
+
+	store := &BackupStore{
+		schedules: make(map[string]*models.BackupSchedule),
+		filePath:  filepath.Join(dataDir, "volume_backups.json"),
+		backupDir: backupDir,
+	}
+
+	if err := store.load(); err != nil {
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	return store, nil
+}
+
+func (s *BackupStore) load() error {
+	data, err := os.ReadFile(s.filePath)
+	if err != nil {
+		return err
+	}
+
+	var schedules []*models.BackupSchedule
+	if err := json.Unmarshal(data, &schedules); err != nil {
+		return err
+	}
+	for _, schedule := range schedules {
+		s.schedules[schedule.ID] = schedule
+	}
+	return nil
+}
+
+func (s *BackupStore) save() error {
+	schedules := make([]*models.BackupSchedule, 0, len(s.schedules))
+	for _, schedule := range s.schedules {
+		schedules = append(schedules, schedule)
+	}
+
+	data, err := json.MarshalIndent(schedules, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.filePath, data, 0644)
+}
+
+// ListSchedules trả về lịch backup của server, serverID rỗng = tất cả
+func (s *BackupStore) ListSchedules(serverID string) []models.BackupSchedule {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]models.BackupSchedule, 0, len(s.schedules))
+	for _, schedule := range s.schedules {
+		if serverID == "" || schedule.ServerID == serverID {
+			result = append(result, *schedule)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result
+}
+
+func (s *BackupStore) GetSchedule(id string) (*models.BackupSchedule, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	schedule, ok := s.schedules[id]
+	if !ok {
+		return nil, ErrBackupScheduleNotFound
+	}
+	copied := *schedule
+	return &copied, nil
+}
+
+func (s *BackupStore) CreateSchedule(serverID string, req models.CreateBackupScheduleRequest) (*models.BackupSchedule, error) {
+	if !validBackupName(req.Volume) {
+		return nil, ErrInvalidBackupName
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	schedule := models.NewBackupSchedule(normalizeServerID(serverID), req)
+	s.schedules[schedule.ID] = schedule
+	if err := s.save(); err != nil {
+		delete(s.schedules, schedule.ID)
+		return nil, err
+	}
+	copied := *schedule
+	return &copied, nil
+}
+
+func (s *BackupStore) UpdateSchedule(id string, req models.UpdateBackupScheduleRequest) (*models.BackupSchedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	schedule, ok := s.schedules[id]
+	if !ok {
+		return nil, ErrBackupScheduleNotFound
+	}
+	if req.Interval > 0 {
+		schedule.Interval = req.Interval
+	}
+	if req.Retention > 0 {
+		schedule.Retention = req.Retention
+	}
+	if req.Enabled != nil {
+		schedule.Enabled = *req.Enabled
+	}
+	schedule.UpdatedAt = time.Now()
+
+	if err := s.save(); err != nil {
+		return nil, err
+	}
+	copied := *schedule
+	return &copied, nil
+}
+
+func (s *BackupStore) DeleteSchedule(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.schedules[id]; !ok {
+		return ErrBackupScheduleNotFound
+	}
+	delete(s.schedules, id)
+	return s.save()
+}
+
+// recordRun lưu kết quả lần chạy gần nhất của lịch backup
+func (s *BackupStore) recordRun(id string, runAt time.Time, runErr error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	schedule, ok := s.schedules[id]
+	if !ok {
+		return
+	}
+	schedule.LastRunAt = &runAt
+	schedule.LastStatus = models.BackupStatusSuccess
+	schedule.LastError = ""
+	if runErr != nil {
+		schedule.LastStatus = models.BackupStatusFailed
+		schedule.LastError = runErr.Error()
+	}
+	s.save()
+}
+
+// validBackupName chặn tên có thể thoát ra ngoài thư mục backup (path traversal)
+func validBackupName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
+
+func (s *BackupStore) volumeDir(serverID, volumeName string) (string, error) {
+	serverID = normalizeServerID(serverID)
+	if !validBackupName(serverID) || !validBackupName(volumeName) {
+		return "", ErrInvalidBackupName
+	}
+	return filepath.Join(s.backupDir, serverID, volumeName), nil
+}
+
+// SaveBackup ghi tar.gz vào thư mục backup của volume. File chỉ xuất hiện khi đã ghi xong.
+func (s *BackupStore) SaveBackup(serverID, volumeName string, content io.Reader) (*models.VolumeBackup, error) {
+	dir, err := s.volumeDir(serverID, volumeName)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	tmpFile, err := os.CreateTemp(dir, ".backup-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmpFile.Name())
+
+	size, err := io.Copy(tmpFile, content)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	createdAt := time.Now()
+	name := volumeName + "_" + createdAt.Format(backupTimeLayout) + backupFileExt
+	for i := 1; ; i++ {
+		if _, err := os.Stat(filepath.Join(dir, name)); os.IsNotExist(err) {
+			break
+		}
+		name = fmt.Sprintf("%s_%s-%d%s", volumeName, createdAt.Format(backupTimeLayout), i, backupFileExt)
+	}
+	if err := os.Rename(tmpFile.Name(), filepath.Join(dir, name)); err != nil {
+		return nil, err
+	}
+
+	return &models.VolumeBackup{
+		File:      name,
+		ServerID:  normalizeServerID(serverID),
+		Volume:    volumeName,
+		Size:      size,
+		CreatedAt: createdAt,
+	}, nil
+}
+
+// ListBackups trả về các bản backup của volume, mới nhất trước
+func (s *BackupStore) ListBackups(serverID, volumeName string) ([]models.VolumeBackup, error) {
+	dir, err := s.volumeDir(serverID, volumeName)
+	if err != nil {
+		return nil, err
+	}
+
+	backups := make([]models.VolumeBackup, 0)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return backups, nil
+		}
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), backupFileExt) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, models.VolumeBackup{
+			File:      entry.Name(),
+			ServerID:  normalizeServerID(serverID),
+			Volume:    volumeName,
+			Size:      info.Size(),
+			CreatedAt: info.ModTime(),
+		})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+	return backups, nil
+}
+
+func (s *BackupStore) backupPath(serverID, volumeName, file string) (string, error) {
+	dir, err := s.volumeDir(serverID, volumeName)
+	if err != nil {
+		return "", err
+	}
+	if !validBackupName(file) || !strings.HasSuffix(file, backupFileExt) {
+		return "", ErrInvalidBackupName
+	}
+	return filepath.Join(dir, file), nil
+}
+
+// OpenBackup mở file backup để tải về hoặc restore
+func (s *BackupStore) OpenBackup(serverID, volumeName, file string) (*os.File, error) {
+	path, err := s.backupPath(serverID, volumeName, file)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrBackupNotFound
+		}
+		return nil, err
+	}
+	return f, nil
+}
+
+func (s *BackupStore) DeleteBackup(serverID, volumeName, file string) error {
+	path, err := s.backupPath(serverID, volumeName, file)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		if os.IsNotExist(err) {
+			return ErrBackupNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// pruneBackups chỉ giữ lại keep bản backup mới nhất của volume
+func (s *BackupStore) pruneBackups(serverID, volumeName string, keep int) error {
+	backups, err := s.ListBackups(serverID, volumeName)
+	if err != nil {
+		return err
+	}
+	for i := keep; i < len(backups); i++ {
+		if err := s.DeleteBackup(serverID, volumeName, backups[i].File); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BackupScheduler chạy các lịch backup tới hạn mỗi phút
+type BackupScheduler struct {
+	store         *BackupStore
+	serverManager *ServerManager
+	mu            sync.Mutex // chỉ chạy một backup tại một thời điểm
+}
+
+func NewBackupScheduler(store *BackupStore, sm *ServerManager) *BackupScheduler {
+	scheduler := &BackupScheduler{
+		store:         store,
+		serverManager: sm,
+	}
+	go scheduler.loop()
+	return scheduler
+}
+
+func (s *BackupScheduler) loop() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for range ticker.C {
+		now := time.Now()
+		for _, schedule := range s.store.ListSchedules("") {
+			if !schedule.Enabled {
+				continue
+			}
+			if schedule.LastRunAt != nil && now.Sub(*schedule.LastRunAt) < time.Duration(schedule.Interval)*time.Minute {
+				continue
+			}
+			if _, err := s.RunSchedule(schedule.ID); err != nil {
+				log.Printf("Backup of volume %s on server %s failed: %v", schedule.Volume, schedule.ServerID, err)
+			}
+		}
+	}
+}
+
+// Backup lưu bản backup của volume vào data dir
+func (s *BackupScheduler) Backup(serverID, volumeName string) (*models.VolumeBackup, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reader, err := s.serverManager.OpenVolumeBackup(serverID, volumeName)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return s.store.SaveBackup(serverID, volumeName, reader)
+}
+
+// RunSchedule chạy lịch backup ngay và xóa các bản cũ vượt quá retention
+func (s *BackupScheduler) RunSchedule(id string) (*models.VolumeBackup, error) {
+	schedule, err := s.store.GetSchedule(id)
+	if err != nil {
+		return nil, err
+	}
+
+	startedAt := time.Now()
+	backup, err := s.Backup(schedule.ServerID, schedule.Volume)
+	if err == nil {
+		err = s.store.pruneBackups(schedule.ServerID, schedule.Volume, schedule.Retention)
+	}
+	s.store.recordRun(id, startedAt, err)
+	return backup, err
+}
@@ -0,0 +1,289 @@
+package services
+
+import (
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types/build"
+	"github.com/docker/docker/api/types/registry"
+	"github.com/google/uuid"
+	"github.com/moby/go-archive"
+	"github.com/moby/patternmatcher/ignorefile"
+)
+
+var (
+	ErrBuildContextNotFound = errors.New("build context not found or already used")
+	ErrInvalidBuildContext  = errors.New("build context path must be an absolute path to a directory")
+)
+
+// Context upload nhưng không được build sẽ bị xóa sau khoảng thời gian này
+const buildContextTTL = time.Hour
+
+// BuildImageOptions tham số build image, dùng chung cho local và agent
+type BuildImageOptions struct {
+	Tags        []string          `json:"tags"`
+	Dockerfile  string            `json:"dockerfile,omitempty"` // đường dẫn trong context, mặc định "Dockerfile"
+	BuildArgs   map[string]string `json:"buildArgs,omitempty"`
+	Target      string            `json:"target,omitempty"` // stage của multi-stage build
+	NoCache     bool              `json:"noCache,omitempty"`
+	Pull        bool              `json:"pull,omitempty"`        // luôn pull base image mới nhất
+	ContextPath string            `json:"contextPath,omitempty"` // thư mục trên server, bỏ trống khi upload tar
+}
+
+// query chuyển options thành query string cho agent
+func (o BuildImageOptions) query() url.Values {
+	q := url.Values{}
+	for _, tag := range o.Tags {
+		q.Add("tag", tag)
+	}
+	for key, value := range o.BuildArgs {
+		q.Add("buildArg", key+"="+value)
+	}
+	if o.Dockerfile != "" {
+		q.Set("dockerfile", o.Dockerfile)
+	}
+	if o.Target != "" {
+		q.Set("target", o.Target)
+	}
+	if o.NoCache {
+		q.Set("noCache", strconv.FormatBool(o.NoCache))
+	}
+	if o.Pull {
+		q.Set("pull", strconv.FormatBool(o.Pull))
+	}
+	if o.ContextPath != "" {
+		q.Set("path", o.ContextPath)
+	}
+	return q
+}
+
+// BuildImage build image từ build context (tar, có thể nén gzip) hoặc từ opts.ContextPath
+// khi buildContext là nil. Trả về stream JSON progress của Docker; dùng DecodeProgress để đọc.
+func (d *DockerService) BuildImage(buildContext io.Reader, opts BuildImageOptions, authConfigs map[string]registry.AuthConfig) (result io.ReadCloser, err error) {
+	if !d.IsConnected() {
+		return nil, ErrDockerNotConnected
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			d.markDisconnected()
+			result = nil
+			err = ErrDockerNotConnected
+		}
+	}()
+
+	var contextTar io.ReadCloser
+	if buildContext == nil {
+		contextTar, err = TarBuildContext(opts.ContextPath, opts.Dockerfile)
+		if err != nil {
+			return nil, err
+		}
+		buildContext = contextTar
+	}
+
+	buildArgs := make(map[string]*string, len(opts.BuildArgs))
+	for key, value := range opts.BuildArgs {
+		value := value
+		buildArgs[key] = &value
+	}
+
+	resp, err := d.client.ImageBuild(d.ctx, buildContext, build.ImageBuildOptions{
+		Tags:        opts.Tags,
+		Dockerfile:  opts.Dockerfile,
+		BuildArgs:   buildArgs,
+		Target:      opts.Target,
+		NoCache:     opts.NoCache,
+		PullParent:  opts.Pull,
+		Remove:      true,
+		AuthConfigs: authConfigs,
+	})
+	if err != nil {
+		if contextTar != nil {
+			contextTar.Close()
+		}
+		return nil, d.handleError(err)
+	}
+	if contextTar == nil {
+		return resp.Body, nil
+	}
+	return &closeBoth{ReadCloser: resp.Body, other: contextTar}, nil
+}
+
+// closeBoth đóng thêm một reader khác khi stream chính được đóng
+type closeBoth struct {
+	io.ReadCloser
+	other io.Closer
+}
+
+func (c *closeBoth) Close() error {
+	err := c.ReadCloser.Close()
+	c.other.Close()
+	return err
+}
+
+// TarBuildContext đóng gói một thư mục trên host thành build context,
+// bỏ qua các file khớp .dockerignore giống docker build.
+func TarBuildContext(dir, dockerfile string) (io.ReadCloser, error) {
+	if !filepath.IsAbs(dir) {
+		return nil, ErrInvalidBuildContext
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, ErrInvalidBuildContext
+	}
+
+	excludes, err := readDockerignore(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(excludes) > 0 {
+		// Daemon cần Dockerfile và .dockerignore kể cả khi chúng bị ignore
+		if dockerfile == "" {
+			dockerfile = "Dockerfile"
+		}
+		excludes = append(excludes, "!.dockerignore", "!"+filepath.ToSlash(filepath.Clean(dockerfile)))
+	}
+
+	return archive.TarWithOptions(dir, &archive.TarOptions{ExcludePatterns: excludes})
+}
+
+func readDockerignore(dir string) ([]string, error) {
+	f, err := os.Open(filepath.Join(dir, ".dockerignore"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	return ignorefile.ReadAll(f)
+}
+
+// BuildImage build image trên server (local hoặc agent) với credentials registry đã lưu
+func (m *ServerManager) BuildImage(serverID string, buildContext io.Reader, opts BuildImageOptions) (io.ReadCloser, error) {
+	authConfigs := m.registryAuthConfigs()
+
+	if m.IsLocal(serverID) {
+		return m.localDocker.BuildImage(buildContext, opts, authConfigs)
+	}
+
+	client := m.getAgentClient(serverID)
+	if client == nil {
+		return nil, ErrServerNotFound
+	}
+	return client.BuildImage(buildContext, opts, authConfigs)
+}
+
+// registryAuthConfigs trả về credentials của mọi registry đã lưu để pull base image khi build
+func (m *ServerManager) registryAuthConfigs() map[string]registry.AuthConfig {
+	m.mu.RLock()
+	registries := m.registries
+	m.mu.RUnlock()
+	if registries == nil {
+		return nil
+	}
+	return registries.AuthConfigs()
+}
+
+// BuildContextStore giữ tạm các build context được upload cho tới khi build.
+// Mỗi context chỉ dùng được một lần; context không dùng tới sẽ bị xóa sau buildContextTTL.
+type BuildContextStore struct {
+	dir      string
+	uploaded map[string]time.Time
+	mu       sync.Mutex
+}
+
+func NewBuildContextStore(dataDir string) (*BuildContextStore, error) {
+	dir := filepath.Join(dataDir, "build-contexts")
+	// Context còn sót từ lần chạy trước không còn dùng được
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	store := &BuildContextStore{
+		dir:      dir,
+		uploaded: make(map[string]time.Time),
+	}
+	go store.cleanupLoop()
+
+	return store, nil
+}
+
+// Save lưu build context và trả về ID dùng khi build
+func (s *BuildContextStore) Save(r io.Reader) (string, int64, error) {
+	id := uuid.New().String()
+	path := filepath.Join(s.dir, id)
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return "", 0, err
+	}
+	size, err := io.Copy(f, r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return "", 0, err
+	}
+
+	s.mu.Lock()
+	s.uploaded[id] = time.Now()
+	s.mu.Unlock()
+
+	return id, size, nil
+}
+
+// Take lấy build context ra để build, file bị xóa khi reader được đóng
+func (s *BuildContextStore) Take(id string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	_, exists := s.uploaded[id]
+	delete(s.uploaded, id)
+	s.mu.Unlock()
+	if !exists {
+		return nil, ErrBuildContextNotFound
+	}
+
+	f, err := os.Open(filepath.Join(s.dir, id))
+	if err != nil {
+		return nil, err
+	}
+	return &removeOnClose{File: f}, nil
+}
+
+func (s *BuildContextStore) cleanupLoop() {
+	ticker := time.NewTicker(10 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.mu.Lock()
+		for id, uploadedAt := range s.uploaded {
+			if time.Since(uploadedAt) > buildContextTTL {
+				delete(s.uploaded, id)
+				os.Remove(filepath.Join(s.dir, id))
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+type removeOnClose struct {
+	*os.File
+}
+
+func (f *removeOnClose) Close() error {
+	err := f.File.Close()
+	os.Remove(f.File.Name())
+	return err
+}
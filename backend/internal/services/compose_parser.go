@@ -0,0 +1,921 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/go-connections/nat"
+	"github.com/docker/go-units"
+	"gopkg.in/yaml.v3"
+)
+
+// Nhãn bổ sung mà Compose gắn vào container, network và volume của project
+const (
+	ComposeConfigHashLabel      = "com.docker.compose.config-hash"
+	ComposeContainerNumberLabel = "com.docker.compose.container-number"
+	ComposeOneoffLabel          = "com.docker.compose.oneoff"
+	ComposeNetworkLabel         = "com.docker.compose.network"
+	ComposeVolumeLabel          = "com.docker.compose.volume"
+)
+
+// ComposeFile là tập con của Compose Specification mà AppDock hỗ trợ deploy
+type ComposeFile struct {
+	Services map[string]*ComposeService `yaml:"services"`
+	Networks map[string]*ComposeNetwork `yaml:"networks"`
+	Volumes  map[string]*ComposeVolume  `yaml:"volumes"`
+}
+
+type ComposeService struct {
+	Image          string                 `yaml:"image"`
+	Build          yaml.Node              `yaml:"build"`
+	EnvFile        yaml.Node              `yaml:"env_file"`
+	ContainerName  string                 `yaml:"container_name"`
+	Command        composeCommand         `yaml:"command"`
+	Entrypoint     composeCommand         `yaml:"entrypoint"`
+	Environment    composeMapping         `yaml:"environment"`
+	Labels         composeMapping         `yaml:"labels"`
+	Ports          composePorts           `yaml:"ports"`
+	Volumes        composeMounts          `yaml:"volumes"`
+	Tmpfs          composeCommand         `yaml:"tmpfs"`
+	Networks       composeServiceNetworks `yaml:"networks"`
+	NetworkMode    string                 `yaml:"network_mode"`
+	DependsOn      composeDependsOn       `yaml:"depends_on"`
+	Restart        string                 `yaml:"restart"`
+	WorkingDir     string                 `yaml:"working_dir"`
+	User           string                 `yaml:"user"`
+	Hostname       string                 `yaml:"hostname"`
+	Tty            bool                   `yaml:"tty"`
+	StdinOpen      bool                   `yaml:"stdin_open"`
+	Privileged     bool                   `yaml:"privileged"`
+	ExtraHosts     []string               `yaml:"extra_hosts"`
+	CapAdd         []string               `yaml:"cap_add"`
+	CapDrop        []string               `yaml:"cap_drop"`
+	MemLimit       string                 `yaml:"mem_limit"`
+	MemReservation string                 `yaml:"mem_reservation"`
+	Cpus           string                 `yaml:"cpus"`
+	Healthcheck    *ComposeHealthcheck    `yaml:"healthcheck"`
+	Deploy         *ComposeDeploy         `yaml:"deploy"`
+}
+
+type ComposeHealthcheck struct {
+	Test        composeHealthTest `yaml:"test"`
+	Interval    string            `yaml:"interval"`
+	Timeout     string            `yaml:"timeout"`
+	StartPeriod string            `yaml:"start_period"`
+	Retries     int               `yaml:"retries"`
+	Disable     bool              `yaml:"disable"`
+}
+
+type ComposeDeploy struct {
+	Resources struct {
+		Limits struct {
+			Cpus   string `yaml:"cpus"`
+			Memory string `yaml:"memory"`
+			Pids   int64  `yaml:"pids"`
+		} `yaml:"limits"`
+		Reservations struct {
+			Memory string `yaml:"memory"`
+		} `yaml:"reservations"`
+	} `yaml:"resources"`
+}
+
+type ComposeNetwork struct {
+	Name       string          `yaml:"name"`
+	Driver     string          `yaml:"driver"`
+	External   composeExternal `yaml:"external"`
+	Internal   bool            `yaml:"internal"`
+	Attachable bool            `yaml:"attachable"`
+	Labels     composeMapping  `yaml:"labels"`
+}
+
+type ComposeVolume struct {
+	Name     string          `yaml:"name"`
+	Driver   string          `yaml:"driver"`
+	External composeExternal `yaml:"external"`
+	Labels   composeMapping  `yaml:"labels"`
+}
+
+// StackPlan là kết quả dịch compose file thành các tài nguyên Docker cần tạo
+type StackPlan struct {
+	Project  string             `json:"project"`
+	Networks []StackNetworkPlan `json:"networks"`
+	Volumes  []StackVolumePlan  `json:"volumes"`
+	Services []StackServicePlan `json:"services"` // theo thứ tự phụ thuộc
+}
+
+type StackNetworkPlan struct {
+	Key        string            `json:"key"` // tên trong compose file
+	Name       string            `json:"name"`
+	Driver     string            `json:"driver"`
+	External   bool              `json:"external"`
+	Internal   bool              `json:"internal"`
+	Attachable bool              `json:"attachable"`
+	Labels     map[string]string `json:"labels"`
+}
+
+type StackVolumePlan struct {
+	Key      string            `json:"key"`
+	Name     string            `json:"name"`
+	Driver   string            `json:"driver"`
+	External bool              `json:"external"`
+	Labels   map[string]string `json:"labels"`
+}
+
+type StackServicePlan struct {
+	Name          string         `json:"name"`
+	ContainerName string         `json:"containerName"`
+	Image         string         `json:"image"`
+	DependsOn     []string       `json:"dependsOn"`
+	ConfigHash    string         `json:"configHash"`
+	Spec          *ContainerSpec `json:"spec"`
+}
+
+// ParseCompose đọc nội dung docker-compose.yml sau khi thay thế biến ${VAR}
+func ParseCompose(content string, env map[string]string) (*ComposeFile, error) {
+	interpolated, err := interpolateCompose(content, env)
+	if err != nil {
+		return nil, err
+	}
+
+	var file ComposeFile
+	if err := yaml.Unmarshal([]byte(interpolated), &file); err != nil {
+		return nil, fmt.Errorf("invalid compose file: %w", err)
+	}
+	if len(file.Services) == 0 {
+		return nil, fmt.Errorf("compose file has no services")
+	}
+
+	for name, svc := range file.Services {
+		if svc == nil {
+			return nil, fmt.Errorf("service %q is empty", name)
+		}
+		if svc.Build.Kind != 0 {
+			return nil, fmt.Errorf("service %q: build is not supported, use a pre-built image", name)
+		}
+		if svc.EnvFile.Kind != 0 {
+			return nil, fmt.Errorf("service %q: env_file is not supported, use stack environment variables", name)
+		}
+		if svc.Image == "" {
+			return nil, fmt.Errorf("service %q: image is required", name)
+		}
+		for _, dep := range svc.DependsOn {
+			if _, ok := file.Services[dep]; !ok {
+				return nil, fmt.Errorf("service %q depends on undefined service %q", name, dep)
+			}
+		}
+	}
+
+	return &file, nil
+}
+
+// BuildStackPlan dịch compose file thành các network, volume và container spec cho project
+func BuildStackPlan(project, content string, env map[string]string) (*StackPlan, error) {
+	file, err := ParseCompose(content, env)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &StackPlan{
+		Project:  project,
+		Networks: make([]StackNetworkPlan, 0),
+		Volumes:  make([]StackVolumePlan, 0),
+		Services: make([]StackServicePlan, 0, len(file.Services)),
+	}
+
+	networks := make(map[string]StackNetworkPlan)
+	for key, n := range file.Networks {
+		if n == nil {
+			n = &ComposeNetwork{}
+		}
+		networks[key] = buildNetworkPlan(project, key, n)
+	}
+
+	volumes := make(map[string]StackVolumePlan)
+	for key, v := range file.Volumes {
+		if v == nil {
+			v = &ComposeVolume{}
+		}
+		volumes[key] = buildVolumePlan(project, key, v)
+	}
+
+	names := make([]string, 0, len(file.Services))
+	deps := make(map[string][]string, len(file.Services))
+	for name, svc := range file.Services {
+		names = append(names, name)
+		deps[name] = svc.DependsOn
+	}
+
+	usedNetworks := make(map[string]bool)
+	for _, name := range sortByDependencies(names, deps) {
+		svc := file.Services[name]
+
+		attachments := svc.Networks
+		if len(attachments) == 0 && svc.NetworkMode == "" {
+			attachments = composeServiceNetworks{{Name: "default"}}
+		}
+		for _, a := range attachments {
+			if _, ok := networks[a.Name]; !ok {
+				if a.Name != "default" {
+					return nil, fmt.Errorf("service %q uses undefined network %q", name, a.Name)
+				}
+				networks[a.Name] = buildNetworkPlan(project, a.Name, &ComposeNetwork{})
+			}
+			usedNetworks[a.Name] = true
+		}
+
+		servicePlan, err := buildServicePlan(project, name, svc, attachments, networks, volumes, env)
+		if err != nil {
+			return nil, err
+		}
+		plan.Services = append(plan.Services, *servicePlan)
+	}
+
+	// Chỉ tạo network được service sử dụng hoặc khai báo tường minh
+	for key, n := range networks {
+		if _, declared := file.Networks[key]; declared || usedNetworks[key] {
+			plan.Networks = append(plan.Networks, n)
+		}
+	}
+	sort.Slice(plan.Networks, func(i, j int) bool { return plan.Networks[i].Key < plan.Networks[j].Key })
+
+	for _, v := range volumes {
+		plan.Volumes = append(plan.Volumes, v)
+	}
+	sort.Slice(plan.Volumes, func(i, j int) bool { return plan.Volumes[i].Key < plan.Volumes[j].Key })
+
+	return plan, nil
+}
+
+func buildNetworkPlan(project, key string, n *ComposeNetwork) StackNetworkPlan {
+	plan := StackNetworkPlan{
+		Key:        key,
+		Driver:     n.Driver,
+		Internal:   n.Internal,
+		Attachable: n.Attachable,
+	}
+	if n.External.External {
+		plan.External = true
+		plan.Name = firstNonEmpty(n.External.Name, n.Name, key)
+		return plan
+	}
+
+	plan.Name = firstNonEmpty(n.Name, project+"_"+key)
+	plan.Labels = n.Labels.Resolve(nil)
+	plan.Labels[ComposeProjectLabel] = project
+	plan.Labels[ComposeNetworkLabel] = key
+	return plan
+}
+
+func buildVolumePlan(project, key string, v *ComposeVolume) StackVolumePlan {
+	plan := StackVolumePlan{
+		Key:    key,
+		Driver: v.Driver,
+	}
+	if v.External.External {
+		plan.External = true
+		plan.Name = firstNonEmpty(v.External.Name, v.Name, key)
+		return plan
+	}
+
+	plan.Name = firstNonEmpty(v.Name, project+"_"+key)
+	plan.Labels = v.Labels.Resolve(nil)
+	plan.Labels[ComposeProjectLabel] = project
+	plan.Labels[ComposeVolumeLabel] = key
+	return plan
+}
+
+func buildServicePlan(
+	project, name string,
+	svc *ComposeService,
+	attachments composeServiceNetworks,
+	networks map[string]StackNetworkPlan,
+	volumes map[string]StackVolumePlan,
+	env map[string]string,
+) (*StackServicePlan, error) {
+	containerName := svc.ContainerName
+	if containerName == "" {
+		containerName = fmt.Sprintf("%s-%s-1", project, name)
+	}
+
+	req := CreateContainerRequest{
+		Image:      svc.Image,
+		Name:       containerName,
+		Cmd:        svc.Command,
+		Entrypoint: svc.Entrypoint,
+		Env:        svc.Environment.EnvList(env),
+		WorkingDir: svc.WorkingDir,
+		User:       svc.User,
+		Hostname:   svc.Hostname,
+		Tty:        svc.Tty,
+		Ports:      svc.Ports,
+		Labels:     svc.Labels.Resolve(nil),
+	}
+
+	restart, maxRetries, err := parseRestartPolicy(svc.Restart)
+	if err != nil {
+		return nil, fmt.Errorf("service %q: %w", name, err)
+	}
+	req.RestartPolicy = restart
+	req.MaxRetries = maxRetries
+
+	if req.Resources, err = parseServiceResources(svc); err != nil {
+		return nil, fmt.Errorf("service %q: %w", name, err)
+	}
+
+	anonymous := make(map[string]struct{})
+	tmpfs := make(map[string]string)
+	for _, m := range svc.Volumes {
+		switch m.Type {
+		case "tmpfs":
+			tmpfs[m.Target] = ""
+		case "volume":
+			if m.Source == "" {
+				anonymous[m.Target] = struct{}{}
+				continue
+			}
+			vol, ok := volumes[m.Source]
+			if !ok {
+				return nil, fmt.Errorf("service %q uses undefined volume %q", name, m.Source)
+			}
+			req.Volumes = append(req.Volumes, VolumeBinding{Source: vol.Name, Target: m.Target, ReadOnly: m.ReadOnly})
+		case "bind":
+			if !strings.HasPrefix(m.Source, "/") {
+				return nil, fmt.Errorf("service %q: bind mount %q must be an absolute path on the server", name, m.Source)
+			}
+			req.Volumes = append(req.Volumes, VolumeBinding{Source: m.Source, Target: m.Target, ReadOnly: m.ReadOnly})
+		default:
+			return nil, fmt.Errorf("service %q: unsupported mount type %q", name, m.Type)
+		}
+	}
+	for _, entry := range svc.Tmpfs {
+		target, options, _ := strings.Cut(entry, ":")
+		tmpfs[target] = options
+	}
+
+	for _, a := range attachments {
+		req.Networks = append(req.Networks, networks[a.Name].Name)
+	}
+
+	spec, err := req.ToSpec()
+	if err != nil {
+		return nil, fmt.Errorf("service %q: %w", name, err)
+	}
+
+	spec.Config.OpenStdin = svc.StdinOpen
+	if len(anonymous) > 0 {
+		spec.Config.Volumes = anonymous
+	}
+	if len(tmpfs) > 0 {
+		spec.HostConfig.Tmpfs = tmpfs
+	}
+	spec.HostConfig.ExtraHosts = svc.ExtraHosts
+	spec.HostConfig.CapAdd = svc.CapAdd
+	spec.HostConfig.CapDrop = svc.CapDrop
+	spec.HostConfig.Privileged = svc.Privileged
+
+	if svc.NetworkMode != "" {
+		if strings.HasPrefix(svc.NetworkMode, "service:") {
+			return nil, fmt.Errorf("service %q: network_mode %q is not supported", name, svc.NetworkMode)
+		}
+		spec.HostConfig.NetworkMode = container.NetworkMode(svc.NetworkMode)
+	}
+
+	// Service name luôn là alias DNS trong mọi network của project
+	if spec.NetworkingConfig != nil {
+		for _, a := range attachments {
+			endpoint := spec.NetworkingConfig.EndpointsConfig[networks[a.Name].Name]
+			endpoint.Aliases = append([]string{name}, a.Aliases...)
+			if a.IPv4Address != "" {
+				endpoint.IPAMConfig = &network.EndpointIPAMConfig{IPv4Address: a.IPv4Address}
+			}
+		}
+	}
+
+	if svc.Healthcheck != nil {
+		if spec.Config.Healthcheck, err = svc.Healthcheck.toConfig(); err != nil {
+			return nil, fmt.Errorf("service %q: %w", name, err)
+		}
+	}
+
+	dependsOn := make([]string, 0, len(svc.DependsOn))
+	dependsOn = append(dependsOn, svc.DependsOn...)
+	sort.Strings(dependsOn)
+
+	labels := spec.Config.Labels
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	labels[ComposeProjectLabel] = project
+	labels[ComposeServiceLabel] = name
+	labels[ComposeContainerNumberLabel] = "1"
+	labels[ComposeOneoffLabel] = "False"
+	if len(dependsOn) > 0 {
+		parts := make([]string, len(dependsOn))
+		for i, dep := range dependsOn {
+			parts[i] = dep + ":service_started:false"
+		}
+		labels[ComposeDependsOnLabel] = strings.Join(parts, ",")
+	}
+	spec.Config.Labels = labels
+
+	// Hash được tính trước khi gắn nhãn config-hash để so sánh lần deploy sau
+	raw, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(raw)
+	hash := hex.EncodeToString(sum[:])
+	labels[ComposeConfigHashLabel] = hash
+
+	return &StackServicePlan{
+		Name:          name,
+		ContainerName: containerName,
+		Image:         svc.Image,
+		DependsOn:     dependsOn,
+		ConfigHash:    hash,
+		Spec:          spec,
+	}, nil
+}
+
+func parseRestartPolicy(restart string) (string, int, error) {
+	if restart == "" {
+		return "", 0, nil
+	}
+	name, retries, hasRetries := strings.Cut(restart, ":")
+	switch container.RestartPolicyMode(name) {
+	case container.RestartPolicyDisabled, container.RestartPolicyAlways, container.RestartPolicyUnlessStopped:
+		return name, 0, nil
+	case container.RestartPolicyOnFailure:
+		if !hasRetries {
+			return name, 0, nil
+		}
+		n, err := strconv.Atoi(retries)
+		if err != nil {
+			return "", 0, fmt.Errorf("invalid restart policy %q", restart)
+		}
+		return name, n, nil
+	}
+	return "", 0, fmt.Errorf("invalid restart policy %q", restart)
+}
+
+// parseServiceResources đọc mem_limit/cpus, deploy.resources được ưu tiên nếu có
+func parseServiceResources(svc *ComposeService) (ResourceLimits, error) {
+	var res ResourceLimits
+
+	memory, memReservation, cpus := svc.MemLimit, svc.MemReservation, svc.Cpus
+	if svc.Deploy != nil {
+		limits := svc.Deploy.Resources.Limits
+		memory = firstNonEmpty(limits.Memory, memory)
+		cpus = firstNonEmpty(limits.Cpus, cpus)
+		memReservation = firstNonEmpty(svc.Deploy.Resources.Reservations.Memory, memReservation)
+		res.PidsLimit = limits.Pids
+	}
+
+	var err error
+	if memory != "" {
+		if res.Memory, err = units.RAMInBytes(memory); err != nil {
+			return res, fmt.Errorf("invalid memory limit %q", memory)
+		}
+	}
+	if memReservation != "" {
+		if res.MemoryReservation, err = units.RAMInBytes(memReservation); err != nil {
+			return res, fmt.Errorf("invalid memory reservation %q", memReservation)
+		}
+	}
+	if cpus != "" {
+		if res.CPUs, err = strconv.ParseFloat(cpus, 64); err != nil {
+			return res, fmt.Errorf("invalid cpus %q", cpus)
+		}
+	}
+	return res, nil
+}
+
+func (h *ComposeHealthcheck) toConfig() (*container.HealthConfig, error) {
+	if h.Disable {
+		return &container.HealthConfig{Test: []string{"NONE"}}, nil
+	}
+
+	config := &container.HealthConfig{Test: h.Test, Retries: h.Retries}
+
+	durations := []struct {
+		value  string
+		target *time.Duration
+	}{
+		{h.Interval, &config.Interval},
+		{h.Timeout, &config.Timeout},
+		{h.StartPeriod, &config.StartPeriod},
+	}
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(d.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid healthcheck duration %q", d.value)
+		}
+		*d.target = parsed
+	}
+	return config, nil
+}
+
+// ==================== Interpolation ====================
+
+var composeVarPattern = regexp.MustCompile(`\$(\$|\{[^}]*\}|[A-Za-z_][A-Za-z0-9_]*)`)
+
+// interpolateCompose thay thế $VAR, ${VAR}, ${VAR:-default}, ${VAR-default},
+// ${VAR:?error} và ${VAR?error}. "$$" là ký tự "$" thường.
+func interpolateCompose(content string, env map[string]string) (string, error) {
+	lookup := func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+
+	var firstErr error
+	result := composeVarPattern.ReplaceAllStringFunc(content, func(match string) string {
+		expr := match[1:]
+		if expr == "$" {
+			return "$"
+		}
+		if !strings.HasPrefix(expr, "{") {
+			v, _ := lookup(expr)
+			return v
+		}
+		expr = strings.TrimSuffix(strings.TrimPrefix(expr, "{"), "}")
+
+		for _, op := range []string{":-", "-", ":?", "?"} {
+			name, arg, found := strings.Cut(expr, op)
+			if !found || strings.ContainsAny(name, ":-?") {
+				continue
+			}
+			v, ok := lookup(name)
+			unset := !ok || (strings.HasPrefix(op, ":") && v == "")
+			switch {
+			case !unset:
+				return v
+			case strings.HasSuffix(op, "-"):
+				return arg
+			default:
+				if firstErr == nil {
+					firstErr = fmt.Errorf("required variable %s is missing: %s", name, arg)
+				}
+				return ""
+			}
+		}
+
+		v, _ := lookup(expr)
+		return v
+	})
+	if firstErr != nil {
+		return "", firstErr
+	}
+	return result, nil
+}
+
+// ==================== YAML helpers ====================
+
+// composeCommand nhận cả dạng chuỗi (được tách như shell) lẫn dạng list
+type composeCommand []string
+
+func (c *composeCommand) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		if node.Tag == "!!null" {
+			*c = nil
+			return nil
+		}
+		parts, err := splitCommandLine(node.Value)
+		if err != nil {
+			return err
+		}
+		*c = parts
+		return nil
+	}
+	var list []string
+	if err := node.Decode(&list); err != nil {
+		return err
+	}
+	*c = list
+	return nil
+}
+
+// composeHealthTest nhận dạng chuỗi (chạy qua shell) hoặc list bắt đầu bằng CMD/CMD-SHELL/NONE
+type composeHealthTest []string
+
+func (t *composeHealthTest) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*t = composeHealthTest{"CMD-SHELL", node.Value}
+		return nil
+	}
+	var list []string
+	if err := node.Decode(&list); err != nil {
+		return err
+	}
+	*t = list
+	return nil
+}
+
+// composeMapping nhận dạng map (KEY: value) hoặc list ("KEY=value", "KEY").
+// Giá trị nil nghĩa là chỉ có tên biến, không có giá trị.
+type composeMapping map[string]*string
+
+func (m *composeMapping) UnmarshalYAML(node *yaml.Node) error {
+	result := make(composeMapping)
+	switch node.Kind {
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			key, value, found := strings.Cut(item.Value, "=")
+			if found {
+				result[key] = &value
+			} else {
+				result[key] = nil
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i].Value, node.Content[i+1]
+			if value.Tag == "!!null" {
+				result[key] = nil
+				continue
+			}
+			v := value.Value
+			result[key] = &v
+		}
+	default:
+		return fmt.Errorf("line %d: expected a map or a list", node.Line)
+	}
+	*m = result
+	return nil
+}
+
+// Resolve trả về map đầy đủ, biến không có giá trị được lấy từ env (nếu có)
+func (m composeMapping) Resolve(env map[string]string) map[string]string {
+	result := make(map[string]string, len(m))
+	for key, value := range m {
+		if value != nil {
+			result[key] = *value
+		} else if v, ok := env[key]; ok {
+			result[key] = v
+		}
+	}
+	return result
+}
+
+// EnvList trả về danh sách KEY=value đã sắp xếp để hash ổn định
+func (m composeMapping) EnvList(env map[string]string) []string {
+	resolved := m.Resolve(env)
+	result := make([]string, 0, len(resolved))
+	for key, value := range resolved {
+		result = append(result, key+"="+value)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// composeExternal nhận "external: true" hoặc dạng cũ "external: {name: x}"
+type composeExternal struct {
+	External bool
+	Name     string
+}
+
+func (e *composeExternal) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.MappingNode {
+		var legacy struct {
+			Name string `yaml:"name"`
+		}
+		if err := node.Decode(&legacy); err != nil {
+			return err
+		}
+		e.External, e.Name = true, legacy.Name
+		return nil
+	}
+	return node.Decode(&e.External)
+}
+
+type composePorts []PortBinding
+
+func (p *composePorts) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.SequenceNode {
+		return fmt.Errorf("line %d: ports must be a list", node.Line)
+	}
+	result := make(composePorts, 0, len(node.Content))
+	for _, item := range node.Content {
+		if item.Kind == yaml.MappingNode {
+			var long struct {
+				Target    string `yaml:"target"`
+				Published string `yaml:"published"`
+				HostIP    string `yaml:"host_ip"`
+				Protocol  string `yaml:"protocol"`
+			}
+			if err := item.Decode(&long); err != nil {
+				return err
+			}
+			result = append(result, PortBinding{
+				ContainerPort: long.Target,
+				HostPort:      long.Published,
+				HostIP:        long.HostIP,
+				Protocol:      long.Protocol,
+			})
+			continue
+		}
+
+		mappings, err := nat.ParsePortSpec(item.Value)
+		if err != nil {
+			return fmt.Errorf("line %d: invalid port %q: %w", item.Line, item.Value, err)
+		}
+		for _, m := range mappings {
+			result = append(result, PortBinding{
+				ContainerPort: m.Port.Port(),
+				HostPort:      m.Binding.HostPort,
+				HostIP:        m.Binding.HostIP,
+				Protocol:      m.Port.Proto(),
+			})
+		}
+	}
+	*p = result
+	return nil
+}
+
+type composeMount struct {
+	Type     string // volume, bind, tmpfs
+	Source   string
+	Target   string
+	ReadOnly bool
+}
+
+type composeMounts []composeMount
+
+func (m *composeMounts) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.SequenceNode {
+		return fmt.Errorf("line %d: volumes must be a list", node.Line)
+	}
+	result := make(composeMounts, 0, len(node.Content))
+	for _, item := range node.Content {
+		if item.Kind == yaml.MappingNode {
+			var long struct {
+				Type     string `yaml:"type"`
+				Source   string `yaml:"source"`
+				Target   string `yaml:"target"`
+				ReadOnly bool   `yaml:"read_only"`
+			}
+			if err := item.Decode(&long); err != nil {
+				return err
+			}
+			if long.Target == "" {
+				return fmt.Errorf("line %d: volume target is required", item.Line)
+			}
+			if long.Type == "" {
+				long.Type = "volume"
+			}
+			result = append(result, composeMount(long))
+			continue
+		}
+
+		parts := strings.Split(item.Value, ":")
+		mount := composeMount{Type: "volume"}
+		switch len(parts) {
+		case 1:
+			mount.Target = parts[0]
+		case 2, 3:
+			mount.Source, mount.Target = parts[0], parts[1]
+			if len(parts) == 3 {
+				for _, opt := range strings.Split(parts[2], ",") {
+					if opt == "ro" {
+						mount.ReadOnly = true
+					}
+				}
+			}
+		default:
+			return fmt.Errorf("line %d: invalid volume %q", item.Line, item.Value)
+		}
+		if isHostPath(mount.Source) {
+			mount.Type = "bind"
+		}
+		result = append(result, mount)
+	}
+	*m = result
+	return nil
+}
+
+func isHostPath(source string) bool {
+	return strings.HasPrefix(source, "/") || strings.HasPrefix(source, ".") || strings.HasPrefix(source, "~")
+}
+
+type composeNetworkAttachment struct {
+	Name        string
+	Aliases     []string
+	IPv4Address string
+}
+
+// composeServiceNetworks nhận list tên network hoặc map tên -> {aliases, ipv4_address}
+type composeServiceNetworks []composeNetworkAttachment
+
+func (n *composeServiceNetworks) UnmarshalYAML(node *yaml.Node) error {
+	result := make(composeServiceNetworks, 0)
+	switch node.Kind {
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			result = append(result, composeNetworkAttachment{Name: item.Value})
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			attachment := composeNetworkAttachment{Name: node.Content[i].Value}
+			var options struct {
+				Aliases     []string `yaml:"aliases"`
+				IPv4Address string   `yaml:"ipv4_address"`
+			}
+			if err := node.Content[i+1].Decode(&options); err != nil {
+				return err
+			}
+			attachment.Aliases = options.Aliases
+			attachment.IPv4Address = options.IPv4Address
+			result = append(result, attachment)
+		}
+		sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	default:
+		return fmt.Errorf("line %d: networks must be a list or a map", node.Line)
+	}
+	*n = result
+	return nil
+}
+
+// composeDependsOn nhận list tên service hoặc map tên -> {condition}
+type composeDependsOn []string
+
+func (d *composeDependsOn) UnmarshalYAML(node *yaml.Node) error {
+	result := make(composeDependsOn, 0)
+	switch node.Kind {
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			result = append(result, item.Value)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			result = append(result, node.Content[i].Value)
+		}
+	default:
+		return fmt.Errorf("line %d: depends_on must be a list or a map", node.Line)
+	}
+	*d = result
+	return nil
+}
+
+// splitCommandLine tách chuỗi lệnh theo khoảng trắng, hỗ trợ nháy đơn, nháy kép và escape
+func splitCommandLine(s string) ([]string, error) {
+	var (
+		args    []string
+		current strings.Builder
+		inArg   bool
+		quote   rune
+		escaped bool
+	)
+	for _, r := range s {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inArg = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("unterminated quote in command %q", s)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
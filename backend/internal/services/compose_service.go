@@ -0,0 +1,292 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Nhãn Docker Compose gắn vào mỗi container
+const (
+	ComposeProjectLabel   = "com.docker.compose.project"
+	ComposeServiceLabel   = "com.docker.compose.service"
+	ComposeDependsOnLabel = "com.docker.compose.depends_on"
+)
+
+var ErrComposeProjectNotFound = errors.New("compose project not found")
+
+// Các action hỗ trợ trên một compose project
+const (
+	ComposeActionStart   = "start"
+	ComposeActionStop    = "stop"
+	ComposeActionRestart = "restart"
+	ComposeActionPull    = "pull"
+	ComposeActionRemove  = "remove"
+)
+
+type ComposeProject struct {
+	Name       string          `json:"name"`
+	Services   []string        `json:"services"`
+	Containers []ContainerInfo `json:"containers"`
+	Running    int             `json:"running"`
+	Total      int             `json:"total"`
+}
+
+// ComposeActionResult kết quả thực hiện action trên từng container của project
+type ComposeActionResult struct {
+	Project   string       `json:"project"`
+	Action    string       `json:"action"`
+	Success   []string     `json:"success"` // IDs thành công
+	Failed    []FailedItem `json:"failed"`  // IDs thất bại
+	Total     int          `json:"total"`
+	Succeeded int          `json:"succeeded"`
+}
+
+// listContainerInfos trả về danh sách container dạng typed cho cả local và agent
+func (m *ServerManager) listContainerInfos(serverID string, all bool) ([]ContainerInfo, error) {
+	if m.IsLocal(serverID) {
+		return m.localDocker.ListContainers(all)
+	}
+
+	client := m.getAgentClient(serverID)
+	if client == nil {
+		return nil, ErrServerNotFound
+	}
+
+	data, err := client.ListContainers(all)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []ContainerInfo
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ListComposeProjects nhóm các container theo nhãn com.docker.compose.project
+func (m *ServerManager) ListComposeProjects(serverID string) ([]ComposeProject, error) {
+	containers, err := m.listContainerInfos(serverID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	projects := make(map[string]*ComposeProject)
+	for _, c := range containers {
+		name := c.Labels[ComposeProjectLabel]
+		if name == "" {
+			continue
+		}
+		p, ok := projects[name]
+		if !ok {
+			p = &ComposeProject{Name: name, Services: []string{}, Containers: []ContainerInfo{}}
+			projects[name] = p
+		}
+		p.Containers = append(p.Containers, c)
+		p.Total++
+		if c.State == "running" {
+			p.Running++
+		}
+		if svc := c.Labels[ComposeServiceLabel]; svc != "" && !containsString(p.Services, svc) {
+			p.Services = append(p.Services, svc)
+		}
+	}
+
+	result := make([]ComposeProject, 0, len(projects))
+	for _, p := range projects {
+		sort.Strings(p.Services)
+		result = append(result, *p)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+// ComposeProjectAction thực hiện start/stop/restart/pull/remove cho toàn bộ project.
+// Container được xử lý theo thứ tự phụ thuộc (com.docker.compose.depends_on):
+// start/restart/pull đi từ dependency trước, stop/remove theo thứ tự ngược lại.
+func (m *ServerManager) ComposeProjectAction(serverID, project, action string) (*ComposeActionResult, error) {
+	switch action {
+	case ComposeActionStart, ComposeActionStop, ComposeActionRestart, ComposeActionPull, ComposeActionRemove:
+	default:
+		return nil, fmt.Errorf("unsupported compose action: %s", action)
+	}
+
+	containers, err := m.listContainerInfos(serverID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]ContainerInfo, 0)
+	for _, c := range containers {
+		if c.Labels[ComposeProjectLabel] == project {
+			members = append(members, c)
+		}
+	}
+	if len(members) == 0 {
+		return nil, ErrComposeProjectNotFound
+	}
+
+	ordered := orderComposeContainers(members)
+	if action == ComposeActionStop || action == ComposeActionRemove {
+		for i, j := 0, len(ordered)-1; i < j; i, j = i+1, j-1 {
+			ordered[i], ordered[j] = ordered[j], ordered[i]
+		}
+	}
+
+	result := &ComposeActionResult{
+		Project: project,
+		Action:  action,
+		Success: make([]string, 0),
+		Failed:  make([]FailedItem, 0),
+		Total:   len(ordered),
+	}
+
+	// Pull mỗi image một lần, kết quả áp dụng cho mọi container dùng image đó
+	pulled := make(map[string]error)
+
+	for _, c := range ordered {
+		var err error
+		switch action {
+		case ComposeActionStart:
+			err = m.StartContainer(serverID, c.ID)
+		case ComposeActionStop:
+			err = m.StopContainer(serverID, c.ID, nil)
+		case ComposeActionRestart:
+			err = m.RestartContainer(serverID, c.ID, nil)
+		case ComposeActionPull:
+			var ref string
+			ref, err = m.containerImageRef(serverID, c.ID)
+			if err != nil {
+				break
+			}
+			pullErr, done := pulled[ref]
+			if !done {
+				pullErr = m.PullImage(serverID, ref)
+				pulled[ref] = pullErr
+			}
+			err = pullErr
+		case ComposeActionRemove:
+			err = m.RemoveContainer(serverID, c.ID, true)
+		}
+
+		if err != nil {
+			result.Failed = append(result.Failed, FailedItem{ID: c.ID, Error: err.Error()})
+		} else {
+			result.Success = append(result.Success, c.ID)
+		}
+	}
+
+	result.Succeeded = len(result.Success)
+	return result, nil
+}
+
+// containerImageRef trả về image reference container được tạo từ (Config.Image).
+// ContainerInfo.Image có thể là image ID khi tag đã trỏ sang image khác nên không dùng để pull.
+func (m *ServerManager) containerImageRef(serverID, containerID string) (string, error) {
+	inspect, err := m.InspectContainer(serverID, containerID)
+	if err != nil {
+		return "", err
+	}
+	if inspect.Config == nil || inspect.Config.Image == "" {
+		return "", fmt.Errorf("container has no image reference")
+	}
+	ref := inspect.Config.Image
+	if strings.HasPrefix(ref, "sha256:") {
+		return "", fmt.Errorf("container was created from image ID %s, no tag to pull", shortID(strings.TrimPrefix(ref, "sha256:")))
+	}
+	return ref, nil
+}
+
+// orderComposeContainers sắp xếp container theo thứ tự phụ thuộc giữa các service
+func orderComposeContainers(containers []ContainerInfo) []ContainerInfo {
+	byService := make(map[string][]ContainerInfo)
+	deps := make(map[string][]string)
+	for _, c := range containers {
+		svc := c.Labels[ComposeServiceLabel]
+		if svc == "" {
+			svc = c.Name
+		}
+		byService[svc] = append(byService[svc], c)
+		if _, ok := deps[svc]; !ok {
+			deps[svc] = parseDependsOn(c.Labels[ComposeDependsOnLabel])
+		}
+	}
+
+	services := make([]string, 0, len(byService))
+	for svc := range byService {
+		services = append(services, svc)
+	}
+
+	result := make([]ContainerInfo, 0, len(containers))
+	for _, svc := range sortByDependencies(services, deps) {
+		group := byService[svc]
+		sort.Slice(group, func(i, j int) bool { return group[i].Name < group[j].Name })
+		result = append(result, group...)
+	}
+	return result
+}
+
+// sortByDependencies trả về các service theo thứ tự dependency trước.
+// Service không khai báo depends_on hoặc nằm trong vòng phụ thuộc được xếp theo tên;
+// dependency không nằm trong danh sách bị bỏ qua.
+func sortByDependencies(services []string, deps map[string][]string) []string {
+	sorted := append([]string(nil), services...)
+	sort.Strings(sorted)
+
+	known := make(map[string]bool, len(sorted))
+	for _, svc := range sorted {
+		known[svc] = true
+	}
+
+	visited := make(map[string]bool)
+	visiting := make(map[string]bool)
+	order := make([]string, 0, len(sorted))
+
+	var visit func(svc string)
+	visit = func(svc string) {
+		if visited[svc] || visiting[svc] {
+			return
+		}
+		visiting[svc] = true
+		for _, dep := range deps[svc] {
+			if known[dep] {
+				visit(dep)
+			}
+		}
+		visiting[svc] = false
+		visited[svc] = true
+		order = append(order, svc)
+	}
+	for _, svc := range sorted {
+		visit(svc)
+	}
+	return order
+}
+
+// parseDependsOn đọc nhãn depends_on dạng "db:service_started:false,cache:service_healthy:true"
+func parseDependsOn(label string) []string {
+	if label == "" {
+		return nil
+	}
+	parts := strings.Split(label, ",")
+	result := make([]string, 0, len(parts))
+	for _, p := range parts {
+		name := strings.TrimSpace(strings.SplitN(p, ":", 2)[0])
+		if name != "" {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -1,22 +1,34 @@
 package services
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/client"
+	"github.com/docker/go-connections/nat"
 )
 
 type ContainerInfo struct {
-	ID      string            `json:"id"`
-	Name    string            `json:"name"`
-	Image   string            `json:"image"`
-	Status  string            `json:"status"`
-	State   string            `json:"state"`
-	Created int64             `json:"created"`
-	Ports   []PortMapping     `json:"ports"`
-	Labels  map[string]string `json:"labels"`
+	ID              string            `json:"id"`
+	Name            string            `json:"name"`
+	Image           string            `json:"image"`
+	ImageID         string            `json:"imageId"`
+	Status          string            `json:"status"`
+	State           string            `json:"state"`
+	Created         int64             `json:"created"`
+	Ports           []PortMapping     `json:"ports"`
+	Labels          map[string]string `json:"labels"`
+	UpdateAvailable bool              `json:"updateAvailable"` // registry có digest mới hơn cho tag của image
 }
 
 type PortMapping struct {
@@ -63,6 +75,7 @@ func (d *DockerService) ListContainers(all bool) (result []ContainerInfo, err er
 			ID:      c.ID[:12],
 			Name:    name,
 			Image:   c.Image,
+			ImageID: c.ImageID,
 			Status:  c.Status,
 			State:   c.State,
 			Created: c.Created,
@@ -196,6 +209,39 @@ func (d *DockerService) GetContainer(id string) (result *ContainerDetail, err er
 	}, nil
 }
 
+// InspectContainer trả về toàn bộ cấu hình inspect của container
+func (d *DockerService) InspectContainer(id string) (result *container.InspectResponse, err error) {
+	if !d.IsConnected() {
+		return nil, ErrDockerNotConnected
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			d.markDisconnected()
+			result = nil
+			err = ErrDockerNotConnected
+		}
+	}()
+	c, err := d.client.ContainerInspect(d.ctx, id)
+	if err != nil {
+		return nil, d.handleError(err)
+	}
+	return &c, nil
+}
+
+func (d *DockerService) RenameContainer(id, name string) (err error) {
+	if !d.IsConnected() {
+		return ErrDockerNotConnected
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			d.markDisconnected()
+			err = ErrDockerNotConnected
+		}
+	}()
+	err = d.client.ContainerRename(d.ctx, id, name)
+	return d.handleError(err)
+}
+
 func (d *DockerService) StartContainer(id string) (err error) {
 	if !d.IsConnected() {
 		return ErrDockerNotConnected
@@ -210,7 +256,71 @@ func (d *DockerService) StartContainer(id string) (err error) {
 	return d.handleError(err)
 }
 
-func (d *DockerService) StopContainer(id string) (err error) {
+// StopContainer gửi stop signal rồi chờ timeout giây trước khi kill.
+// timeout nil dùng stop timeout của container (mặc định 10 giây), -1 chờ không giới hạn.
+func (d *DockerService) StopContainer(id string, timeout *int) (err error) {
+	if !d.IsConnected() {
+		return ErrDockerNotConnected
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			d.markDisconnected()
+			err = ErrDockerNotConnected
+		}
+	}()
+	err = d.client.ContainerStop(d.ctx, id, container.StopOptions{Timeout: timeout})
+	return d.handleError(err)
+}
+
+func (d *DockerService) RestartContainer(id string, timeout *int) (err error) {
+	if !d.IsConnected() {
+		return ErrDockerNotConnected
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			d.markDisconnected()
+			err = ErrDockerNotConnected
+		}
+	}()
+	err = d.client.ContainerRestart(d.ctx, id, container.StopOptions{Timeout: timeout})
+	return d.handleError(err)
+}
+
+// UpdateContainer áp dụng giới hạn tài nguyên/restart policy mới mà không cần tạo lại container
+func (d *DockerService) UpdateContainer(id string, update container.UpdateConfig) (result []string, err error) {
+	if !d.IsConnected() {
+		return nil, ErrDockerNotConnected
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			d.markDisconnected()
+			result = nil
+			err = ErrDockerNotConnected
+		}
+	}()
+	resp, err := d.client.ContainerUpdate(d.ctx, id, update)
+	if err != nil {
+		return nil, d.handleError(err)
+	}
+	return resp.Warnings, nil
+}
+
+// KillContainer gửi signal (e.g. "SIGHUP", "HUP", "1") tới process chính của container
+func (d *DockerService) KillContainer(id, signal string) (err error) {
+	if !d.IsConnected() {
+		return ErrDockerNotConnected
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			d.markDisconnected()
+			err = ErrDockerNotConnected
+		}
+	}()
+	err = d.client.ContainerKill(d.ctx, id, signal)
+	return d.handleError(err)
+}
+
+func (d *DockerService) PauseContainer(id string) (err error) {
 	if !d.IsConnected() {
 		return ErrDockerNotConnected
 	}
@@ -220,12 +330,11 @@ func (d *DockerService) StopContainer(id string) (err error) {
 			err = ErrDockerNotConnected
 		}
 	}()
-	timeout := 10
-	err = d.client.ContainerStop(d.ctx, id, container.StopOptions{Timeout: &timeout})
+	err = d.client.ContainerPause(d.ctx, id)
 	return d.handleError(err)
 }
 
-func (d *DockerService) RestartContainer(id string) (err error) {
+func (d *DockerService) UnpauseContainer(id string) (err error) {
 	if !d.IsConnected() {
 		return ErrDockerNotConnected
 	}
@@ -235,11 +344,33 @@ func (d *DockerService) RestartContainer(id string) (err error) {
 			err = ErrDockerNotConnected
 		}
 	}()
-	timeout := 10
-	err = d.client.ContainerRestart(d.ctx, id, container.StopOptions{Timeout: &timeout})
+	err = d.client.ContainerUnpause(d.ctx, id)
 	return d.handleError(err)
 }
 
+// TopContainer chạy ps trên host cho các process của container, psArgs rỗng dùng "-ef" của Docker
+func (d *DockerService) TopContainer(id, psArgs string) (result *container.TopResponse, err error) {
+	if !d.IsConnected() {
+		return nil, ErrDockerNotConnected
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			d.markDisconnected()
+			result = nil
+			err = ErrDockerNotConnected
+		}
+	}()
+	var args []string
+	if psArgs != "" {
+		args = []string{psArgs}
+	}
+	top, err := d.client.ContainerTop(d.ctx, id, args)
+	if err != nil {
+		return nil, d.handleError(err)
+	}
+	return &top, nil
+}
+
 func (d *DockerService) RemoveContainer(id string, force bool) (err error) {
 	if !d.IsConnected() {
 		return ErrDockerNotConnected
@@ -272,18 +403,42 @@ func (d *DockerService) GetContainerLogs(id string, tail string) (result string,
 		Timestamps: true,
 	}
 
+	inspect, err := d.client.ContainerInspect(d.ctx, id)
+	if err != nil {
+		return "", d.handleError(err)
+	}
+
 	reader, err := d.client.ContainerLogs(d.ctx, id, options)
 	if err != nil {
 		return "", d.handleError(err)
 	}
 	defer reader.Close()
 
-	logs, err := io.ReadAll(reader)
-	if err != nil {
+	var logs bytes.Buffer
+	if err := DemuxToWriter(&logs, reader, inspect.Config != nil && inspect.Config.Tty); err != nil {
 		return "", d.handleError(err)
 	}
 
-	return string(logs), nil
+	return logs.String(), nil
+}
+
+// ContainerHasTTY cho biết container có chạy với TTY không (log stream không multiplex)
+func (d *DockerService) ContainerHasTTY(id string) (result bool, err error) {
+	if !d.IsConnected() {
+		return false, ErrDockerNotConnected
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			d.markDisconnected()
+			result = false
+			err = ErrDockerNotConnected
+		}
+	}()
+	c, err := d.client.ContainerInspect(d.ctx, id)
+	if err != nil {
+		return false, d.handleError(err)
+	}
+	return c.Config != nil && c.Config.Tty, nil
 }
 
 type ContainerStats struct {
@@ -293,16 +448,21 @@ type ContainerStats struct {
 	MemoryPercent float64 `json:"memoryPercent"`
 	NetworkRx     uint64  `json:"networkRx"`
 	NetworkTx     uint64  `json:"networkTx"`
+	BlockRead     uint64  `json:"blockRead"`
+	BlockWrite    uint64  `json:"blockWrite"`
+	PIDs          uint64  `json:"pids"`
 }
 
 // StatsJSON is used to decode the stats response from Docker API
 type StatsJSON struct {
+	Read     time.Time `json:"read"`
 	CPUStats struct {
 		CPUUsage struct {
 			TotalUsage  uint64   `json:"total_usage"`
 			PercpuUsage []uint64 `json:"percpu_usage"`
 		} `json:"cpu_usage"`
 		SystemUsage uint64 `json:"system_cpu_usage"`
+		OnlineCPUs  uint32 `json:"online_cpus"`
 	} `json:"cpu_stats"`
 	PreCPUStats struct {
 		CPUUsage struct {
@@ -319,6 +479,70 @@ type StatsJSON struct {
 		RxBytes uint64 `json:"rx_bytes"`
 		TxBytes uint64 `json:"tx_bytes"`
 	} `json:"networks"`
+	BlkioStats struct {
+		IoServiceBytesRecursive []struct {
+			Op    string `json:"op"`
+			Value uint64 `json:"value"`
+		} `json:"io_service_bytes_recursive"`
+	} `json:"blkio_stats"`
+	PidsStats struct {
+		Current uint64 `json:"current"`
+	} `json:"pids_stats"`
+}
+
+// ContainerStats tính các chỉ số từ một mẫu stats của Docker
+func (s *StatsJSON) ContainerStats() *ContainerStats {
+	// Tính CPU percent
+	cpuDelta := float64(s.CPUStats.CPUUsage.TotalUsage - s.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(s.CPUStats.SystemUsage - s.PreCPUStats.SystemUsage)
+	cpuPercent := 0.0
+	// cgroup v2 không có percpu_usage, dùng online_cpus
+	numCPUs := int(s.CPUStats.OnlineCPUs)
+	if numCPUs == 0 {
+		numCPUs = len(s.CPUStats.CPUUsage.PercpuUsage)
+	}
+	if numCPUs == 0 {
+		numCPUs = 1
+	}
+	if systemDelta > 0 && cpuDelta > 0 {
+		cpuPercent = (cpuDelta / systemDelta) * float64(numCPUs) * 100.0
+	}
+
+	// Tính Memory percent
+	memPercent := 0.0
+	if s.MemoryStats.Limit > 0 {
+		memPercent = float64(s.MemoryStats.Usage) / float64(s.MemoryStats.Limit) * 100.0
+	}
+
+	// Tính Network
+	var networkRx, networkTx uint64
+	for _, net := range s.Networks {
+		networkRx += net.RxBytes
+		networkTx += net.TxBytes
+	}
+
+	// Tính Block I/O (cgroup v1 dùng "Read"/"Write", v2 dùng "read"/"write")
+	var blockRead, blockWrite uint64
+	for _, entry := range s.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(entry.Op) {
+		case "read":
+			blockRead += entry.Value
+		case "write":
+			blockWrite += entry.Value
+		}
+	}
+
+	return &ContainerStats{
+		CPUPercent:    cpuPercent,
+		MemoryUsage:   s.MemoryStats.Usage,
+		MemoryLimit:   s.MemoryStats.Limit,
+		MemoryPercent: memPercent,
+		NetworkRx:     networkRx,
+		NetworkTx:     networkTx,
+		BlockRead:     blockRead,
+		BlockWrite:    blockWrite,
+		PIDs:          s.PidsStats.Current,
+	}
 }
 
 func (d *DockerService) GetContainerStats(id string) (result *ContainerStats, err error) {
@@ -342,43 +566,37 @@ func (d *DockerService) GetContainerStats(id string) (result *ContainerStats, er
 	if err := json.NewDecoder(stats.Body).Decode(&statsJSON); err != nil {
 		return nil, err
 	}
+	return statsJSON.ContainerStats(), nil
+}
 
-	// Tính CPU percent
-	cpuDelta := float64(statsJSON.CPUStats.CPUUsage.TotalUsage - statsJSON.PreCPUStats.CPUUsage.TotalUsage)
-	systemDelta := float64(statsJSON.CPUStats.SystemUsage - statsJSON.PreCPUStats.SystemUsage)
-	cpuPercent := 0.0
-	numCPUs := len(statsJSON.CPUStats.CPUUsage.PercpuUsage)
-	if numCPUs == 0 {
-		numCPUs = 1
-	}
-	if systemDelta > 0 && cpuDelta > 0 {
-		cpuPercent = (cpuDelta / systemDelta) * float64(numCPUs) * 100.0
-	}
-
-	// Tính Memory percent
-	memPercent := 0.0
-	if statsJSON.MemoryStats.Limit > 0 {
-		memPercent = float64(statsJSON.MemoryStats.Usage) / float64(statsJSON.MemoryStats.Limit) * 100.0
+// StreamContainerStats trả về stream stats của Docker (một JSON mỗi giây).
+// Caller phải đóng reader để dừng stream.
+func (d *DockerService) StreamContainerStats(id string) (result io.ReadCloser, err error) {
+	if !d.IsConnected() {
+		return nil, ErrDockerNotConnected
 	}
-
-	// Tính Network
-	var networkRx, networkTx uint64
-	for _, net := range statsJSON.Networks {
-		networkRx += net.RxBytes
-		networkTx += net.TxBytes
+	defer func() {
+		if r := recover(); r != nil {
+			d.markDisconnected()
+			result = nil
+			err = ErrDockerNotConnected
+		}
+	}()
+	stats, err := d.client.ContainerStats(d.ctx, id, true)
+	if err != nil {
+		return nil, d.handleError(err)
 	}
+	return stats.Body, nil
+}
 
-	return &ContainerStats{
-		CPUPercent:    cpuPercent,
-		MemoryUsage:   statsJSON.MemoryStats.Usage,
-		MemoryLimit:   statsJSON.MemoryStats.Limit,
-		MemoryPercent: memPercent,
-		NetworkRx:     networkRx,
-		NetworkTx:     networkTx,
-	}, nil
+// LogStreamOptions controls which part of a container's log is streamed
+type LogStreamOptions struct {
+	Follow bool
+	Tail   string
+	Since  string
 }
 
-func (d *DockerService) StreamContainerLogs(id string) (result io.ReadCloser, err error) {
+func (d *DockerService) StreamContainerLogs(id string, opts LogStreamOptions) (result io.ReadCloser, err error) {
 	if !d.IsConnected() {
 		return nil, ErrDockerNotConnected
 	}
@@ -392,8 +610,9 @@ func (d *DockerService) StreamContainerLogs(id string) (result io.ReadCloser, er
 	options := container.LogsOptions{
 		ShowStdout: true,
 		ShowStderr: true,
-		Follow:     true,
-		Tail:       "100",
+		Follow:     opts.Follow,
+		Tail:       opts.Tail,
+		Since:      opts.Since,
 		Timestamps: true,
 	}
 
@@ -404,11 +623,41 @@ func (d *DockerService) StreamContainerLogs(id string) (result io.ReadCloser, er
 	return result, nil
 }
 
+// ExecSession is an interactive exec session in a container, either local or via agent
+type ExecSession interface {
+	io.ReadWriteCloser
+	Resize(height, width uint) error
+}
+
+// ExecOptions configures an interactive exec session
+type ExecOptions struct {
+	Cmd        []string `json:"cmd"`
+	User       string   `json:"user"`
+	WorkingDir string   `json:"workingDir"`
+	Env        []string `json:"env"`
+}
+
 // HijackedResponse wraps the Docker hijacked connection
 type HijackedResponse struct {
 	Conn   interface{ Write([]byte) (int, error) }
 	Reader *io.PipeReader
 	closer func()
+	resize func(height, width uint) error
+}
+
+func (h *HijackedResponse) Read(p []byte) (int, error) {
+	return h.Reader.Read(p)
+}
+
+func (h *HijackedResponse) Write(p []byte) (int, error) {
+	return h.Conn.Write(p)
+}
+
+func (h *HijackedResponse) Resize(height, width uint) error {
+	if h.resize == nil {
+		return nil
+	}
+	return h.resize(height, width)
 }
 
 func (h *HijackedResponse) Close() error {
@@ -418,8 +667,8 @@ func (h *HijackedResponse) Close() error {
 	return nil
 }
 
-// CreateExec tạo exec instance trong container
-func (d *DockerService) CreateExec(containerID string) (result string, err error) {
+// CreateExec tạo exec instance trong container (mặc định /bin/sh)
+func (d *DockerService) CreateExec(containerID string, opts ExecOptions) (result string, err error) {
 	if !d.IsConnected() {
 		return "", ErrDockerNotConnected
 	}
@@ -430,12 +679,19 @@ func (d *DockerService) CreateExec(containerID string) (result string, err error
 			err = ErrDockerNotConnected
 		}
 	}()
+	cmd := opts.Cmd
+	if len(cmd) == 0 {
+		cmd = []string{"/bin/sh"}
+	}
 	execConfig := container.ExecOptions{
 		AttachStdin:  true,
 		AttachStdout: true,
 		AttachStderr: true,
 		Tty:          true,
-		Cmd:          []string{"/bin/sh"},
+		Cmd:          cmd,
+		User:         opts.User,
+		WorkingDir:   opts.WorkingDir,
+		Env:          opts.Env,
 	}
 
 	resp, err := d.client.ContainerExecCreate(d.ctx, containerID, execConfig)
@@ -478,5 +734,453 @@ func (d *DockerService) AttachExec(execID string) (result *HijackedResponse, err
 		Conn:   resp.Conn,
 		Reader: pr,
 		closer: resp.Close,
+		resize: func(height, width uint) error {
+			return d.ResizeExec(execID, height, width)
+		},
 	}, nil
 }
+
+// ResizeExec thay đổi kích thước TTY của exec instance
+func (d *DockerService) ResizeExec(execID string, height, width uint) (err error) {
+	if !d.IsConnected() {
+		return ErrDockerNotConnected
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			d.markDisconnected()
+			err = ErrDockerNotConnected
+		}
+	}()
+	err = d.client.ContainerExecResize(d.ctx, execID, container.ResizeOptions{
+		Height: height,
+		Width:  width,
+	})
+	return d.handleError(err)
+}
+
+// ==================== Create / Run ====================
+
+// CreateContainerRequest mô tả container cần tạo (tương đương docker run)
+type CreateContainerRequest struct {
+	Image         string            `json:"image"`
+	Name          string            `json:"name"`
+	Cmd           []string          `json:"cmd"`
+	Entrypoint    []string          `json:"entrypoint"`
+	Env           []string          `json:"env"`
+	WorkingDir    string            `json:"workingDir"`
+	User          string            `json:"user"`
+	Hostname      string            `json:"hostname"`
+	Tty           bool              `json:"tty"`
+	Ports         []PortBinding     `json:"ports"`
+	Volumes       []VolumeBinding   `json:"volumes"`
+	Networks      []string          `json:"networks"`
+	RestartPolicy string            `json:"restartPolicy"` // no, always, unless-stopped, on-failure
+	MaxRetries    int               `json:"maxRetries"`
+	Labels        map[string]string `json:"labels"`
+	Resources     ResourceLimits    `json:"resources"`
+	Start         bool              `json:"start"` // start ngay sau khi tạo (docker run)
+}
+
+type PortBinding struct {
+	ContainerPort string `json:"containerPort"`
+	HostPort      string `json:"hostPort"`
+	HostIP        string `json:"hostIp"`
+	Protocol      string `json:"protocol"` // tcp (mặc định) hoặc udp
+}
+
+type VolumeBinding struct {
+	Source   string `json:"source"` // tên volume hoặc đường dẫn trên host
+	Target   string `json:"target"`
+	ReadOnly bool   `json:"readOnly"`
+}
+
+type ResourceLimits struct {
+	Memory            int64   `json:"memory"`            // bytes
+	MemoryReservation int64   `json:"memoryReservation"` // bytes
+	MemorySwap        int64   `json:"memorySwap"`        // bytes, -1 = unlimited
+	CPUs              float64 `json:"cpus"`              // số CPU, ví dụ 0.5
+	CPUShares         int64   `json:"cpuShares"`
+	CpusetCpus        string  `json:"cpusetCpus"`
+	PidsLimit         int64   `json:"pidsLimit"`
+}
+
+// UpdateContainerRequest thay đổi giới hạn tài nguyên và restart policy của container đang chạy
+// ("docker update"). Field bằng 0 hoặc rỗng được giữ nguyên.
+type UpdateContainerRequest struct {
+	Memory            int64   `json:"memory"`            // bytes
+	MemoryReservation int64   `json:"memoryReservation"` // bytes
+	MemorySwap        int64   `json:"memorySwap"`        // bytes, -1 = unlimited
+	CPUs              float64 `json:"cpus"`              // số CPU, ví dụ 0.5 (không dùng cùng cpuQuota)
+	CPUShares         int64   `json:"cpuShares"`
+	CPUPeriod         int64   `json:"cpuPeriod"` // microseconds
+	CPUQuota          int64   `json:"cpuQuota"`  // microseconds mỗi cpuPeriod
+	CpusetCpus        string  `json:"cpusetCpus"`
+	CpusetMems        string  `json:"cpusetMems"`
+	PidsLimit         *int64  `json:"pidsLimit"`     // 0 hoặc -1 = không giới hạn
+	RestartPolicy     string  `json:"restartPolicy"` // no, always, unless-stopped, on-failure
+	MaxRetries        int     `json:"maxRetries"`
+}
+
+var ErrInvalidContainerUpdate = errors.New("invalid container update")
+
+// ToUpdateConfig chuyển request thành cấu hình update của Docker
+func (req UpdateContainerRequest) ToUpdateConfig() (container.UpdateConfig, error) {
+	var update container.UpdateConfig
+	// maxRetries chỉ có nghĩa khi đổi restart policy
+	if req == (UpdateContainerRequest{MaxRetries: req.MaxRetries}) {
+		return update, fmt.Errorf("%w: nothing to update", ErrInvalidContainerUpdate)
+	}
+	if req.CPUs < 0 || req.Memory < 0 || req.MemoryReservation < 0 || req.MemorySwap < -1 ||
+		req.CPUShares < 0 || req.CPUPeriod < 0 || req.CPUQuota < 0 {
+		return update, fmt.Errorf("%w: limits must not be negative", ErrInvalidContainerUpdate)
+	}
+	if req.CPUs > 0 && (req.CPUPeriod > 0 || req.CPUQuota > 0) {
+		return update, fmt.Errorf("%w: cpus cannot be combined with cpuPeriod/cpuQuota", ErrInvalidContainerUpdate)
+	}
+
+	update.Resources = container.Resources{
+		Memory:            req.Memory,
+		MemoryReservation: req.MemoryReservation,
+		MemorySwap:        req.MemorySwap,
+		NanoCPUs:          int64(req.CPUs * 1e9),
+		CPUShares:         req.CPUShares,
+		CPUPeriod:         req.CPUPeriod,
+		CPUQuota:          req.CPUQuota,
+		CpusetCpus:        req.CpusetCpus,
+		CpusetMems:        req.CpusetMems,
+		PidsLimit:         req.PidsLimit,
+	}
+	if req.RestartPolicy != "" {
+		update.RestartPolicy = container.RestartPolicy{Name: container.RestartPolicyMode(req.RestartPolicy)}
+		if update.RestartPolicy.Name == container.RestartPolicyOnFailure {
+			update.RestartPolicy.MaximumRetryCount = req.MaxRetries
+		}
+		if err := container.ValidateRestartPolicy(update.RestartPolicy); err != nil {
+			return update, fmt.Errorf("%w: %v", ErrInvalidContainerUpdate, err)
+		}
+	}
+	return update, nil
+}
+
+// ContainerSpec là cấu hình Docker gốc dùng để tạo container, gửi nguyên vẹn tới agent
+type ContainerSpec struct {
+	Name             string                    `json:"name"`
+	Config           *container.Config         `json:"config"`
+	HostConfig       *container.HostConfig     `json:"hostConfig"`
+	NetworkingConfig *network.NetworkingConfig `json:"networkingConfig"`
+}
+
+type CreateContainerResult struct {
+	ID       string   `json:"id"`
+	Warnings []string `json:"warnings"`
+}
+
+// ToSpec chuyển request thành cấu hình Docker
+func (req CreateContainerRequest) ToSpec() (*ContainerSpec, error) {
+	if req.Image == "" {
+		return nil, fmt.Errorf("image is required")
+	}
+
+	exposedPorts := nat.PortSet{}
+	portBindings := nat.PortMap{}
+	for _, p := range req.Ports {
+		proto := p.Protocol
+		if proto == "" {
+			proto = "tcp"
+		}
+		port, err := nat.NewPort(proto, p.ContainerPort)
+		if err != nil {
+			return nil, fmt.Errorf("invalid container port %q: %w", p.ContainerPort, err)
+		}
+		exposedPorts[port] = struct{}{}
+		if p.HostPort != "" || p.HostIP != "" {
+			portBindings[port] = append(portBindings[port], nat.PortBinding{
+				HostIP:   p.HostIP,
+				HostPort: p.HostPort,
+			})
+		}
+	}
+
+	binds := make([]string, 0, len(req.Volumes))
+	for _, v := range req.Volumes {
+		if v.Source == "" || v.Target == "" {
+			return nil, fmt.Errorf("volume binding requires source and target")
+		}
+		bind := v.Source + ":" + v.Target
+		if v.ReadOnly {
+			bind += ":ro"
+		}
+		binds = append(binds, bind)
+	}
+
+	restartPolicy := container.RestartPolicy{Name: container.RestartPolicyMode(req.RestartPolicy)}
+	if restartPolicy.Name == container.RestartPolicyOnFailure {
+		restartPolicy.MaximumRetryCount = req.MaxRetries
+	}
+
+	resources := container.Resources{
+		Memory:            req.Resources.Memory,
+		MemoryReservation: req.Resources.MemoryReservation,
+		MemorySwap:        req.Resources.MemorySwap,
+		NanoCPUs:          int64(req.Resources.CPUs * 1e9),
+		CPUShares:         req.Resources.CPUShares,
+		CpusetCpus:        req.Resources.CpusetCpus,
+	}
+	if req.Resources.PidsLimit != 0 {
+		pids := req.Resources.PidsLimit
+		resources.PidsLimit = &pids
+	}
+
+	hostConfig := &container.HostConfig{
+		Binds:         binds,
+		PortBindings:  portBindings,
+		RestartPolicy: restartPolicy,
+		Resources:     resources,
+	}
+
+	var networkingConfig *network.NetworkingConfig
+	if len(req.Networks) > 0 {
+		hostConfig.NetworkMode = container.NetworkMode(req.Networks[0])
+		endpoints := make(map[string]*network.EndpointSettings, len(req.Networks))
+		for _, name := range req.Networks {
+			endpoints[name] = &network.EndpointSettings{}
+		}
+		networkingConfig = &network.NetworkingConfig{EndpointsConfig: endpoints}
+	}
+
+	return &ContainerSpec{
+		Name: req.Name,
+		Config: &container.Config{
+			Image:        req.Image,
+			Cmd:          req.Cmd,
+			Entrypoint:   req.Entrypoint,
+			Env:          req.Env,
+			WorkingDir:   req.WorkingDir,
+			User:         req.User,
+			Hostname:     req.Hostname,
+			Tty:          req.Tty,
+			OpenStdin:    req.Tty,
+			ExposedPorts: exposedPorts,
+			Labels:       req.Labels,
+		},
+		HostConfig:       hostConfig,
+		NetworkingConfig: networkingConfig,
+	}, nil
+}
+
+// SpecFromInspect dựng lại cấu hình tạo container từ kết quả inspect.
+// Dữ liệu runtime (IP, endpoint ID, MAC, hostname mặc định...) được loại bỏ để
+// container mới nhận giá trị riêng. Nếu imageConfig (cấu hình của image container đang chạy)
+// khác nil, các giá trị trùng với mặc định của image đó cũng bị loại bỏ để image mới
+// áp dụng mặc định của nó.
+func SpecFromInspect(inspect *container.InspectResponse, imageConfig *ImageConfig) (*ContainerSpec, error) {
+	if inspect.Config == nil {
+		return nil, fmt.Errorf("container has no config")
+	}
+	config := *inspect.Config
+	if imageConfig != nil {
+		stripImageDefaults(&config, imageConfig)
+	}
+	shortID := ""
+	if inspect.ContainerJSONBase != nil && len(inspect.ID) >= 12 {
+		shortID = inspect.ID[:12]
+	}
+	// Hostname mặc định là short ID của container cũ
+	if shortID != "" && config.Hostname == shortID {
+		config.Hostname = ""
+	}
+
+	var hostConfig *container.HostConfig
+	name := ""
+	if inspect.ContainerJSONBase != nil {
+		if inspect.HostConfig != nil {
+			hc := *inspect.HostConfig
+			hostConfig = &hc
+		}
+		name = strings.TrimPrefix(inspect.Name, "/")
+	}
+
+	var networkingConfig *network.NetworkingConfig
+	if inspect.NetworkSettings != nil && len(inspect.NetworkSettings.Networks) > 0 {
+		endpoints := make(map[string]*network.EndpointSettings, len(inspect.NetworkSettings.Networks))
+		for netName, ep := range inspect.NetworkSettings.Networks {
+			if ep == nil {
+				continue
+			}
+			aliases := make([]string, 0, len(ep.Aliases))
+			for _, alias := range ep.Aliases {
+				if alias != shortID {
+					aliases = append(aliases, alias)
+				}
+			}
+			endpoints[netName] = &network.EndpointSettings{
+				IPAMConfig: ep.IPAMConfig,
+				Links:      ep.Links,
+				Aliases:    aliases,
+				DriverOpts: ep.DriverOpts,
+				GwPriority: ep.GwPriority,
+			}
+		}
+		networkingConfig = &network.NetworkingConfig{EndpointsConfig: endpoints}
+	}
+
+	return &ContainerSpec{
+		Name:             name,
+		Config:           &config,
+		HostConfig:       hostConfig,
+		NetworkingConfig: networkingConfig,
+	}, nil
+}
+
+// KeepAnonymousVolumes gắn lại anonymous volume (VOLUME của image hoặc "-v /path") của container
+// cũ vào spec, để container tạo lại giữ dữ liệu thay vì nhận volume rỗng mới (giống docker compose).
+func KeepAnonymousVolumes(spec *ContainerSpec, inspect *container.InspectResponse) {
+	if inspect.ContainerJSONBase == nil {
+		return
+	}
+	if spec.HostConfig == nil {
+		spec.HostConfig = &container.HostConfig{}
+	}
+	for _, mp := range inspect.Mounts {
+		if mp.Type != mount.TypeVolume || mp.Name == "" || isNamedVolume(inspect.HostConfig, mp.Name) {
+			continue
+		}
+		// Mount volume không có source trong spec sẽ tạo volume mới, thay bằng volume cũ
+		replaced := false
+		for i, mnt := range spec.HostConfig.Mounts {
+			if mnt.Target == mp.Destination {
+				if mnt.Type == mount.TypeVolume && mnt.Source == "" {
+					spec.HostConfig.Mounts[i].Source = mp.Name
+				}
+				replaced = true
+				break
+			}
+		}
+		if replaced || hasBindTarget(spec.HostConfig.Binds, mp.Destination) {
+			continue
+		}
+		spec.HostConfig.Mounts = append(spec.HostConfig.Mounts, mount.Mount{
+			Type:     mount.TypeVolume,
+			Source:   mp.Name,
+			Target:   mp.Destination,
+			ReadOnly: !mp.RW,
+		})
+	}
+}
+
+// hasBindTarget cho biết Binds ("src:dst[:opts]") đã có mount tại target chưa
+func hasBindTarget(binds []string, target string) bool {
+	for _, bind := range binds {
+		parts := strings.Split(bind, ":")
+		if len(parts) >= 2 && parts[1] == target {
+			return true
+		}
+	}
+	return false
+}
+
+// stripImageDefaults bỏ các giá trị config được kế thừa từ image (Env, Cmd, Entrypoint,
+// WorkingDir, User, Labels), chỉ giữ lại phần người dùng đã ghi đè
+func stripImageDefaults(config *container.Config, image *ImageConfig) {
+	if len(config.Env) > 0 {
+		imageEnv := make(map[string]bool, len(image.Env))
+		for _, env := range image.Env {
+			imageEnv[env] = true
+		}
+		env := make([]string, 0, len(config.Env))
+		for _, e := range config.Env {
+			if !imageEnv[e] {
+				env = append(env, e)
+			}
+		}
+		config.Env = env
+	}
+	if slices.Equal(config.Entrypoint, image.Entrypoint) {
+		config.Entrypoint = nil
+	}
+	if slices.Equal(config.Cmd, image.Cmd) {
+		config.Cmd = nil
+	}
+	if config.WorkingDir == image.WorkingDir {
+		config.WorkingDir = ""
+	}
+	if config.User == image.User {
+		config.User = ""
+	}
+	if len(config.Labels) > 0 {
+		labels := make(map[string]string, len(config.Labels))
+		for key, value := range config.Labels {
+			if imageValue, ok := image.Labels[key]; !ok || imageValue != value {
+				labels[key] = value
+			}
+		}
+		config.Labels = labels
+	}
+}
+
+// CreateContainer tạo container từ spec, tự pull image nếu chưa có.
+// Container được tạo với network chính (NetworkMode), các network còn lại được connect sau
+// để tương thích với Docker API cũ chỉ hỗ trợ một endpoint khi tạo.
+func (d *DockerService) CreateContainer(spec *ContainerSpec) (result *CreateContainerResult, err error) {
+	if !d.IsConnected() {
+		return nil, ErrDockerNotConnected
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			d.markDisconnected()
+			result = nil
+			err = ErrDockerNotConnected
+		}
+	}()
+	if spec == nil || spec.Config == nil || spec.Config.Image == "" {
+		return nil, fmt.Errorf("image is required")
+	}
+
+	primary, extra := splitEndpoints(spec)
+
+	resp, err := d.client.ContainerCreate(d.ctx, spec.Config, spec.HostConfig, primary, nil, spec.Name)
+	if err != nil && client.IsErrNotFound(err) {
+		if pullErr := d.PullImage(spec.Config.Image); pullErr != nil {
+			return nil, pullErr
+		}
+		resp, err = d.client.ContainerCreate(d.ctx, spec.Config, spec.HostConfig, primary, nil, spec.Name)
+	}
+	if err != nil {
+		return nil, d.handleError(err)
+	}
+
+	for name, endpoint := range extra {
+		if err := d.client.NetworkConnect(d.ctx, name, resp.ID, endpoint); err != nil {
+			d.client.ContainerRemove(d.ctx, resp.ID, container.RemoveOptions{Force: true})
+			return nil, d.handleError(err)
+		}
+	}
+
+	return &CreateContainerResult{
+		ID:       resp.ID[:12],
+		Warnings: resp.Warnings,
+	}, nil
+}
+
+// splitEndpoints tách endpoint của network chính khỏi các network phụ
+func splitEndpoints(spec *ContainerSpec) (*network.NetworkingConfig, map[string]*network.EndpointSettings) {
+	if spec.NetworkingConfig == nil || len(spec.NetworkingConfig.EndpointsConfig) <= 1 {
+		return spec.NetworkingConfig, nil
+	}
+
+	primaryName := ""
+	if spec.HostConfig != nil {
+		primaryName = string(spec.HostConfig.NetworkMode)
+	}
+
+	primary := &network.NetworkingConfig{EndpointsConfig: map[string]*network.EndpointSettings{}}
+	extra := make(map[string]*network.EndpointSettings)
+	for name, endpoint := range spec.NetworkingConfig.EndpointsConfig {
+		if name == primaryName {
+			primary.EndpointsConfig[name] = endpoint
+		} else {
+			extra[name] = endpoint
+		}
+	}
+	return primary, extra
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidSignal = errors.New("invalid signal")
+
+const (
+	// Số signal lớn nhất trên Linux (SIGRTMAX)
+	maxSignal = 64
+	// Docker nhận SIGRTMIN+1..+15 và SIGRTMAX-14..-1
+	maxRealtimeOffset = 15
+)
+
+// Tên signal Linux mà Docker chấp nhận (không kèm tiền tố SIG)
+var linuxSignals = map[string]bool{
+	"ABRT": true, "ALRM": true, "BUS": true, "CHLD": true, "CLD": true, "CONT": true,
+	"FPE": true, "HUP": true, "ILL": true, "INT": true, "IO": true, "IOT": true,
+	"KILL": true, "PIPE": true, "POLL": true, "PROF": true, "PWR": true, "QUIT": true,
+	"SEGV": true, "STKFLT": true, "STOP": true, "SYS": true, "TERM": true, "TRAP": true,
+	"TSTP": true, "TTIN": true, "TTOU": true, "URG": true, "USR1": true, "USR2": true,
+	"VTALRM": true, "WINCH": true, "XCPU": true, "XFSZ": true,
+	"RTMIN": true, "RTMAX": true,
+}
+
+// normalizeSignal kiểm tra signal gửi cho container: số (1-64), tên có hoặc không có
+// tiền tố SIG, hoặc RTMIN+n/RTMAX-n. Trả về tên viết hoa, e.g. "term" -> "SIGTERM".
+func normalizeSignal(signal string) (string, error) {
+	signal = strings.ToUpper(strings.TrimSpace(signal))
+	if n, err := strconv.Atoi(signal); err == nil {
+		if n < 1 || n > maxSignal {
+			return "", ErrInvalidSignal
+		}
+		return signal, nil
+	}
+
+	name := strings.TrimPrefix(signal, "SIG")
+	if base, offset, ok := strings.Cut(name, "+"); ok && base == "RTMIN" {
+		if n, err := strconv.Atoi(offset); err != nil || n < 1 || n > maxRealtimeOffset {
+			return "", ErrInvalidSignal
+		}
+		return "SIG" + name, nil
+	}
+	if base, offset, ok := strings.Cut(name, "-"); ok && base == "RTMAX" {
+		if n, err := strconv.Atoi(offset); err != nil || n < 1 || n >= maxRealtimeOffset {
+			return "", ErrInvalidSignal
+		}
+		return "SIG" + name, nil
+	}
+	if !linuxSignals[name] {
+		return "", ErrInvalidSignal
+	}
+	return "SIG" + name, nil
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// Tham số ps mặc định cho top, đủ cột cho ContainerProcess
+const defaultPsArgs = "-eo pid,ppid,user,%cpu,%mem,etime,args"
+
+// ContainerProcess là một process trong container, lấy từ các cột ps tương ứng
+type ContainerProcess struct {
+	PID     string  `json:"pid"`
+	PPID    string  `json:"ppid,omitempty"`
+	User    string  `json:"user,omitempty"`
+	CPU     float64 `json:"cpu"`    // %CPU
+	Memory  float64 `json:"memory"` // %MEM
+	Elapsed string  `json:"elapsed,omitempty"`
+	Command string  `json:"command"`
+}
+
+// ContainerTop là kết quả top: cột gốc của ps và danh sách process đã map theo cột
+type ContainerTop struct {
+	Titles    []string           `json:"titles"`
+	Rows      [][]string         `json:"rows"`
+	Processes []ContainerProcess `json:"processes"`
+}
+
+func newContainerTop(top *container.TopResponse) *ContainerTop {
+	result := &ContainerTop{
+		Titles:    top.Titles,
+		Rows:      top.Processes,
+		Processes: make([]ContainerProcess, 0, len(top.Processes)),
+	}
+	if result.Titles == nil {
+		result.Titles = []string{}
+	}
+	if result.Rows == nil {
+		result.Rows = [][]string{}
+	}
+
+	for _, row := range top.Processes {
+		var process ContainerProcess
+		for i, title := range top.Titles {
+			if i >= len(row) {
+				break
+			}
+			value := row[i]
+			switch strings.ToUpper(title) {
+			case "PID":
+				process.PID = value
+			case "PPID":
+				process.PPID = value
+			case "USER", "UID":
+				process.User = value
+			case "%CPU", "C":
+				process.CPU, _ = strconv.ParseFloat(value, 64)
+			case "%MEM":
+				process.Memory, _ = strconv.ParseFloat(value, 64)
+			case "ELAPSED":
+				process.Elapsed = value
+			case "COMMAND", "CMD", "ARGS":
+				process.Command = value
+			}
+		}
+		result.Processes = append(result.Processes, process)
+	}
+	return result
+}
+
+// TopContainer trả về các process đang chạy trong container (local và agent).
+// psArgs rỗng dùng defaultPsArgs; ps chạy trên host nên cột tùy theo ps của host.
+func (m *ServerManager) TopContainer(serverID, containerID, psArgs string) (*ContainerTop, error) {
+	if psArgs == "" {
+		psArgs = defaultPsArgs
+	}
+	if m.IsLocal(serverID) {
+		top, err := m.localDocker.TopContainer(containerID, psArgs)
+		if err != nil {
+			return nil, err
+		}
+		return newContainerTop(top), nil
+	}
+
+	client := m.getAgentClient(serverID)
+	if client == nil {
+		return nil, ErrServerNotFound
+	}
+
+	data, err := client.TopContainer(containerID, psArgs)
+	if err != nil {
+		return nil, err
+	}
+	var top container.TopResponse
+	if err := json.Unmarshal(data, &top); err != nil {
+		return nil, err
+	}
+	return newContainerTop(&top), nil
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/image"
 	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/api/types/volume"
 	"github.com/docker/docker/client"
@@ -27,6 +28,7 @@ type DockerService struct {
 	mu           sync.RWMutex
 	stopHealthCh chan struct{}
 	listeners    []func(connected bool)
+	registryAuth func(ref string) string
 }
 
 // NewDockerService creates a new Docker service (gracefully handles Docker not running)
@@ -42,6 +44,24 @@ func NewDockerService() (*DockerService, error) {
 	return ds, nil
 }
 
+// SetRegistryAuth sets the provider returning X-Registry-Auth for an image reference,
+// used by pulls so images from private registries can be fetched.
+func (d *DockerService) SetRegistryAuth(provider func(ref string) string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.registryAuth = provider
+}
+
+func (d *DockerService) pullOptions(ref string) image.PullOptions {
+	d.mu.RLock()
+	provider := d.registryAuth
+	d.mu.RUnlock()
+	if provider == nil {
+		return image.PullOptions{}
+	}
+	return image.PullOptions{RegistryAuth: provider(ref)}
+}
+
 // tryConnect attempts to connect to Docker daemon
 func (d *DockerService) tryConnect() bool {
 	d.mu.Lock()
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+const (
+	StreamStdout = "stdout"
+	StreamStderr = "stderr"
+)
+
+// ErrStreamClosed is returned by emit callbacks to stop demultiplexing early
+var ErrStreamClosed = errors.New("stream closed")
+
+// LogLine is a single log line with its origin stream and Docker timestamp
+type LogLine struct {
+	Stream    string `json:"stream"`
+	Timestamp string `json:"timestamp,omitempty"`
+	Data      string `json:"data"`
+}
+
+// DemuxLogs reads a Docker log stream and calls emit once per complete line.
+// Containers without TTY produce a multiplexed stream (8-byte frame headers) which is
+// split into stdout and stderr; TTY containers produce a raw stream reported as stdout.
+// Frames and lines may span reads. Returns nil when the stream ends normally.
+func DemuxLogs(r io.Reader, tty bool, emit func(LogLine) error) error {
+	stdout := &lineWriter{stream: StreamStdout, emit: emit}
+	stderr := &lineWriter{stream: StreamStderr, emit: emit}
+
+	var err error
+	if tty {
+		_, err = io.Copy(stdout, r)
+	} else {
+		_, err = stdcopy.StdCopy(stdout, stderr, r)
+	}
+	if err == nil {
+		if err = stdout.flush(); err == nil {
+			err = stderr.flush()
+		}
+	}
+	if errors.Is(err, ErrStreamClosed) {
+		return nil
+	}
+	return err
+}
+
+// DemuxToWriter writes the payload of a Docker log stream to w, stripping frame headers
+// when the stream is multiplexed. stdout and stderr are interleaved in arrival order.
+func DemuxToWriter(w io.Writer, r io.Reader, tty bool) error {
+	var err error
+	if tty {
+		_, err = io.Copy(w, r)
+	} else {
+		_, err = stdcopy.StdCopy(w, w, r)
+	}
+	return err
+}
+
+// lineWriter buffers partial writes and emits complete lines
+type lineWriter struct {
+	stream string
+	emit   func(LogLine) error
+	buf    bytes.Buffer
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf.Write(p)
+	for {
+		idx := bytes.IndexByte(w.buf.Bytes(), '\n')
+		if idx < 0 {
+			break
+		}
+		line := string(w.buf.Next(idx + 1))
+		if err := w.emitLine(line); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (w *lineWriter) flush() error {
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	line := w.buf.String()
+	w.buf.Reset()
+	return w.emitLine(line)
+}
+
+func (w *lineWriter) emitLine(line string) error {
+	line = strings.TrimRight(line, "\r\n")
+	timestamp, data := splitLogTimestamp(line)
+	if timestamp == "" && data == "" {
+		return nil
+	}
+	return w.emit(LogLine{
+		Stream:    w.stream,
+		Timestamp: timestamp,
+		Data:      data,
+	})
+}
+
+// splitLogTimestamp separates the RFC3339Nano prefix added by Docker when
+// Timestamps is enabled. Lines without a valid prefix are returned unchanged.
+func splitLogTimestamp(line string) (string, string) {
+	idx := strings.IndexByte(line, ' ')
+	if idx <= 0 {
+		if _, err := time.Parse(time.RFC3339Nano, line); err == nil {
+			return line, ""
+		}
+		return "", line
+	}
+	if _, err := time.Parse(time.RFC3339Nano, line[:idx]); err != nil {
+		return "", line
+	}
+	return line[:idx], line[idx+1:]
+}
+
+// UTF8Chunker splits a byte stream into chunks that never end in the middle of a
+// multi-byte UTF-8 sequence, so each chunk can be safely JSON-encoded as a string.
+type UTF8Chunker struct {
+	pending []byte
+}
+
+// Next returns the printable part of pending+data and keeps an incomplete trailing rune
+func (c *UTF8Chunker) Next(data []byte) string {
+	buf := append(c.pending, data...)
+	cut := len(buf)
+	// A UTF-8 sequence is at most 4 bytes, so only the tail needs checking
+	for i := len(buf) - 1; i >= 0 && i >= len(buf)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(buf[i]) {
+			if !utf8.FullRune(buf[i:]) {
+				cut = i
+			}
+			break
+		}
+	}
+	c.pending = append([]byte(nil), buf[cut:]...)
+	return string(buf[:cut])
+}
+
+// ProgressMessage is a single message of a Docker JSON progress stream (pull, push, build)
+type ProgressMessage struct {
+	ID       string `json:"id,omitempty"` // layer ID
+	Status   string `json:"status,omitempty"`
+	Progress string `json:"progress,omitempty"` // text progress bar rendered by Docker
+	Current  int64  `json:"current,omitempty"`
+	Total    int64  `json:"total,omitempty"`
+	Stream   string `json:"stream,omitempty"`  // build output
+	ImageID  string `json:"imageId,omitempty"` // image ID khi build xong
+}
+
+// DecodeProgress reads a Docker JSON message stream and calls emit for every message.
+// An error reported inside the stream is returned as an error. emit may be nil when
+// the caller only needs to wait for the operation to finish.
+func DecodeProgress(r io.Reader, emit func(ProgressMessage) error) error {
+	decoder := json.NewDecoder(r)
+	for {
+		var msg jsonmessage.JSONMessage
+		if err := decoder.Decode(&msg); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if emit == nil {
+			continue
+		}
+
+		progress := ProgressMessage{
+			ID:     msg.ID,
+			Status: msg.Status,
+			Stream: msg.Stream,
+		}
+		if msg.Aux != nil {
+			var aux struct {
+				ID string `json:"ID"`
+			}
+			if json.Unmarshal(*msg.Aux, &aux) == nil {
+				progress.ImageID = aux.ID
+			}
+		}
+		if msg.Progress != nil {
+			progress.Progress = msg.ProgressMessage
+			progress.Current = msg.Progress.Current
+			progress.Total = msg.Progress.Total
+		}
+		if err := emit(progress); err != nil {
+			if errors.Is(err, ErrStreamClosed) {
+				return nil
+			}
+			return err
+		}
+	}
+}
@@ -2,21 +2,29 @@ package services
 
 import (
 	"io"
+	"sort"
 	"strconv"
+	"time"
+
+	"appdock/internal/models"
 
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/registry"
+	"github.com/docker/docker/client"
 )
+
 type ImageInfo struct {
-	ID          string            `json:"id"`
-	RepoTags    []string          `json:"repoTags"`
-	RepoDigests []string          `json:"repoDigests"`
-	Created     int64             `json:"created"`
-	Size        int64             `json:"size"`
-	VirtualSize int64             `json:"virtualSize"`
-	Labels      map[string]string `json:"labels"`
-	InUse       bool              `json:"inUse"`
-	Containers  []string          `json:"containers"` // Container names using this image
+	ID          string              `json:"id"`
+	RepoTags    []string            `json:"repoTags"`
+	RepoDigests []string            `json:"repoDigests"`
+	Created     int64               `json:"created"`
+	Size        int64               `json:"size"`
+	VirtualSize int64               `json:"virtualSize"`
+	Labels      map[string]string   `json:"labels"`
+	InUse       bool                `json:"inUse"`
+	Containers  []string            `json:"containers"`     // Container names using this image
+	Scan        *models.ScanSummary `json:"scan,omitempty"` // Kết quả scan lỗ hổng gần nhất
 }
 
 func (d *DockerService) ListImages() (result []ImageInfo, err error) {
@@ -126,22 +134,186 @@ func (d *DockerService) GetImage(id string) (result *ImageInfo, err error) {
 		imgID = imgID[7:19]
 	}
 
-	created, err := strconv.ParseInt(img.Created, 10, 64)
-	if err != nil {
-		return nil, err
+	var labels map[string]string
+	if img.Config != nil {
+		labels = img.Config.Labels
 	}
 
 	return &ImageInfo{
 		ID:          imgID,
 		RepoTags:    img.RepoTags,
 		RepoDigests: img.RepoDigests,
-		Created:     created, // img.Created is a string (not time.Time), so assign directly
+		Created:     parseImageCreated(img.Created),
 		Size:        img.Size,
 		VirtualSize: img.Size,
-		Labels:      img.Config.Labels,
+		Labels:      labels,
 	}, nil
 }
 
+// parseImageCreated chuyển thời điểm tạo image (RFC3339, API cũ dùng unix timestamp) sang unix
+func parseImageCreated(created string) int64 {
+	if t, err := time.Parse(time.RFC3339Nano, created); err == nil {
+		return t.Unix()
+	}
+	if ts, err := strconv.ParseInt(created, 10, 64); err == nil {
+		return ts
+	}
+	return 0
+}
+
+// ImageLayer một bước trong lịch sử image, tương ứng một dòng của docker history
+type ImageLayer struct {
+	ID        string   `json:"id"` // "<missing>" nếu layer được build ở máy khác
+	Created   int64    `json:"created"`
+	CreatedBy string   `json:"createdBy"` // lệnh Dockerfile đã tạo layer
+	Size      int64    `json:"size"`
+	Comment   string   `json:"comment,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+	Empty     bool     `json:"empty"` // chỉ đổi metadata (ENV, CMD, LABEL, ...), không thêm dữ liệu
+}
+
+// ImageConfig cấu hình mặc định khi chạy container từ image
+type ImageConfig struct {
+	Entrypoint   []string          `json:"entrypoint"`
+	Cmd          []string          `json:"cmd"`
+	Env          []string          `json:"env"`
+	WorkingDir   string            `json:"workingDir"`
+	User         string            `json:"user"`
+	ExposedPorts []string          `json:"exposedPorts"` // e.g., "80/tcp"
+	Volumes      []string          `json:"volumes"`
+	Labels       map[string]string `json:"labels"`
+	StopSignal   string            `json:"stopSignal,omitempty"`
+	Healthcheck  *ImageHealthcheck `json:"healthcheck,omitempty"`
+}
+
+type ImageHealthcheck struct {
+	Test        []string `json:"test"`
+	Interval    string   `json:"interval,omitempty"` // e.g., "30s"
+	Timeout     string   `json:"timeout,omitempty"`
+	StartPeriod string   `json:"startPeriod,omitempty"`
+	Retries     int      `json:"retries,omitempty"`
+}
+
+// ImageDetails chi tiết image: cấu hình đầy đủ và kích thước từng layer
+type ImageDetails struct {
+	ImageInfo
+	Architecture string       `json:"architecture"`
+	Os           string       `json:"os"`
+	Author       string       `json:"author,omitempty"`
+	Config       ImageConfig  `json:"config"`
+	Layers       []ImageLayer `json:"layers"`     // mới nhất trước, giống docker history
+	LayerCount   int          `json:"layerCount"` // số layer có dữ liệu trong rootfs
+}
+
+func (d *DockerService) GetImageDetails(id string) (result *ImageDetails, err error) {
+	if !d.IsConnected() {
+		return nil, ErrDockerNotConnected
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			d.markDisconnected()
+			result = nil
+			err = ErrDockerNotConnected
+		}
+	}()
+	img, err := d.client.ImageInspect(d.ctx, id)
+	if err != nil {
+		return nil, d.handleError(err)
+	}
+	history, err := d.client.ImageHistory(d.ctx, img.ID)
+	if err != nil {
+		return nil, d.handleError(err)
+	}
+	return newImageDetails(img, history), nil
+}
+
+// newImageDetails ghép kết quả inspect và history (local hoặc JSON thô từ agent)
+func newImageDetails(img image.InspectResponse, history []image.HistoryResponseItem) *ImageDetails {
+	imgID := img.ID
+	if len(imgID) > 19 {
+		imgID = imgID[7:19]
+	}
+
+	details := &ImageDetails{
+		ImageInfo: ImageInfo{
+			ID:          imgID,
+			RepoTags:    img.RepoTags,
+			RepoDigests: img.RepoDigests,
+			Created:     parseImageCreated(img.Created),
+			Size:        img.Size,
+			VirtualSize: img.Size,
+		},
+		Architecture: img.Architecture,
+		Os:           img.Os,
+		Author:       img.Author,
+		Config: ImageConfig{
+			Entrypoint:   make([]string, 0),
+			Cmd:          make([]string, 0),
+			Env:          make([]string, 0),
+			ExposedPorts: make([]string, 0),
+			Volumes:      make([]string, 0),
+		},
+		Layers:     make([]ImageLayer, 0, len(history)),
+		LayerCount: len(img.RootFS.Layers),
+	}
+
+	if cfg := img.Config; cfg != nil {
+		details.Labels = cfg.Labels
+		details.Config.Labels = cfg.Labels
+		details.Config.WorkingDir = cfg.WorkingDir
+		details.Config.User = cfg.User
+		details.Config.StopSignal = cfg.StopSignal
+		if cfg.Entrypoint != nil {
+			details.Config.Entrypoint = cfg.Entrypoint
+		}
+		if cfg.Cmd != nil {
+			details.Config.Cmd = cfg.Cmd
+		}
+		if cfg.Env != nil {
+			details.Config.Env = cfg.Env
+		}
+		for port := range cfg.ExposedPorts {
+			details.Config.ExposedPorts = append(details.Config.ExposedPorts, port)
+		}
+		sort.Strings(details.Config.ExposedPorts)
+		for path := range cfg.Volumes {
+			details.Config.Volumes = append(details.Config.Volumes, path)
+		}
+		sort.Strings(details.Config.Volumes)
+
+		if hc := cfg.Healthcheck; hc != nil && len(hc.Test) > 0 {
+			details.Config.Healthcheck = &ImageHealthcheck{
+				Test:        hc.Test,
+				Interval:    formatDuration(hc.Interval),
+				Timeout:     formatDuration(hc.Timeout),
+				StartPeriod: formatDuration(hc.StartPeriod),
+				Retries:     hc.Retries,
+			}
+		}
+	}
+
+	for _, item := range history {
+		details.Layers = append(details.Layers, ImageLayer{
+			ID:        item.ID,
+			Created:   item.Created,
+			CreatedBy: item.CreatedBy,
+			Size:      item.Size,
+			Comment:   item.Comment,
+			Tags:      item.Tags,
+			Empty:     item.Size == 0,
+		})
+	}
+
+	return details
+}
+
+func formatDuration(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return d.String()
+}
+
 func (d *DockerService) RemoveImage(id string, force bool) (err error) {
 	if !d.IsConnected() {
 		return ErrDockerNotConnected
@@ -166,15 +338,127 @@ func (d *DockerService) PullImage(refStr string) (err error) {
 			err = ErrDockerNotConnected
 		}
 	}()
-	reader, err := d.client.ImagePull(d.ctx, refStr, image.PullOptions{})
+	reader, err := d.client.ImagePull(d.ctx, refStr, d.pullOptions(refStr))
 	if err != nil {
 		return d.handleError(err)
 	}
 	defer reader.Close()
 
-	// Đọc hết response để hoàn thành pull
-	_, err = io.Copy(io.Discard, reader)
-	return d.handleError(err)
+	// Đọc hết response để hoàn thành pull, lỗi nằm trong stream cũng được trả về
+	return d.handleError(DecodeProgress(reader, nil))
+}
+
+// StreamPullImage bắt đầu pull và trả về stream JSON progress của Docker.
+// Caller phải đọc hết và đóng stream; dùng DecodeProgress để đọc từng message.
+func (d *DockerService) StreamPullImage(refStr string) (result io.ReadCloser, err error) {
+	if !d.IsConnected() {
+		return nil, ErrDockerNotConnected
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			d.markDisconnected()
+			result = nil
+			err = ErrDockerNotConnected
+		}
+	}()
+	reader, err := d.client.ImagePull(d.ctx, refStr, d.pullOptions(refStr))
+	if err != nil {
+		return nil, d.handleError(err)
+	}
+	return reader, nil
+}
+
+// TagImage gắn thêm tag target (vd. registry.example.com/app:1.0) cho image source
+func (d *DockerService) TagImage(source, target string) (err error) {
+	if !d.IsConnected() {
+		return ErrDockerNotConnected
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			d.markDisconnected()
+			err = ErrDockerNotConnected
+		}
+	}()
+	return d.handleError(d.client.ImageTag(d.ctx, source, target))
+}
+
+// StreamPushImage bắt đầu push và trả về stream JSON progress của Docker.
+// registryAuth là X-Registry-Auth đã mã hóa base64.
+func (d *DockerService) StreamPushImage(refStr, registryAuth string) (result io.ReadCloser, err error) {
+	if !d.IsConnected() {
+		return nil, ErrDockerNotConnected
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			d.markDisconnected()
+			result = nil
+			err = ErrDockerNotConnected
+		}
+	}()
+	reader, err := d.client.ImagePush(d.ctx, refStr, image.PushOptions{RegistryAuth: registryAuth})
+	if err != nil {
+		return nil, d.handleError(err)
+	}
+	return reader, nil
+}
+
+// SaveImage export image (tên, tag hoặc ID) thành tar giống "docker save".
+// Caller phải đóng stream.
+func (d *DockerService) SaveImage(refs []string) (result io.ReadCloser, err error) {
+	if !d.IsConnected() {
+		return nil, ErrDockerNotConnected
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			d.markDisconnected()
+			result = nil
+			err = ErrDockerNotConnected
+		}
+	}()
+	reader, err := d.client.ImageSave(d.ctx, refs)
+	if err != nil {
+		return nil, d.handleError(err)
+	}
+	return reader, nil
+}
+
+// LoadImage import image từ tar của "docker save" (có thể nén gzip), giống "docker load".
+// Trả về output JSON của Docker; caller phải đọc hết để biết kết quả và đóng reader.
+func (d *DockerService) LoadImage(input io.Reader) (result io.ReadCloser, err error) {
+	if !d.IsConnected() {
+		return nil, ErrDockerNotConnected
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			d.markDisconnected()
+			result = nil
+			err = ErrDockerNotConnected
+		}
+	}()
+	resp, err := d.client.ImageLoad(d.ctx, input, client.ImageLoadWithQuiet(true))
+	if err != nil {
+		return nil, d.handleError(err)
+	}
+	return resp.Body, nil
+}
+
+// RegistryLogin kiểm tra thông tin đăng nhập registry từ Docker daemon
+func (d *DockerService) RegistryLogin(auth registry.AuthConfig) (status string, err error) {
+	if !d.IsConnected() {
+		return "", ErrDockerNotConnected
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			d.markDisconnected()
+			status = ""
+			err = ErrDockerNotConnected
+		}
+	}()
+	resp, err := d.client.RegistryLogin(d.ctx, auth)
+	if err != nil {
+		return "", d.handleError(err)
+	}
+	return resp.Status, nil
 }
 
 // BulkDeleteResult kết quả xóa nhiều images
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+var ErrSameServerTransfer = errors.New("source and target server must be different")
+
+// ImageTransferResult là kết quả chuyển image giữa hai server
+type ImageTransferResult struct {
+	SourceServerID string   `json:"sourceServerId"`
+	TargetServerID string   `json:"targetServerId"`
+	Images         []string `json:"images"` // image đã load trên server đích
+	Bytes          int64    `json:"bytes"`  // kích thước tar đã chuyển
+	DurationMs     int64    `json:"durationMs"`
+}
+
+// SaveImage export image dạng tar ("docker save") từ server (local và agent)
+func (m *ServerManager) SaveImage(serverID string, refs []string) (io.ReadCloser, error) {
+	if m.IsLocal(serverID) {
+		return m.localDocker.SaveImage(refs)
+	}
+
+	client := m.getAgentClient(serverID)
+	if client == nil {
+		return nil, ErrServerNotFound
+	}
+	return client.SaveImage(refs)
+}
+
+// LoadImage import tar của "docker save" vào server (local và agent) và trả về các image đã load
+func (m *ServerManager) LoadImage(serverID string, input io.Reader) ([]string, error) {
+	var (
+		reader io.ReadCloser
+		err    error
+	)
+	if m.IsLocal(serverID) {
+		reader, err = m.localDocker.LoadImage(input)
+	} else {
+		client := m.getAgentClient(serverID)
+		if client == nil {
+			return nil, ErrServerNotFound
+		}
+		reader, err = client.LoadImage(input)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return readLoadedImages(reader)
+}
+
+// readLoadedImages đọc output của "docker load" và lấy tên (hoặc ID) các image đã load
+func readLoadedImages(r io.Reader) ([]string, error) {
+	images := make([]string, 0)
+	err := DecodeProgress(r, func(msg ProgressMessage) error {
+		for _, line := range strings.Split(msg.Stream, "\n") {
+			line = strings.TrimSpace(line)
+			if ref, ok := strings.CutPrefix(line, "Loaded image: "); ok {
+				images = append(images, ref)
+			} else if id, ok := strings.CutPrefix(line, "Loaded image ID: "); ok {
+				images = append(images, id)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(images) == 0 {
+		return nil, errors.New("no image was loaded, the archive may be empty or invalid")
+	}
+	return images, nil
+}
+
+// TransferImage chuyển image từ server nguồn sang server đích: output "docker save" của nguồn
+// được stream thẳng vào "docker load" của đích nên không giữ cả tarball trong bộ nhớ hay trên đĩa.
+func (m *ServerManager) TransferImage(sourceServerID, targetServerID string, refs []string) (*ImageTransferResult, error) {
+	sourceServerID = normalizeServerID(sourceServerID)
+	targetServerID = normalizeServerID(targetServerID)
+	if sourceServerID == targetServerID {
+		return nil, ErrSameServerTransfer
+	}
+	if !m.IsLocal(targetServerID) && m.getAgentClient(targetServerID) == nil {
+		return nil, ErrServerNotFound
+	}
+
+	startedAt := time.Now()
+	source, err := m.SaveImage(sourceServerID, refs)
+	if err != nil {
+		return nil, fmt.Errorf("save on source server: %w", err)
+	}
+	defer source.Close()
+
+	counter := &countingReader{r: source}
+	images, err := m.LoadImage(targetServerID, counter)
+	if err != nil {
+		return nil, fmt.Errorf("load on target server: %w", err)
+	}
+
+	return &ImageTransferResult{
+		SourceServerID: sourceServerID,
+		TargetServerID: targetServerID,
+		Images:         images,
+		Bytes:          counter.n,
+		DurationMs:     time.Since(startedAt).Milliseconds(),
+	}, nil
+}
@@ -0,0 +1,457 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+)
+
+// ErrMigrationConflict được trả về khi server đích có container/volume trùng tên hoặc port đã bị dùng
+var ErrMigrationConflict = errors.New("migration blocked by conflicts on the target server")
+
+type MigrateOptions struct {
+	TargetServer  string `json:"targetServer"`  // để trống = local
+	StopSource    bool   `json:"stopSource"`    // dừng container nguồn trước khi copy volume và giữ dừng sau khi xong
+	DryRun        bool   `json:"dryRun"`        // chỉ trả về kế hoạch, không thay đổi gì
+	HealthTimeout int    `json:"healthTimeout"` // số giây chờ container mới healthy, mặc định 120
+}
+
+type MigrationImage struct {
+	ID       string `json:"id"`
+	Ref      string `json:"ref"` // image dùng để tạo container trên server đích
+	Size     int64  `json:"size"`
+	OnTarget bool   `json:"onTarget"` // server đích đã có image, không cần chuyển
+}
+
+type MigrationVolume struct {
+	Name        string `json:"name"` // rỗng với anonymous volume
+	Destination string `json:"destination"`
+	Driver      string `json:"driver"`
+	Bytes       int64  `json:"bytes,omitempty"` // dữ liệu đã copy, chỉ có sau khi migrate
+}
+
+type MigrationNetwork struct {
+	Name     string `json:"name"`
+	Driver   string `json:"driver"`
+	OnTarget bool   `json:"onTarget"`
+}
+
+// MigrationPlan mô tả những gì sẽ được chuyển sang server đích
+type MigrationPlan struct {
+	SourceServerID string             `json:"sourceServerId"`
+	TargetServerID string             `json:"targetServerId"`
+	ContainerID    string             `json:"containerId"`
+	Name           string             `json:"name"`
+	Running        bool               `json:"running"`
+	Image          MigrationImage     `json:"image"`
+	Volumes        []MigrationVolume  `json:"volumes"`
+	Networks       []MigrationNetwork `json:"networks"`
+	Ports          []string           `json:"ports"` // host port sẽ được publish trên server đích
+	Warnings       []string           `json:"warnings"`
+	Conflicts      []string           `json:"conflicts"` // khác rỗng thì không thể migrate
+}
+
+type MigrationResult struct {
+	*MigrationPlan
+	TargetContainerID string `json:"targetContainerId"`
+	ImageBytes        int64  `json:"imageBytes"` // 0 khi server đích đã có image
+	SourceStopped     bool   `json:"sourceStopped"`
+	DurationMs        int64  `json:"durationMs"`
+}
+
+// Label gắn cho container tạm dùng để ghi dữ liệu volume trên server đích
+const migrationStagingLabel = "appdock.migration.staging"
+
+// planMigration lập kế hoạch chuyển container sang server đích mà không thay đổi gì
+func (m *ServerManager) planMigration(sourceServerID, containerID string, opts MigrateOptions) (*MigrationPlan, *container.InspectResponse, error) {
+	sourceServerID = normalizeServerID(sourceServerID)
+	targetServerID := normalizeServerID(opts.TargetServer)
+	if sourceServerID == targetServerID {
+		return nil, nil, ErrSameServerTransfer
+	}
+	if err := m.TestConnection(targetServerID); err != nil {
+		return nil, nil, fmt.Errorf("target server: %w", err)
+	}
+
+	inspect, err := m.InspectContainer(sourceServerID, containerID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	plan := &MigrationPlan{
+		SourceServerID: sourceServerID,
+		TargetServerID: targetServerID,
+		ContainerID:    shortID(inspect.ID),
+		Name:           strings.TrimPrefix(inspect.Name, "/"),
+		Running:        inspect.State != nil && inspect.State.Running,
+		Volumes:        make([]MigrationVolume, 0),
+		Networks:       make([]MigrationNetwork, 0),
+		Ports:          make([]string, 0),
+		Warnings:       make([]string, 0),
+		Conflicts:      make([]string, 0),
+	}
+
+	if _, err := m.InspectContainer(targetServerID, plan.Name); err == nil {
+		plan.Conflicts = append(plan.Conflicts, fmt.Sprintf("container %q already exists on the target server", plan.Name))
+	}
+
+	if err := m.planMigrationImage(plan, inspect); err != nil {
+		return nil, nil, err
+	}
+	m.planMigrationVolumes(plan, inspect)
+	m.planMigrationNetworks(plan, inspect)
+	if err := m.planMigrationPorts(plan, inspect); err != nil {
+		return nil, nil, err
+	}
+
+	if plan.Running && !opts.StopSource {
+		plan.Warnings = append(plan.Warnings, "the source container keeps running, so both containers will run after the migration")
+		if len(plan.Volumes) > 0 {
+			plan.Warnings = append(plan.Warnings, "volume data is copied while the source container is running and may be inconsistent, use stopSource for databases")
+		}
+	}
+	return plan, inspect, nil
+}
+
+// planMigrationImage chọn image để tạo container trên server đích. Nếu tag của container
+// đã trỏ sang image khác trên server nguồn thì chuyển đúng image đang chạy theo ID.
+func (m *ServerManager) planMigrationImage(plan *MigrationPlan, inspect *container.InspectResponse) error {
+	source, err := m.GetImageDetails(plan.SourceServerID, inspect.Image)
+	if err != nil {
+		return fmt.Errorf("inspect image on source server: %w", err)
+	}
+	plan.Image = MigrationImage{
+		ID:   source.ID,
+		Ref:  source.ID,
+		Size: source.Size,
+	}
+	if imageHasTag(source.RepoTags, inspect.Config.Image) {
+		plan.Image.Ref = inspect.Config.Image
+	} else if !strings.HasPrefix(inspect.Config.Image, "sha256:") {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("tag %s no longer points to the image the container runs, the image is migrated by ID", inspect.Config.Image))
+	}
+
+	if target, err := m.GetImageDetails(plan.TargetServerID, plan.Image.Ref); err == nil {
+		if target.ID == source.ID {
+			plan.Image.OnTarget = true
+		} else {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("tag %s on the target server points to a different image and will be replaced", plan.Image.Ref))
+		}
+	}
+	return nil
+}
+
+// imageHasTag kiểm tra ref (e.g. "nginx" hoặc "docker.io/library/nginx:latest") có trong RepoTags
+func imageHasTag(repoTags []string, ref string) bool {
+	tagged := normalizeImageTag(ref)
+	if tagged == nil {
+		return false
+	}
+	for _, tag := range repoTags {
+		if other := normalizeImageTag(tag); other != nil && other.String() == tagged.String() {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *ServerManager) planMigrationVolumes(plan *MigrationPlan, inspect *container.InspectResponse) {
+	for _, mp := range inspect.Mounts {
+		switch mp.Type {
+		case mount.TypeVolume:
+			vol := MigrationVolume{Destination: mp.Destination, Driver: mp.Driver}
+			if isNamedVolume(inspect.HostConfig, mp.Name) {
+				vol.Name = mp.Name
+				if _, err := m.GetVolume(plan.TargetServerID, mp.Name); err == nil {
+					plan.Conflicts = append(plan.Conflicts, fmt.Sprintf("volume %q already exists on the target server", mp.Name))
+				}
+			}
+			if mp.Driver != "" && mp.Driver != "local" {
+				plan.Warnings = append(plan.Warnings, fmt.Sprintf("volume %s uses driver %s, which must be installed on the target server", mp.Destination, mp.Driver))
+			}
+			plan.Volumes = append(plan.Volumes, vol)
+		case mount.TypeBind:
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("bind mount %s -> %s is not copied, the host path must exist on the target server", mp.Source, mp.Destination))
+		}
+	}
+}
+
+// isNamedVolume phân biệt volume được đặt tên trong HostConfig với anonymous volume của image
+func isNamedVolume(hostConfig *container.HostConfig, name string) bool {
+	if hostConfig == nil || name == "" {
+		return false
+	}
+	for _, bind := range hostConfig.Binds {
+		if strings.HasPrefix(bind, name+":") {
+			return true
+		}
+	}
+	for _, mnt := range hostConfig.Mounts {
+		if mnt.Type == mount.TypeVolume && mnt.Source == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *ServerManager) planMigrationNetworks(plan *MigrationPlan, inspect *container.InspectResponse) {
+	if inspect.HostConfig != nil && inspect.HostConfig.NetworkMode.IsContainer() {
+		plan.Conflicts = append(plan.Conflicts, "containers sharing the network namespace of another container cannot be migrated")
+		return
+	}
+	if inspect.NetworkSettings == nil {
+		return
+	}
+
+	for name := range inspect.NetworkSettings.Networks {
+		if isPredefinedNetwork(name) {
+			continue
+		}
+		item := MigrationNetwork{Name: name}
+		var info NetworkInfo
+		if raw, err := m.GetNetwork(plan.SourceServerID, name); err == nil && decodeJSON(raw, &info) == nil {
+			item.Driver = info.Driver
+		}
+		if _, err := m.GetNetwork(plan.TargetServerID, name); err == nil {
+			item.OnTarget = true
+		} else if item.Driver != "" && item.Driver != "bridge" {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("network %s uses driver %s and is created on the target server with the same driver", name, item.Driver))
+		}
+		plan.Networks = append(plan.Networks, item)
+	}
+}
+
+// isPredefinedNetwork trả về true với network có sẵn trên mọi Docker host
+func isPredefinedNetwork(name string) bool {
+	switch name {
+	case network.NetworkDefault, network.NetworkBridge, network.NetworkHost, network.NetworkNone:
+		return true
+	}
+	return false
+}
+
+// planMigrationPorts liệt kê host port và báo xung đột với container đang chạy trên server đích
+func (m *ServerManager) planMigrationPorts(plan *MigrationPlan, inspect *container.InspectResponse) error {
+	if inspect.HostConfig == nil || len(inspect.HostConfig.PortBindings) == 0 {
+		return nil
+	}
+
+	running, err := m.listContainerInfos(plan.TargetServerID, false)
+	if err != nil {
+		return fmt.Errorf("list containers on target server: %w", err)
+	}
+	used := make(map[string]string)
+	for _, c := range running {
+		for _, p := range c.Ports {
+			if p.PublicPort != 0 {
+				used[fmt.Sprintf("%d/%s", p.PublicPort, p.Type)] = c.Name
+			}
+		}
+	}
+
+	for port, bindings := range inspect.HostConfig.PortBindings {
+		for _, binding := range bindings {
+			if binding.HostPort == "" {
+				continue
+			}
+			hostPort := binding.HostPort + "/" + port.Proto()
+			plan.Ports = append(plan.Ports, hostPort)
+			if name, ok := used[hostPort]; ok && plan.Running {
+				plan.Conflicts = append(plan.Conflicts, fmt.Sprintf("port %s is already published by container %s on the target server", hostPort, name))
+			}
+		}
+	}
+	return nil
+}
+
+// MigrateContainer chuyển container sang server khác: image được stream bằng save/load,
+// volume được copy qua archive API từ server nguồn sang server đích mà không lưu tạm.
+// Quy trình: transfer image -> tạo network/volume -> create container -> (stop nguồn) ->
+// copy volume -> start. Lỗi ở bất kỳ bước nào sẽ xóa những gì đã tạo trên server đích
+// và start lại container nguồn nếu đã dừng nó.
+func (m *ServerManager) MigrateContainer(sourceServerID, containerID string, opts MigrateOptions) (*MigrationResult, error) {
+	startedAt := time.Now()
+	plan, inspect, err := m.planMigration(sourceServerID, containerID, opts)
+	if err != nil {
+		return nil, err
+	}
+	result := &MigrationResult{MigrationPlan: plan}
+	if opts.DryRun {
+		return result, nil
+	}
+	if len(plan.Conflicts) > 0 {
+		return result, fmt.Errorf("%w: %s", ErrMigrationConflict, strings.Join(plan.Conflicts, "; "))
+	}
+
+	source, target := plan.SourceServerID, plan.TargetServerID
+	var createdNetworks, createdVolumes []string
+	targetContainerID := ""
+	fail := func(step string, err error) (*MigrationResult, error) {
+		if targetContainerID != "" {
+			m.RemoveContainer(target, targetContainerID, true)
+		}
+		for _, name := range createdVolumes {
+			m.RemoveVolume(target, name, true)
+		}
+		for _, name := range createdNetworks {
+			m.RemoveNetwork(target, name)
+		}
+		if result.SourceStopped {
+			m.StartContainer(source, inspect.ID)
+			result.SourceStopped = false
+		}
+		return result, fmt.Errorf("%s: %w", step, err)
+	}
+
+	if !plan.Image.OnTarget {
+		transfer, err := m.TransferImage(source, target, []string{plan.Image.Ref})
+		if err != nil {
+			return fail("transfer image", err)
+		}
+		result.ImageBytes = transfer.Bytes
+	}
+
+	for _, n := range plan.Networks {
+		if n.OnTarget {
+			continue
+		}
+		if _, err := m.CreateNetwork(target, CreateNetworkRequest{Name: n.Name, Driver: n.Driver}); err != nil {
+			return fail("create network "+n.Name, err)
+		}
+		createdNetworks = append(createdNetworks, n.Name)
+	}
+
+	for _, v := range plan.Volumes {
+		if v.Name == "" {
+			continue
+		}
+		req := CreateVolumeRequest{Name: v.Name, Driver: v.Driver}
+		var info VolumeInfo
+		if raw, err := m.GetVolume(source, v.Name); err == nil && decodeJSON(raw, &info) == nil {
+			req.Labels = info.Labels
+		}
+		if _, err := m.CreateVolume(target, req); err != nil {
+			return fail("create volume "+v.Name, err)
+		}
+		createdVolumes = append(createdVolumes, v.Name)
+	}
+
+	// Cùng một image trên server đích nên giữ nguyên cấu hình kế thừa từ image
+	spec, err := SpecFromInspect(inspect, nil)
+	if err != nil {
+		return fail("create container", err)
+	}
+	spec.Config.Image = plan.Image.Ref
+	created, err := m.CreateContainerFromSpec(target, spec)
+	if err != nil {
+		return fail("create container", err)
+	}
+	targetContainerID = created.ID
+	result.TargetContainerID = created.ID
+
+	if opts.StopSource && plan.Running {
+		if err := m.StopContainer(source, inspect.ID, nil); err != nil {
+			return fail("stop source container", err)
+		}
+		result.SourceStopped = true
+	}
+
+	if len(plan.Volumes) > 0 {
+		targetInspect, err := m.InspectContainer(target, targetContainerID)
+		if err != nil {
+			return fail("inspect target container", err)
+		}
+		// Anonymous volume được tạo cùng container mới, cần xóa khi rollback
+		for _, mp := range targetInspect.Mounts {
+			if mp.Type == mount.TypeVolume && !isNamedVolume(targetInspect.HostConfig, mp.Name) {
+				createdVolumes = append(createdVolumes, mp.Name)
+			}
+		}
+		if err := m.copyMigrationVolumes(plan, inspect.ID, targetInspect); err != nil {
+			return fail("copy volumes", err)
+		}
+	}
+
+	if plan.Running {
+		healthTimeout := defaultRecreateHealthTimeout
+		if opts.HealthTimeout > 0 {
+			healthTimeout = time.Duration(opts.HealthTimeout) * time.Second
+		}
+		if err := m.startAndVerify(target, targetContainerID, healthTimeout); err != nil {
+			return fail("start target container", err)
+		}
+	}
+
+	result.DurationMs = time.Since(startedAt).Milliseconds()
+	return result, nil
+}
+
+// copyMigrationVolumes stream dữ liệu từng volume từ container nguồn sang volume tương ứng
+// trên server đích. Dữ liệu được ghi qua một container tạm mount các volume ở chế độ
+// read-write, vì container đích có thể mount volume read-only hoặc có rootfs read-only.
+func (m *ServerManager) copyMigrationVolumes(plan *MigrationPlan, sourceContainerID string, target *container.InspectResponse) error {
+	targetVolumes := make(map[string]string, len(target.Mounts))
+	for _, mp := range target.Mounts {
+		if mp.Type == mount.TypeVolume {
+			targetVolumes[mp.Destination] = mp.Name
+		}
+	}
+
+	mounts := make([]mount.Mount, 0, len(plan.Volumes))
+	for _, v := range plan.Volumes {
+		name, ok := targetVolumes[v.Destination]
+		if !ok {
+			return fmt.Errorf("volume for %s not found on target container", v.Destination)
+		}
+		mounts = append(mounts, mount.Mount{Type: mount.TypeVolume, Source: name, Target: v.Destination})
+	}
+
+	staging, err := m.CreateContainerFromSpec(plan.TargetServerID, &ContainerSpec{
+		Config: &container.Config{
+			Image:      plan.Image.Ref,
+			Entrypoint: []string{"true"}, // không bao giờ được start
+			Labels:     map[string]string{migrationStagingLabel: plan.Name},
+		},
+		HostConfig: &container.HostConfig{
+			NetworkMode: network.NetworkNone,
+			Mounts:      mounts,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("create staging container: %w", err)
+	}
+	defer m.RemoveContainer(plan.TargetServerID, staging.ID, true)
+
+	for i := range plan.Volumes {
+		v := &plan.Volumes[i]
+		reader, _, err := m.CopyFromContainer(plan.SourceServerID, sourceContainerID, v.Destination)
+		if err != nil {
+			return fmt.Errorf("read %s: %w", v.Destination, err)
+		}
+		// Entry trong tar mang tên thư mục của volume nên giải nén vào thư mục cha
+		counter := &countingReader{r: reader}
+		err = m.CopyToContainer(plan.TargetServerID, staging.ID, path.Dir(v.Destination), counter, false)
+		reader.Close()
+		if err != nil {
+			return fmt.Errorf("write %s: %w", v.Destination, err)
+		}
+		v.Bytes = counter.n
+	}
+	return nil
+}
+
+// decodeJSON chuyển kết quả dạng interface{} (struct local hoặc JSON từ agent) sang struct
+func decodeJSON(value interface{}, out interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
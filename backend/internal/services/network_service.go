@@ -141,10 +141,11 @@ func (d *DockerService) GetNetwork(id string) (result *NetworkInfo, err error) {
 }
 
 type CreateNetworkRequest struct {
-	Name       string `json:"name"`
-	Driver     string `json:"driver"`
-	Internal   bool   `json:"internal"`
-	Attachable bool   `json:"attachable"`
+	Name       string            `json:"name"`
+	Driver     string            `json:"driver"`
+	Internal   bool              `json:"internal"`
+	Attachable bool              `json:"attachable"`
+	Labels     map[string]string `json:"labels"`
 }
 
 func (d *DockerService) CreateNetwork(req CreateNetworkRequest) (result string, err error) {
@@ -167,6 +168,7 @@ func (d *DockerService) CreateNetwork(req CreateNetworkRequest) (result string,
 		Driver:     driver,
 		Internal:   req.Internal,
 		Attachable: req.Attachable,
+		Labels:     req.Labels,
 	})
 	if err != nil {
 		return "", d.handleError(err)
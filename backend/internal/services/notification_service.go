@@ -0,0 +1,36 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+var webhookClient = &http.Client{Timeout: 15 * time.Second}
+
+// SendWebhook gửi thông báo dạng JSON tới webhook. Payload có cả "text" (Slack, Mattermost)
+// và "content" (Discord) để dùng trực tiếp với các dịch vụ chat, data là chi tiết sự kiện.
+func SendWebhook(url, event, text string, data interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"event":   event,
+		"text":    text,
+		"content": text,
+		"data":    data,
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := webhookClient.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/docker/docker/api/types/build"
+	"github.com/docker/docker/api/types/filters"
+)
+
+// PruneOptions chọn loại tài nguyên cần dọn
+type PruneOptions struct {
+	Containers bool `json:"containers"` // container đã dừng
+	Images     bool `json:"images"`     // image dangling (<none>)
+	AllImages  bool `json:"allImages"`  // mọi image không được container nào dùng
+	BuildCache bool `json:"buildCache"`
+	Networks   bool `json:"networks"` // network không được dùng
+	Volumes    bool `json:"volumes"`  // volume ẩn danh không được dùng
+	AllVolumes bool `json:"allVolumes"`
+}
+
+// PruneReport kết quả dọn một loại tài nguyên
+type PruneReport struct {
+	Deleted        []string `json:"deleted"`
+	SpaceReclaimed uint64   `json:"spaceReclaimed"`
+	Error          string   `json:"error,omitempty"`
+}
+
+type PruneResult struct {
+	Containers     *PruneReport `json:"containers,omitempty"`
+	Images         *PruneReport `json:"images,omitempty"`
+	BuildCache     *PruneReport `json:"buildCache,omitempty"`
+	Networks       *PruneReport `json:"networks,omitempty"`
+	Volumes        *PruneReport `json:"volumes,omitempty"`
+	SpaceReclaimed uint64       `json:"spaceReclaimed"` // tổng dung lượng thu hồi (bytes)
+}
+
+// ServerPruneResult kết quả prune của một server khi dọn toàn bộ fleet
+type ServerPruneResult struct {
+	ServerID   string       `json:"serverId"`
+	ServerName string       `json:"serverName"`
+	Result     *PruneResult `json:"result,omitempty"`
+	Error      string       `json:"error,omitempty"`
+}
+
+// IsEmpty cho biết không có loại tài nguyên nào được chọn
+func (o PruneOptions) IsEmpty() bool {
+	return !o.Containers && !o.Images && !o.AllImages && !o.BuildCache && !o.Networks && !o.Volumes && !o.AllVolumes
+}
+
+// Prune dọn tài nguyên không dùng. Container được dọn trước để image/network/volume
+// của chúng cũng được giải phóng. Lỗi của từng loại được ghi vào report tương ứng.
+func (d *DockerService) Prune(opts PruneOptions) (result *PruneResult, err error) {
+	if !d.IsConnected() {
+		return nil, ErrDockerNotConnected
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			d.markDisconnected()
+			result = nil
+			err = ErrDockerNotConnected
+		}
+	}()
+	result = &PruneResult{}
+
+	if opts.Containers {
+		report := &PruneReport{Deleted: make([]string, 0)}
+		resp, err := d.client.ContainersPrune(d.ctx, filters.NewArgs())
+		if err != nil {
+			report.Error = err.Error()
+		} else {
+			for _, id := range resp.ContainersDeleted {
+				report.Deleted = append(report.Deleted, shortID(id))
+			}
+			report.SpaceReclaimed = resp.SpaceReclaimed
+		}
+		result.Containers = report
+	}
+
+	if opts.Images || opts.AllImages {
+		report := &PruneReport{Deleted: make([]string, 0)}
+		args := filters.NewArgs()
+		if opts.AllImages {
+			args.Add("dangling", "false")
+		}
+		resp, err := d.client.ImagesPrune(d.ctx, args)
+		if err != nil {
+			report.Error = err.Error()
+		} else {
+			for _, item := range resp.ImagesDeleted {
+				if item.Deleted != "" {
+					report.Deleted = append(report.Deleted, item.Deleted)
+				} else if item.Untagged != "" {
+					report.Deleted = append(report.Deleted, item.Untagged)
+				}
+			}
+			report.SpaceReclaimed = resp.SpaceReclaimed
+		}
+		result.Images = report
+	}
+
+	if opts.Networks {
+		report := &PruneReport{Deleted: make([]string, 0)}
+		resp, err := d.client.NetworksPrune(d.ctx, filters.NewArgs())
+		if err != nil {
+			report.Error = err.Error()
+		} else {
+			report.Deleted = append(report.Deleted, resp.NetworksDeleted...)
+		}
+		result.Networks = report
+	}
+
+	if opts.Volumes || opts.AllVolumes {
+		report := &PruneReport{Deleted: make([]string, 0)}
+		args := filters.NewArgs()
+		if opts.AllVolumes {
+			args.Add("all", "true")
+		}
+		resp, err := d.client.VolumesPrune(d.ctx, args)
+		if err != nil {
+			report.Error = err.Error()
+		} else {
+			report.Deleted = append(report.Deleted, resp.VolumesDeleted...)
+			report.SpaceReclaimed = resp.SpaceReclaimed
+		}
+		result.Volumes = report
+	}
+
+	if opts.BuildCache {
+		report := &PruneReport{Deleted: make([]string, 0)}
+		resp, err := d.client.BuildCachePrune(d.ctx, build.CachePruneOptions{All: opts.AllImages})
+		if err != nil {
+			report.Error = err.Error()
+		} else {
+			report.Deleted = append(report.Deleted, resp.CachesDeleted...)
+			report.SpaceReclaimed = resp.SpaceReclaimed
+		}
+		result.BuildCache = report
+	}
+
+	result.sumSpace()
+	return result, nil
+}
+
+func (r *PruneResult) sumSpace() {
+	r.SpaceReclaimed = 0
+	for _, report := range []*PruneReport{r.Containers, r.Images, r.BuildCache, r.Networks, r.Volumes} {
+		if report != nil {
+			r.SpaceReclaimed += report.SpaceReclaimed
+		}
+	}
+}
+
+// Prune dọn tài nguyên không dùng trên một server
+func (m *ServerManager) Prune(serverID string, opts PruneOptions) (*PruneResult, error) {
+	if m.IsLocal(serverID) {
+		return m.localDocker.Prune(opts)
+	}
+
+	client := m.getAgentClient(serverID)
+	if client == nil {
+		return nil, ErrServerNotFound
+	}
+
+	data, err := client.Prune(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var result PruneResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// PruneAllServers chạy prune song song trên mọi server đã đăng ký
+func (m *ServerManager) PruneAllServers(opts PruneOptions) []ServerPruneResult {
+	servers := m.store.List()
+	results := make([]ServerPruneResult, len(servers))
+
+	var wg sync.WaitGroup
+	for i, server := range servers {
+		wg.Add(1)
+		go func(i int, serverID, name string) {
+			defer wg.Done()
+			item := ServerPruneResult{ServerID: serverID, ServerName: name}
+			result, err := m.Prune(serverID, opts)
+			if err != nil {
+				item.Error = err.Error()
+			} else {
+				item.Result = result
+			}
+			results[i] = item
+		}(i, server.ID, server.Name)
+	}
+	wg.Wait()
+
+	return results
+}
@@ -0,0 +1,231 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/distribution/reference"
+	"github.com/docker/docker/api/types/registry"
+)
+
+// Media types được chấp nhận khi hỏi digest, index/manifest list đứng trước
+// để digest trùng với RepoDigests mà Docker lưu khi pull theo tag
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+}
+
+// RegistryClient gọi Registry HTTP API v2 để lấy digest của tag mà không cần pull
+type RegistryClient struct {
+	client   *http.Client
+	insecure map[string]bool // registry dùng HTTP thay vì HTTPS
+}
+
+// NewRegistryClient tạo client; registry trong APPDOCK_INSECURE_REGISTRIES (phân cách bằng dấu phẩy)
+// và registry trên localhost được gọi qua HTTP, giống quy tắc insecure registry của Docker
+func NewRegistryClient() *RegistryClient {
+	insecure := make(map[string]bool)
+	for _, host := range strings.Split(os.Getenv("APPDOCK_INSECURE_REGISTRIES"), ",") {
+		if host = NormalizeRegistryHost(host); host != "" {
+			insecure[host] = true
+		}
+	}
+	return &RegistryClient{
+		client:   &http.Client{Timeout: 30 * time.Second},
+		insecure: insecure,
+	}
+}
+
+// ManifestDigest trả về digest hiện tại của tag trên registry (Docker-Content-Digest).
+// auth có thể nil với registry public.
+func (c *RegistryClient) ManifestDigest(ref reference.NamedTagged, auth *registry.AuthConfig) (string, error) {
+	manifestURL := fmt.Sprintf("%s/v2/%s/manifests/%s", c.baseURL(reference.Domain(ref)), reference.Path(ref), ref.Tag())
+
+	resp, err := c.manifestRequest(http.MethodHead, manifestURL, "", auth)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	authorization := ""
+	if resp.StatusCode == http.StatusUnauthorized {
+		authorization, err = c.authorize(resp.Header.Get("WWW-Authenticate"), auth)
+		if err != nil {
+			return "", err
+		}
+		resp, err = c.manifestRequest(http.MethodHead, manifestURL, authorization, auth)
+		if err != nil {
+			return "", err
+		}
+		resp.Body.Close()
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		if digest := resp.Header.Get("Docker-Content-Digest"); digest != "" {
+			return digest, nil
+		}
+		// Một số registry không trả digest cho HEAD, khi đó tự tính từ nội dung manifest
+		return c.manifestBodyDigest(manifestURL, authorization, auth)
+	case http.StatusNotFound:
+		return "", fmt.Errorf("tag %s not found in registry", ref.Tag())
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return "", errors.New("registry denied access, check the saved credentials for " + reference.Domain(ref))
+	}
+	return "", fmt.Errorf("registry returned status %d", resp.StatusCode)
+}
+
+func (c *RegistryClient) baseURL(domain string) string {
+	host := NormalizeRegistryHost(domain)
+	if host == dockerHubHost {
+		return "https://registry-1.docker.io"
+	}
+	if c.insecure[host] || isLoopbackHost(host) {
+		return "http://" + host
+	}
+	return "https://" + host
+}
+
+func isLoopbackHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// manifestRequest gửi request manifest, authorization rỗng thì dùng basic auth (nếu có)
+func (c *RegistryClient) manifestRequest(method, manifestURL, authorization string, auth *registry.AuthConfig) (*http.Response, error) {
+	req, err := http.NewRequest(method, manifestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	} else if auth != nil && auth.Username != "" {
+		req.SetBasicAuth(auth.Username, auth.Password)
+	}
+	return c.client.Do(req)
+}
+
+func (c *RegistryClient) manifestBodyDigest(manifestURL, authorization string, auth *registry.AuthConfig) (string, error) {
+	resp, err := c.manifestRequest(http.MethodGet, manifestURL, authorization, auth)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("registry returned status %d", resp.StatusCode)
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, io.LimitReader(resp.Body, 4<<20)); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("sha256:%x", hash.Sum(nil)), nil
+}
+
+// authorize xử lý challenge của registry: Bearer (lấy token từ auth server) hoặc Basic
+func (c *RegistryClient) authorize(challenge string, auth *registry.AuthConfig) (string, error) {
+	scheme, params := parseAuthChallenge(challenge)
+	switch scheme {
+	case "basic":
+		if auth == nil || auth.Username == "" {
+			return "", errors.New("registry requires credentials")
+		}
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(auth.Username+":"+auth.Password)), nil
+	case "bearer":
+		token, err := c.fetchToken(params, auth)
+		if err != nil {
+			return "", err
+		}
+		return "Bearer " + token, nil
+	}
+	return "", fmt.Errorf("unsupported registry auth challenge: %q", challenge)
+}
+
+func (c *RegistryClient) fetchToken(params map[string]string, auth *registry.AuthConfig) (string, error) {
+	realm, err := url.Parse(params["realm"])
+	if err != nil || realm.Host == "" {
+		return "", errors.New("invalid token realm in registry challenge")
+	}
+	query := realm.Query()
+	if service := params["service"]; service != "" {
+		query.Set("service", service)
+	}
+	if scope := params["scope"]; scope != "" {
+		query.Set("scope", scope)
+	}
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if auth != nil && auth.Username != "" {
+		req.SetBasicAuth(auth.Username, auth.Password)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("registry token request failed with status %d", resp.StatusCode)
+	}
+
+	var tokenResp struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return "", err
+	}
+	if tokenResp.Token != "" {
+		return tokenResp.Token, nil
+	}
+	if tokenResp.AccessToken != "" {
+		return tokenResp.AccessToken, nil
+	}
+	return "", errors.New("registry token response contains no token")
+}
+
+// parseAuthChallenge tách header WWW-Authenticate: Bearer realm="...",service="...",scope="..."
+func parseAuthChallenge(header string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+	params := make(map[string]string)
+	for rest != "" {
+		var key, value string
+		key, rest, _ = strings.Cut(strings.TrimLeft(rest, " ,"), "=")
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				value, rest = rest[1:], ""
+			} else {
+				value, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+		if key = strings.ToLower(strings.TrimSpace(key)); key != "" {
+			params[key] = value
+		}
+	}
+	return strings.ToLower(scheme), params
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"appdock/internal/models"
+
+	"github.com/distribution/reference"
+	"github.com/docker/docker/api/types/registry"
+)
+
+const (
+	testRegistryUser  = "deploy"
+	testRegistryPass  = "s3cret"
+	testRegistryToken = "token-123"
+)
+
+// fakeRegistry là registry v2 tối thiểu: manifest yêu cầu Bearer token lấy từ /token bằng basic auth
+type fakeRegistry struct {
+	*httptest.Server
+
+	mu            sync.Mutex
+	digest        string // Docker-Content-Digest của team/app:1.0
+	tokenRequests int
+	lastScope     string
+}
+
+func newFakeRegistry(t *testing.T, digest string) *fakeRegistry {
+	t.Helper()
+	r := &fakeRegistry{digest: digest}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, req *http.Request) {
+		r.mu.Lock()
+		r.tokenRequests++
+		r.lastScope = req.URL.Query().Get("scope")
+		r.mu.Unlock()
+
+		user, pass, ok := req.BasicAuth()
+		if !ok || user != testRegistryUser || pass != testRegistryPass {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if req.URL.Query().Get("service") != "fake-registry" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"token": %q}`, testRegistryToken)
+	})
+	mux.HandleFunc("/v2/team/app/manifests/", func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer "+testRegistryToken {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(
+				`Bearer realm="%s/token",service="fake-registry",scope="repository:team/app:pull"`, r.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if !strings.Contains(req.Header.Get("Accept"), "application/vnd.oci.image.index.v1+json") {
+			w.WriteHeader(http.StatusNotAcceptable)
+			return
+		}
+
+		switch strings.TrimPrefix(req.URL.Path, "/v2/team/app/manifests/") {
+		case "1.0":
+			r.mu.Lock()
+			w.Header().Set("Docker-Content-Digest", r.digest)
+			r.mu.Unlock()
+		case "no-digest":
+			// Registry không trả digest cho HEAD, nội dung manifest chỉ có ở GET
+			if req.Method == http.MethodGet {
+				w.Write([]byte(`{"schemaVersion": 2}`))
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	r.Server = httptest.NewServer(mux)
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *fakeRegistry) host() string {
+	return r.Listener.Addr().String()
+}
+
+func (r *fakeRegistry) setDigest(digest string) {
+	r.mu.Lock()
+	r.digest = digest
+	r.mu.Unlock()
+}
+
+func (r *fakeRegistry) ref(t *testing.T, tag string) reference.NamedTagged {
+	t.Helper()
+	ref := normalizeImageTag(r.host() + "/team/app:" + tag)
+	if ref == nil {
+		t.Fatalf("cannot parse image reference for tag %s", tag)
+	}
+	return ref
+}
+
+func TestRegistryClientManifestDigestBearer(t *testing.T) {
+	const digest = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	reg := newFakeRegistry(t, digest)
+	client := NewRegistryClient()
+	auth := &registry.AuthConfig{Username: testRegistryUser, Password: testRegistryPass}
+
+	got, err := client.ManifestDigest(reg.ref(t, "1.0"), auth)
+	if err != nil {
+		t.Fatalf("ManifestDigest: %v", err)
+	}
+	if got != digest {
+		t.Errorf("digest = %q, want %q", got, digest)
+	}
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	if reg.tokenRequests != 1 {
+		t.Errorf("token requests = %d, want 1", reg.tokenRequests)
+	}
+	if reg.lastScope != "repository:team/app:pull" {
+		t.Errorf("token scope = %q, want repository:team/app:pull", reg.lastScope)
+	}
+}
+
+func TestRegistryClientManifestDigestFromBody(t *testing.T) {
+	reg := newFakeRegistry(t, "")
+	client := NewRegistryClient()
+	auth := &registry.AuthConfig{Username: testRegistryUser, Password: testRegistryPass}
+
+	got, err := client.ManifestDigest(reg.ref(t, "no-digest"), auth)
+	if err != nil {
+		t.Fatalf("ManifestDigest: %v", err)
+	}
+	want := fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(`{"schemaVersion": 2}`)))
+	if got != want {
+		t.Errorf("digest = %q, want %q", got, want)
+	}
+}
+
+func TestRegistryClientManifestDigestErrors(t *testing.T) {
+	reg := newFakeRegistry(t, "sha256:1111111111111111111111111111111111111111111111111111111111111111")
+	client := NewRegistryClient()
+
+	if _, err := client.ManifestDigest(reg.ref(t, "1.0"), nil); err == nil {
+		t.Error("expected an error without credentials")
+	}
+	wrong := &registry.AuthConfig{Username: testRegistryUser, Password: "wrong"}
+	if _, err := client.ManifestDigest(reg.ref(t, "1.0"), wrong); err == nil {
+		t.Error("expected an error with wrong credentials")
+	}
+	auth := &registry.AuthConfig{Username: testRegistryUser, Password: testRegistryPass}
+	if _, err := client.ManifestDigest(reg.ref(t, "missing"), auth); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("missing tag error = %v, want not found", err)
+	}
+}
+
+func TestUpdateCheckerCompareDigests(t *testing.T) {
+	const (
+		oldDigest = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+		newDigest = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+	)
+	reg := newFakeRegistry(t, oldDigest)
+
+	// Credentials lưu trong registry store được dùng khi lấy token
+	t.Setenv("APPDOCK_SECRET_KEY", "test-secret")
+	store, err := NewRegistryStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Create(models.CreateRegistryRequest{
+		Name:     "fake",
+		Host:     reg.host(),
+		Username: testRegistryUser,
+		Password: testRegistryPass,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	checker := &UpdateChecker{
+		serverManager: &ServerManager{registries: store},
+		registry:      NewRegistryClient(),
+	}
+
+	ref := reg.ref(t, "1.0")
+	img := &ImageInfo{RepoDigests: []string{reg.host() + "/team/app@" + oldDigest}}
+
+	var update ImageUpdate
+	checker.compareDigests(&update, ref, img, newDigestCache())
+	if update.Error != "" {
+		t.Fatalf("unexpected error: %s", update.Error)
+	}
+	if update.UpdateAvailable {
+		t.Error("update flagged although the registry digest is unchanged")
+	}
+	if update.LocalDigest != oldDigest || update.RemoteDigest != oldDigest {
+		t.Errorf("digests = (%s, %s), want both %s", update.LocalDigest, update.RemoteDigest, oldDigest)
+	}
+
+	reg.setDigest(newDigest)
+	update = ImageUpdate{}
+	checker.compareDigests(&update, ref, img, newDigestCache())
+	if update.Error != "" {
+		t.Fatalf("unexpected error: %s", update.Error)
+	}
+	if !update.UpdateAvailable {
+		t.Error("update not flagged although the registry digest changed")
+	}
+	if update.LocalDigest != oldDigest || update.RemoteDigest != newDigest {
+		t.Errorf("digests = (%s, %s), want (%s, %s)", update.LocalDigest, update.RemoteDigest, oldDigest, newDigest)
+	}
+
+	// Image build local không có RepoDigests thì không hỏi registry
+	update = ImageUpdate{}
+	checker.compareDigests(&update, ref, &ImageInfo{}, newDigestCache())
+	if update.Error == "" || update.UpdateAvailable {
+		t.Errorf("image without digest = %+v, want an error and no update", update)
+	}
+}
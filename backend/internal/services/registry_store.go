@@ -0,0 +1,364 @@
+package services
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"appdock/internal/models"
+
+	"github.com/distribution/reference"
+	"github.com/docker/docker/api/types/registry"
+)
+
+var (
+	ErrRegistryNotFound      = errors.New("registry not found")
+	ErrRegistryAlreadyExists = errors.New("registry already exists for this host")
+)
+
+// Docker Hub có nhiều hostname, tất cả được quy về docker.io.
+// Khi đăng nhập, Docker Hub dùng địa chỉ index cũ.
+const (
+	dockerHubHost       = "docker.io"
+	dockerHubAuthServer = "https://index.docker.io/v1/"
+)
+
+// RegistryStore lưu thông tin đăng nhập registry trong registries.json.
+// Password được mã hóa AES-GCM; key lấy từ APPDOCK_SECRET_KEY hoặc tự sinh tại secret.key.
+type RegistryStore struct {
+	registries map[string]*models.Registry
+	// Registry không giải mã được password (sai key), được giữ nguyên khi ghi file
+	undecryptable []models.Registry
+	filePath      string
+	gcm           cipher.AEAD
+	mu            sync.RWMutex
+}
+
+func NewRegistryStore(dataDir string) (*RegistryStore, error) {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, err
+	}
+
+	key, err := loadSecretKey(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	store := &RegistryStore{
+		registries: make(map[string]*models.Registry),
+		filePath:   filepath.Join(dataDir, "registries.json"),
+		gcm:        gcm,
+	}
+
+	if err := store.load(); err != nil {
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	return store, nil
+}
+
+// loadSecretKey trả về key 32 bytes dùng để mã hóa dữ liệu nhạy cảm trong data dir
+func loadSecretKey(dataDir string) ([]byte, error) {
+	if secret := os.Getenv("APPDOCK_SECRET_KEY"); secret != "" {
+		sum := sha256.Sum256([]byte(secret))
+		return sum[:], nil
+	}
+
+	keyPath := filepath.Join(dataDir, "secret.key")
+	if data, err := os.ReadFile(keyPath); err == nil {
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil || len(key) != 32 {
+			return nil, errors.New("invalid secret key in " + keyPath)
+		}
+		return key, nil
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(keyPath, []byte(base64.StdEncoding.EncodeToString(key)), 0600); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func (s *RegistryStore) encrypt(plaintext string) (string, error) {
+	nonce := make([]byte, s.gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := s.gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (s *RegistryStore) decrypt(ciphertext string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < s.gcm.NonceSize() {
+		return "", errors.New("encrypted value is too short")
+	}
+	nonce, sealed := data[:s.gcm.NonceSize()], data[s.gcm.NonceSize():]
+	plaintext, err := s.gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", errors.New("cannot decrypt registry password, check APPDOCK_SECRET_KEY")
+	}
+	return string(plaintext), nil
+}
+
+func (s *RegistryStore) load() error {
+	data, err := os.ReadFile(s.filePath)
+	if err != nil {
+		return err
+	}
+
+	var registries []*models.Registry
+	if err := json.Unmarshal(data, &registries); err != nil {
+		return err
+	}
+
+	s.registries = make(map[string]*models.Registry)
+	s.undecryptable = nil
+	for _, reg := range registries {
+		password, err := s.decrypt(reg.Password)
+		if err != nil {
+			log.Printf("Skipping registry %s (%s): %v", reg.Name, reg.Host, err)
+			s.undecryptable = append(s.undecryptable, *reg)
+			continue
+		}
+		reg.Password = password
+		s.registries[reg.ID] = reg
+	}
+
+	return nil
+}
+
+func (s *RegistryStore) save() error {
+	registries := make([]models.Registry, 0, len(s.registries)+len(s.undecryptable))
+	registries = append(registries, s.undecryptable...)
+	for _, reg := range s.registries {
+		encrypted, err := s.encrypt(reg.Password)
+		if err != nil {
+			return err
+		}
+		stored := *reg
+		stored.Password = encrypted
+		registries = append(registries, stored)
+	}
+
+	data, err := json.MarshalIndent(registries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.filePath, data, 0600)
+}
+
+func (s *RegistryStore) List() []*models.Registry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	registries := make([]*models.Registry, 0, len(s.registries))
+	for _, reg := range s.registries {
+		registries = append(registries, reg)
+	}
+
+	return registries
+}
+
+func (s *RegistryStore) Get(id string) (*models.Registry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	reg, exists := s.registries[id]
+	if !exists {
+		return nil, ErrRegistryNotFound
+	}
+
+	return reg, nil
+}
+
+func (s *RegistryStore) Create(req models.CreateRegistryRequest) (*models.Registry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	req.Host = NormalizeRegistryHost(req.Host)
+	if s.findByHost(req.Host) != nil {
+		return nil, ErrRegistryAlreadyExists
+	}
+
+	reg := models.NewRegistry(req)
+	s.registries[reg.ID] = reg
+
+	if err := s.save(); err != nil {
+		delete(s.registries, reg.ID)
+		return nil, err
+	}
+
+	return reg, nil
+}
+
+func (s *RegistryStore) Update(id string, req models.UpdateRegistryRequest) (*models.Registry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reg, exists := s.registries[id]
+	if !exists {
+		return nil, ErrRegistryNotFound
+	}
+
+	if req.Host != "" {
+		host := NormalizeRegistryHost(req.Host)
+		if other := s.findByHost(host); other != nil && other.ID != id {
+			return nil, ErrRegistryAlreadyExists
+		}
+		reg.Host = host
+	}
+	if req.Name != "" {
+		reg.Name = req.Name
+	}
+	if req.Username != "" {
+		reg.Username = req.Username
+	}
+	if req.Password != "" {
+		reg.Password = req.Password
+	}
+	reg.UpdatedAt = time.Now()
+
+	if err := s.save(); err != nil {
+		return nil, err
+	}
+
+	return reg, nil
+}
+
+func (s *RegistryStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reg, exists := s.registries[id]
+	if !exists {
+		return ErrRegistryNotFound
+	}
+
+	delete(s.registries, id)
+
+	if err := s.save(); err != nil {
+		s.registries[id] = reg
+		return err
+	}
+
+	return nil
+}
+
+func (s *RegistryStore) findByHost(host string) *models.Registry {
+	for _, reg := range s.registries {
+		if reg.Host == host {
+			return reg
+		}
+	}
+	return nil
+}
+
+// AuthForImage trả về X-Registry-Auth (base64) cho registry chứa image ref,
+// hoặc chuỗi rỗng nếu không có credentials cho host đó.
+func (s *RegistryStore) AuthForImage(ref string) string {
+	authConfig := s.AuthConfigForImage(ref)
+	if authConfig == nil {
+		return ""
+	}
+	auth, err := registry.EncodeAuthConfig(*authConfig)
+	if err != nil {
+		return ""
+	}
+	return auth
+}
+
+// AuthConfigForImage trả về credentials cho registry chứa image ref, nil nếu chưa lưu
+func (s *RegistryStore) AuthConfigForImage(ref string) *registry.AuthConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	reg := s.findByHost(RegistryHostFromImage(ref))
+	if reg == nil {
+		return nil
+	}
+	auth := RegistryAuthConfig(reg)
+	return &auth
+}
+
+// AuthConfigs trả về credentials của mọi registry theo địa chỉ đăng nhập,
+// dùng cho build (base image có thể nằm ở nhiều registry khác nhau)
+func (s *RegistryStore) AuthConfigs() map[string]registry.AuthConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	configs := make(map[string]registry.AuthConfig, len(s.registries))
+	for _, reg := range s.registries {
+		auth := RegistryAuthConfig(reg)
+		configs[auth.ServerAddress] = auth
+	}
+	return configs
+}
+
+// RegistryAuthConfig chuyển registry đã lưu thành AuthConfig của Docker
+func RegistryAuthConfig(reg *models.Registry) registry.AuthConfig {
+	address := reg.Host
+	if address == dockerHubHost {
+		address = dockerHubAuthServer
+	}
+	return registry.AuthConfig{
+		Username:      reg.Username,
+		Password:      reg.Password,
+		ServerAddress: address,
+	}
+}
+
+// RegistryHostFromImage trả về host registry của image ref (docker.io nếu không ghi rõ)
+func RegistryHostFromImage(ref string) string {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return ""
+	}
+	return NormalizeRegistryHost(reference.Domain(named))
+}
+
+// NormalizeRegistryHost bỏ scheme, path và quy các hostname của Docker Hub về docker.io
+func NormalizeRegistryHost(host string) string {
+	host = strings.TrimSpace(strings.ToLower(host))
+	host = strings.TrimPrefix(host, "https://")
+	host = strings.TrimPrefix(host, "http://")
+	if idx := strings.IndexByte(host, '/'); idx >= 0 {
+		host = host[:idx]
+	}
+	switch host {
+	case "index.docker.io", "registry-1.docker.io", "registry.hub.docker.com":
+		return dockerHubHost
+	}
+	return host
+}
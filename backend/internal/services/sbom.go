@@ -0,0 +1,268 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"appdock/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// Định dạng SBOM hỗ trợ export
+const (
+	SBOMFormatSPDX      = "spdx"
+	SBOMFormatCycloneDX = "cyclonedx"
+)
+
+// WriteSBOM ghi danh sách package của kết quả scan theo SPDX 2.3 hoặc CycloneDX 1.5 (JSON)
+func WriteSBOM(w io.Writer, result *models.ScanResult, format string) error {
+	var doc interface{}
+	switch format {
+	case SBOMFormatSPDX:
+		doc = newSPDXDocument(result)
+	case SBOMFormatCycloneDX:
+		doc = newCycloneDXDocument(result)
+	default:
+		return fmt.Errorf("unsupported SBOM format: %s", format)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(doc)
+}
+
+// WriteVulnerabilitiesCSV export danh sách lỗ hổng ra CSV
+func WriteVulnerabilitiesCSV(w io.Writer, result *models.ScanResult) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"id", "aliases", "severity", "score", "package", "version", "fixed_version", "type", "purl", "location", "summary"})
+	for _, v := range result.Vulnerabilities {
+		writer.Write([]string{
+			v.ID,
+			strings.Join(v.Aliases, " "),
+			string(v.Severity),
+			formatScore(v.Score),
+			v.Package,
+			v.Version,
+			v.FixedVersion,
+			v.Type,
+			v.PURL,
+			v.Location,
+			v.Summary,
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// sbomImageName tên image dùng trong SBOM: tag đầu tiên hoặc image ID
+func sbomImageName(result *models.ScanResult) string {
+	if len(result.RepoTags) > 0 {
+		return result.RepoTags[0]
+	}
+	return result.ImageID
+}
+
+// ==================== SPDX ====================
+
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	Name             string            `json:"name"`
+	SPDXID           string            `json:"SPDXID"`
+	VersionInfo      string            `json:"versionInfo,omitempty"`
+	DownloadLocation string            `json:"downloadLocation"`
+	FilesAnalyzed    bool              `json:"filesAnalyzed"`
+	SourceInfo       string            `json:"sourceInfo,omitempty"`
+	ExternalRefs     []spdxExternalRef `json:"externalRefs,omitempty"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+func newSPDXDocument(result *models.ScanResult) *spdxDocument {
+	imageName := sbomImageName(result)
+	doc := &spdxDocument{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              imageName,
+		DocumentNamespace: "https://appdock/spdx/" + result.ImageID + "-" + uuid.New().String(),
+		CreationInfo: spdxCreationInfo{
+			Created:  result.Summary.ScannedAt.UTC().Format(time.RFC3339),
+			Creators: []string{"Tool: appdock"},
+		},
+		Packages: []spdxPackage{{
+			Name:             imageName,
+			SPDXID:           "SPDXRef-Image",
+			VersionInfo:      result.ImageID,
+			DownloadLocation: "NOASSERTION",
+		}},
+		Relationships: []spdxRelationship{{
+			SPDXElementID:      "SPDXRef-DOCUMENT",
+			RelationshipType:   "DESCRIBES",
+			RelatedSPDXElement: "SPDXRef-Image",
+		}},
+	}
+
+	for i, pkg := range result.Packages {
+		id := fmt.Sprintf("SPDXRef-Package-%d", i+1)
+		spdxPkg := spdxPackage{
+			Name:             pkg.Name,
+			SPDXID:           id,
+			VersionInfo:      pkg.Version,
+			DownloadLocation: "NOASSERTION",
+			SourceInfo:       "acquired package info from " + pkg.Location,
+		}
+		if pkg.PURL != "" {
+			spdxPkg.ExternalRefs = []spdxExternalRef{{
+				ReferenceCategory: "PACKAGE-MANAGER",
+				ReferenceType:     "purl",
+				ReferenceLocator:  pkg.PURL,
+			}}
+		}
+		doc.Packages = append(doc.Packages, spdxPkg)
+		doc.Relationships = append(doc.Relationships, spdxRelationship{
+			SPDXElementID:      "SPDXRef-Image",
+			RelationshipType:   "CONTAINS",
+			RelatedSPDXElement: id,
+		})
+	}
+
+	return doc
+}
+
+// ==================== CycloneDX ====================
+
+type cdxDocument struct {
+	BOMFormat       string             `json:"bomFormat"`
+	SpecVersion     string             `json:"specVersion"`
+	SerialNumber    string             `json:"serialNumber"`
+	Version         int                `json:"version"`
+	Metadata        cdxMetadata        `json:"metadata"`
+	Components      []cdxComponent     `json:"components"`
+	Vulnerabilities []cdxVulnerability `json:"vulnerabilities,omitempty"`
+}
+
+type cdxMetadata struct {
+	Timestamp string       `json:"timestamp"`
+	Tools     []cdxTool    `json:"tools"`
+	Component cdxComponent `json:"component"`
+}
+
+type cdxTool struct {
+	Name string `json:"name"`
+}
+
+type cdxComponent struct {
+	BOMRef     string        `json:"bom-ref,omitempty"`
+	Type       string        `json:"type"`
+	Name       string        `json:"name"`
+	Version    string        `json:"version,omitempty"`
+	PURL       string        `json:"purl,omitempty"`
+	Properties []cdxProperty `json:"properties,omitempty"`
+}
+
+type cdxProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type cdxVulnerability struct {
+	ID             string      `json:"id"`
+	Description    string      `json:"description,omitempty"`
+	Ratings        []cdxRating `json:"ratings"`
+	Recommendation string      `json:"recommendation,omitempty"`
+	Affects        []cdxAffect `json:"affects"`
+}
+
+type cdxRating struct {
+	Score    float64 `json:"score,omitempty"`
+	Severity string  `json:"severity"`
+	Method   string  `json:"method,omitempty"`
+}
+
+type cdxAffect struct {
+	Ref string `json:"ref"`
+}
+
+func newCycloneDXDocument(result *models.ScanResult) *cdxDocument {
+	doc := &cdxDocument{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: "urn:uuid:" + uuid.New().String(),
+		Version:      1,
+		Metadata: cdxMetadata{
+			Timestamp: result.Summary.ScannedAt.UTC().Format(time.RFC3339),
+			Tools:     []cdxTool{{Name: "appdock"}},
+			Component: cdxComponent{
+				Type:    "container",
+				Name:    sbomImageName(result),
+				Version: result.ImageID,
+			},
+		},
+		Components: make([]cdxComponent, 0, len(result.Packages)),
+	}
+
+	// Cùng purl có thể xuất hiện ở nhiều vị trí (e.g. node_modules lồng nhau) nên bom-ref đánh theo thứ tự
+	refs := make(map[string]string, len(result.Packages))
+	for i, pkg := range result.Packages {
+		ref := fmt.Sprintf("package-%d", i+1)
+		refs[pkg.PURL+"|"+pkg.Location] = ref
+		doc.Components = append(doc.Components, cdxComponent{
+			BOMRef:     ref,
+			Type:       "library",
+			Name:       pkg.Name,
+			Version:    pkg.Version,
+			PURL:       pkg.PURL,
+			Properties: []cdxProperty{{Name: "appdock:location", Value: pkg.Location}},
+		})
+	}
+
+	for _, v := range result.Vulnerabilities {
+		rating := cdxRating{Severity: strings.ToLower(string(v.Severity))}
+		if v.Score > 0 {
+			rating.Score = v.Score
+			rating.Method = "CVSSv3"
+		}
+		vuln := cdxVulnerability{
+			ID:          v.ID,
+			Description: v.Summary,
+			Ratings:     []cdxRating{rating},
+			Affects:     []cdxAffect{{Ref: refs[v.PURL+"|"+v.Location]}},
+		}
+		if v.FixedVersion != "" {
+			vuln.Recommendation = "Upgrade " + v.Package + " to " + v.FixedVersion
+		}
+		doc.Vulnerabilities = append(doc.Vulnerabilities, vuln)
+	}
+
+	return doc
+}
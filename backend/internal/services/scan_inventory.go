@@ -0,0 +1,493 @@
+package services
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"debug/buildinfo"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"appdock/internal/models"
+)
+
+const (
+	// File metadata (status, package.json, METADATA) lớn hơn mức này bị bỏ qua
+	maxMetadataFileSize = 64 << 20
+	// Chỉ đọc build info của Go binary nhỏ hơn mức này, vì phải nạp cả file vào bộ nhớ
+	maxBinaryFileSize = 256 << 20
+)
+
+var pypiNameSeparators = regexp.MustCompile(`[-_.]+`)
+
+// imageInventory gom package qua từng layer. Layer sau ghi đè hoặc xóa (whiteout)
+// file của layer trước, nên package được lưu theo file chứa thông tin của nó.
+type imageInventory struct {
+	packages  map[string][]models.Package
+	osRelease map[string]*models.ImageOS
+	warnings  map[string]bool
+}
+
+func newImageInventory() *imageInventory {
+	return &imageInventory{
+		packages:  make(map[string][]models.Package),
+		osRelease: make(map[string]*models.ImageOS),
+		warnings:  make(map[string]bool),
+	}
+}
+
+// readImageInventory đọc tar của docker save. Thứ tự entry trong tar không cố định
+// (manifest.json có thể nằm cuối) nên stream được ghi ra file tạm rồi đọc layer theo manifest.
+func readImageInventory(r io.Reader) (*imageInventory, error) {
+	tmp, err := os.CreateTemp("", "appdock-scan-*.tar")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		return nil, err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string]*tar.Header)
+	offsets := make(map[string]int64)
+	counter := &countingReader{r: tmp}
+	tr := tar.NewReader(counter)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		name := path.Clean(hdr.Name)
+		entries[name] = hdr
+		offsets[name] = counter.n
+	}
+
+	manifestHdr, ok := entries["manifest.json"]
+	if !ok {
+		return nil, errors.New("manifest.json not found in image archive")
+	}
+	var manifests []struct {
+		Layers []string `json:"Layers"`
+	}
+	manifestData := io.NewSectionReader(tmp, offsets["manifest.json"], manifestHdr.Size)
+	if err := json.NewDecoder(manifestData).Decode(&manifests); err != nil {
+		return nil, fmt.Errorf("invalid manifest.json: %w", err)
+	}
+	if len(manifests) == 0 {
+		return nil, errors.New("image archive contains no image")
+	}
+
+	inv := newImageInventory()
+	for _, layer := range manifests[0].Layers {
+		name := path.Clean(layer)
+		// Layer trùng nhau trong định dạng cũ là symlink tới layer.tar của layer khác
+		for i := 0; i < 8; i++ {
+			hdr, ok := entries[name]
+			if !ok || hdr.Typeflag != tar.TypeSymlink {
+				break
+			}
+			name = path.Clean(path.Join(path.Dir(name), hdr.Linkname))
+		}
+		hdr, ok := entries[name]
+		if !ok {
+			return nil, fmt.Errorf("layer %s not found in image archive", layer)
+		}
+		if err := inv.addLayer(io.NewSectionReader(tmp, offsets[name], hdr.Size)); err != nil {
+			return nil, fmt.Errorf("read layer %s: %w", layer, err)
+		}
+	}
+
+	return inv, nil
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// addLayer áp dụng một layer (tar, có thể nén gzip) lên inventory
+func (inv *imageInventory) addLayer(r io.Reader) error {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	} else {
+		r = br
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		name := path.Clean("/" + hdr.Name)
+		dir, base := path.Split(name)
+		if strings.HasPrefix(base, ".wh.") {
+			if base == ".wh..wh..opq" {
+				inv.removeTree(path.Clean(dir), false)
+			} else {
+				inv.removeTree(path.Join(dir, strings.TrimPrefix(base, ".wh.")), true)
+			}
+			continue
+		}
+
+		// File hoặc thư mục mới thay thế nội dung cũ tại cùng path
+		delete(inv.packages, name)
+		delete(inv.osRelease, name)
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		inv.scanFile(name, hdr, tr)
+	}
+}
+
+// removeTree xóa package thuộc path (includeSelf) và mọi file bên dưới
+func (inv *imageInventory) removeTree(p string, includeSelf bool) {
+	prefix := strings.TrimSuffix(p, "/") + "/"
+	for name := range inv.packages {
+		if (includeSelf && name == p) || strings.HasPrefix(name, prefix) {
+			delete(inv.packages, name)
+		}
+	}
+	for name := range inv.osRelease {
+		if (includeSelf && name == p) || strings.HasPrefix(name, prefix) {
+			delete(inv.osRelease, name)
+		}
+	}
+}
+
+func (inv *imageInventory) scanFile(name string, hdr *tar.Header, r io.Reader) {
+	dir, base := path.Split(name)
+	parent := path.Base(dir)
+
+	var (
+		pkgs []models.Package
+		err  error
+	)
+	switch {
+	case name == "/etc/os-release" || name == "/usr/lib/os-release":
+		if data, readErr := readMetadataFile(hdr, r); readErr == nil {
+			inv.osRelease[name] = parseOSRelease(data)
+		}
+		return
+	case name == "/var/lib/dpkg/status" || dir == "/var/lib/dpkg/status.d/":
+		pkgs, err = withMetadataFile(hdr, r, parseDpkgStatus)
+	case name == "/lib/apk/db/installed":
+		pkgs, err = withMetadataFile(hdr, r, parseApkInstalled)
+	case strings.HasPrefix(name, "/var/lib/rpm/") || strings.HasPrefix(name, "/usr/lib/sysimage/rpm/"):
+		inv.warnings["RPM package databases are not supported, OS packages of RPM based images are not listed"] = true
+		return
+	case base == "package.json" && isNpmManifest(name):
+		pkgs, err = withMetadataFile(hdr, r, parseNpmPackage)
+	case (base == "METADATA" && strings.HasSuffix(parent, ".dist-info")) ||
+		(base == "PKG-INFO" && strings.HasSuffix(parent, ".egg-info")):
+		pkgs, err = withMetadataFile(hdr, r, parsePythonMetadata)
+	case hdr.Mode&0111 != 0 && hdr.Size > 1024 && hdr.Size <= maxBinaryFileSize:
+		pkgs, err = parseGoBinary(hdr, r)
+	default:
+		return
+	}
+	if err != nil || len(pkgs) == 0 {
+		return
+	}
+
+	for i := range pkgs {
+		pkgs[i].Location = name
+	}
+	inv.packages[name] = pkgs
+}
+
+// OS trả về distro của image, ưu tiên /etc/os-release
+func (inv *imageInventory) OS() *models.ImageOS {
+	if osInfo := inv.osRelease["/etc/os-release"]; osInfo != nil {
+		return osInfo
+	}
+	return inv.osRelease["/usr/lib/os-release"]
+}
+
+// Packages trả về danh sách package đã loại trùng, kèm purl theo distro của image
+func (inv *imageInventory) Packages() []models.Package {
+	osInfo := inv.OS()
+	seen := make(map[string]bool)
+	result := make([]models.Package, 0)
+	for _, pkgs := range inv.packages {
+		for _, pkg := range pkgs {
+			pkg.PURL = packageURL(pkg, osInfo)
+			key := pkg.PURL + "|" + pkg.Location
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			result = append(result, pkg)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Type != result[j].Type {
+			return result[i].Type < result[j].Type
+		}
+		if result[i].Name != result[j].Name {
+			return result[i].Name < result[j].Name
+		}
+		return result[i].Location < result[j].Location
+	})
+	return result
+}
+
+func (inv *imageInventory) Warnings() []string {
+	warnings := make([]string, 0, len(inv.warnings))
+	for warning := range inv.warnings {
+		warnings = append(warnings, warning)
+	}
+	sort.Strings(warnings)
+	return warnings
+}
+
+func readMetadataFile(hdr *tar.Header, r io.Reader) ([]byte, error) {
+	if hdr.Size > maxMetadataFileSize {
+		return nil, errors.New("file too large")
+	}
+	return io.ReadAll(io.LimitReader(r, maxMetadataFileSize))
+}
+
+func withMetadataFile(hdr *tar.Header, r io.Reader, parse func([]byte) []models.Package) ([]models.Package, error) {
+	data, err := readMetadataFile(hdr, r)
+	if err != nil {
+		return nil, err
+	}
+	return parse(data), nil
+}
+
+func parseOSRelease(data []byte) *models.ImageOS {
+	osInfo := &models.ImageOS{}
+	for _, line := range strings.Split(string(data), "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(value, `"'`)
+		switch key {
+		case "ID":
+			osInfo.ID = strings.ToLower(value)
+		case "VERSION_ID":
+			osInfo.Version = value
+		case "PRETTY_NAME":
+			osInfo.Name = value
+		}
+	}
+	return osInfo
+}
+
+// parseDpkgStatus đọc /var/lib/dpkg/status, chỉ lấy package đã cài
+func parseDpkgStatus(data []byte) []models.Package {
+	var pkgs []models.Package
+	for _, block := range strings.Split(string(data), "\n\n") {
+		fields := parseControlFields(block)
+		if fields["Package"] == "" || fields["Version"] == "" {
+			continue
+		}
+		if status := fields["Status"]; status != "" && !strings.HasSuffix(status, " installed") {
+			continue
+		}
+		pkg := models.Package{
+			Name:    fields["Package"],
+			Version: fields["Version"],
+			Type:    models.PackageTypeDeb,
+		}
+		// "Source: openssl (3.0.11-1)" - version trong ngoặc là version của source package
+		if source := fields["Source"]; source != "" {
+			pkg.Source = strings.Fields(source)[0]
+		}
+		pkgs = append(pkgs, pkg)
+	}
+	return pkgs
+}
+
+// parseControlFields đọc một đoạn "Key: value" kiểu Debian control, bỏ qua dòng tiếp nối
+func parseControlFields(block string) map[string]string {
+	fields := make(map[string]string)
+	for _, line := range strings.Split(block, "\n") {
+		if line == "" || line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if ok {
+			fields[key] = strings.TrimSpace(value)
+		}
+	}
+	return fields
+}
+
+// parseApkInstalled đọc /lib/apk/db/installed (P: tên, V: version, o: origin)
+func parseApkInstalled(data []byte) []models.Package {
+	var pkgs []models.Package
+	for _, block := range strings.Split(string(data), "\n\n") {
+		var pkg models.Package
+		for _, line := range strings.Split(block, "\n") {
+			if len(line) < 3 || line[1] != ':' {
+				continue
+			}
+			switch line[0] {
+			case 'P':
+				pkg.Name = line[2:]
+			case 'V':
+				pkg.Version = line[2:]
+			case 'o':
+				pkg.Source = line[2:]
+			}
+		}
+		if pkg.Name != "" && pkg.Version != "" {
+			pkg.Type = models.PackageTypeApk
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	return pkgs
+}
+
+// isNpmManifest kiểm tra package.json nằm ngay trong node_modules/<name> hoặc node_modules/@scope/<name>
+func isNpmManifest(name string) bool {
+	pkgDir := path.Dir(name)
+	parent := path.Dir(pkgDir)
+	if strings.HasPrefix(path.Base(parent), "@") {
+		parent = path.Dir(parent)
+	}
+	return path.Base(parent) == "node_modules"
+}
+
+func parseNpmPackage(data []byte) []models.Package {
+	var manifest struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	if json.Unmarshal(data, &manifest) != nil || manifest.Name == "" || manifest.Version == "" {
+		return nil
+	}
+	return []models.Package{{Name: manifest.Name, Version: manifest.Version, Type: models.PackageTypeNpm}}
+}
+
+// parsePythonMetadata đọc METADATA (wheel) hoặc PKG-INFO (egg)
+func parsePythonMetadata(data []byte) []models.Package {
+	// Phần header kết thúc ở dòng trống đầu tiên, sau đó là mô tả dài
+	header, _, _ := strings.Cut(string(data), "\n\n")
+	fields := parseControlFields(header)
+	if fields["Name"] == "" || fields["Version"] == "" {
+		return nil
+	}
+	return []models.Package{{Name: fields["Name"], Version: fields["Version"], Type: models.PackageTypePypi}}
+}
+
+// parseGoBinary đọc build info nhúng trong binary Go: module chính, dependencies và stdlib
+func parseGoBinary(hdr *tar.Header, r io.Reader) ([]models.Package, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(4)
+	if err != nil {
+		return nil, err
+	}
+	// ELF, Mach-O và PE đều có thể chứa build info, image Linux hầu như chỉ có ELF
+	if !bytes.Equal(magic, []byte("\x7fELF")) {
+		return nil, nil
+	}
+
+	data, err := io.ReadAll(io.LimitReader(br, hdr.Size))
+	if err != nil {
+		return nil, err
+	}
+	info, err := buildinfo.Read(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil
+	}
+
+	var pkgs []models.Package
+	if goVersion := strings.TrimPrefix(info.GoVersion, "go"); goVersion != "" {
+		// "go1.22.1 X:boringcrypto" -> "1.22.1"
+		goVersion = strings.Fields(goVersion)[0]
+		pkgs = append(pkgs, models.Package{Name: "stdlib", Version: "v" + goVersion, Type: models.PackageTypeGolang})
+	}
+	if info.Main.Path != "" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		pkgs = append(pkgs, models.Package{Name: info.Main.Path, Version: info.Main.Version, Type: models.PackageTypeGolang})
+	}
+	for _, dep := range info.Deps {
+		if dep.Replace != nil {
+			dep = dep.Replace
+		}
+		if dep.Path == "" || dep.Version == "" || dep.Version == "(devel)" {
+			continue
+		}
+		pkgs = append(pkgs, models.Package{Name: dep.Path, Version: dep.Version, Type: models.PackageTypeGolang})
+	}
+	return pkgs, nil
+}
+
+// packageURL tạo purl (https://github.com/package-url/purl-spec) cho package
+func packageURL(pkg models.Package, osInfo *models.ImageOS) string {
+	version := purlEscape(pkg.Version)
+	switch pkg.Type {
+	case models.PackageTypeDeb, models.PackageTypeApk:
+		namespace := "debian"
+		if pkg.Type == models.PackageTypeApk {
+			namespace = "alpine"
+		}
+		qualifiers := url.Values{}
+		if osInfo != nil && osInfo.ID != "" {
+			namespace = osInfo.ID
+			qualifiers.Set("distro", osInfo.ID+"-"+osInfo.Version)
+		}
+		if pkg.Source != "" && pkg.Source != pkg.Name {
+			qualifiers.Set("upstream", pkg.Source)
+		}
+		purl := "pkg:" + pkg.Type + "/" + namespace + "/" + purlEscape(pkg.Name) + "@" + version
+		if len(qualifiers) > 0 {
+			purl += "?" + qualifiers.Encode()
+		}
+		return purl
+	case models.PackageTypeNpm:
+		// Scope "@types/node" thành namespace "%40types"
+		return "pkg:npm/" + purlEscape(pkg.Name) + "@" + version
+	case models.PackageTypePypi:
+		name := strings.ToLower(pypiNameSeparators.ReplaceAllString(pkg.Name, "-"))
+		return "pkg:pypi/" + purlEscape(name) + "@" + version
+	case models.PackageTypeGolang:
+		return "pkg:golang/" + purlEscape(pkg.Name) + "@" + version
+	}
+	return "pkg:generic/" + purlEscape(pkg.Name) + "@" + version
+}
+
+// purlEscape percent-encode từng đoạn, giữ dấu "/" phân cách namespace
+func purlEscape(s string) string {
+	segments := strings.Split(s, "/")
+	for i, segment := range segments {
+		segments[i] = strings.ReplaceAll(url.PathEscape(segment), "@", "%40")
+	}
+	return strings.Join(segments, "/")
+}
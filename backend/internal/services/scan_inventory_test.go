@@ -0,0 +1,186 @@
+package services
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"reflect"
+	"testing"
+
+	"appdock/internal/models"
+)
+
+func TestParseDpkgStatus(t *testing.T) {
+	status := `Package: libssl3
+Status: install ok installed
+Priority: optional
+Architecture: amd64
+Source: openssl (3.0.11-1~deb12u2)
+Version: 3.0.11-1~deb12u2
+Description: Secure Sockets Layer toolkit - shared libraries
+ This package is part of the OpenSSL project's implementation of the SSL
+ and TLS cryptographic protocols.
+
+Package: removed-pkg
+Status: deinstall ok config-files
+Version: 1.0-1
+
+Package: base-files
+Version: 12.4+deb12u5
+Essential: yes
+
+Package: no-version
+Status: install ok installed
+`
+
+	got := parseDpkgStatus([]byte(status))
+	want := []models.Package{
+		{Name: "libssl3", Version: "3.0.11-1~deb12u2", Type: models.PackageTypeDeb, Source: "openssl"},
+		{Name: "base-files", Version: "12.4+deb12u5", Type: models.PackageTypeDeb},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseDpkgStatus() = %+v, want %+v", got, want)
+	}
+}
+
+type layerFile struct {
+	name    string
+	content string
+}
+
+// buildLayer tạo layer tar (gzip nếu compress = true) từ các file, file rỗng dùng cho whiteout
+func buildLayer(t *testing.T, compress bool, files ...layerFile) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	var gz *gzip.Writer
+	var w io.Writer = &buf
+	if compress {
+		gz = gzip.NewWriter(&buf)
+		w = gz
+	}
+	tw := tar.NewWriter(w)
+	for _, f := range files {
+		hdr := &tar.Header{Name: f.name, Mode: 0644, Size: int64(len(f.content)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(f.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &buf
+}
+
+func inventoryPackageNames(inv *imageInventory) []string {
+	names := make([]string, 0)
+	for _, pkg := range inv.Packages() {
+		names = append(names, pkg.Name)
+	}
+	return names
+}
+
+func TestImageInventoryWhiteouts(t *testing.T) {
+	dpkgStatus := "Package: curl\nStatus: install ok installed\nVersion: 7.88.1-10\n"
+	statusD := "Package: tzdata\nVersion: 2024a-0\n"
+	npmPackage := `{"name": "left-pad", "version": "1.3.0"}`
+	osRelease := "ID=debian\nVERSION_ID=\"12\"\n"
+
+	base := []layerFile{
+		{"etc/os-release", osRelease},
+		{"var/lib/dpkg/status", dpkgStatus},
+		{"var/lib/dpkg/status.d/tzdata", statusD},
+		{"usr/lib/node_modules/left-pad/package.json", npmPackage},
+	}
+
+	tests := []struct {
+		name    string
+		layers  [][]layerFile
+		want    []string
+		wantOS  bool
+		gzipped bool
+	}{
+		{
+			name:   "no whiteout",
+			layers: [][]layerFile{base},
+			want:   []string{"curl", "tzdata", "left-pad"},
+			wantOS: true,
+		},
+		{
+			name:    "gzipped layer",
+			layers:  [][]layerFile{base},
+			want:    []string{"curl", "tzdata", "left-pad"},
+			wantOS:  true,
+			gzipped: true,
+		},
+		{
+			name:   "file whiteout",
+			layers: [][]layerFile{base, {{"var/lib/dpkg/.wh.status", ""}}},
+			want:   []string{"tzdata", "left-pad"},
+			wantOS: true,
+		},
+		{
+			name:   "directory whiteout",
+			layers: [][]layerFile{base, {{"usr/lib/.wh.node_modules", ""}}},
+			want:   []string{"curl", "tzdata"},
+			wantOS: true,
+		},
+		{
+			name:   "opaque directory",
+			layers: [][]layerFile{base, {{"var/lib/dpkg/status.d/.wh..wh..opq", ""}}},
+			want:   []string{"curl", "left-pad"},
+			wantOS: true,
+		},
+		{
+			name:   "os-release whiteout",
+			layers: [][]layerFile{base, {{"etc/.wh.os-release", ""}}},
+			want:   []string{"curl", "tzdata", "left-pad"},
+		},
+		{
+			name: "file re-added after whiteout",
+			layers: [][]layerFile{
+				base,
+				{{"var/lib/dpkg/.wh.status", ""}},
+				{{"var/lib/dpkg/status", dpkgStatus}},
+			},
+			want:   []string{"curl", "tzdata", "left-pad"},
+			wantOS: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inv := newImageInventory()
+			for _, layer := range tt.layers {
+				if err := inv.addLayer(buildLayer(t, tt.gzipped, layer...)); err != nil {
+					t.Fatalf("addLayer: %v", err)
+				}
+			}
+
+			got := inventoryPackageNames(inv)
+			wantSet := make(map[string]bool, len(tt.want))
+			for _, name := range tt.want {
+				wantSet[name] = true
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("packages = %v, want %v", got, tt.want)
+			}
+			for _, name := range got {
+				if !wantSet[name] {
+					t.Fatalf("packages = %v, want %v", got, tt.want)
+				}
+			}
+			if hasOS := inv.OS() != nil; hasOS != tt.wantOS {
+				t.Errorf("OS() present = %v, want %v", hasOS, tt.wantOS)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"appdock/internal/models"
+)
+
+var ErrScannerNotConfigured = errors.New("image scanner is not configured")
+
+// SetScanner bật scan lỗ hổng image với vulnerability DB offline và nơi lưu kết quả
+func (m *ServerManager) SetScanner(vulnDB *VulnDatabase, scans *ScanStore) {
+	m.mu.Lock()
+	m.vulnDB = vulnDB
+	m.scans = scans
+	m.mu.Unlock()
+}
+
+func (m *ServerManager) scanner() (*VulnDatabase, *ScanStore) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.vulnDB, m.scans
+}
+
+// attachScanSummaries gắn summary của lần scan gần nhất vào danh sách image
+func (m *ServerManager) attachScanSummaries(serverID string, images []ImageInfo) {
+	_, scans := m.scanner()
+	if scans == nil {
+		return
+	}
+	for i := range images {
+		images[i].Scan = scans.Summary(serverID, images[i].ID)
+	}
+}
+
+// ScanImage đọc các layer của image qua Docker API, lập danh sách package (SBOM)
+// và đối chiếu với vulnerability DB. Image vẫn được scan khi DB trống, chỉ không có lỗ hổng.
+func (m *ServerManager) ScanImage(serverID, imageID string) (*models.ScanResult, error) {
+	vulnDB, scans := m.scanner()
+	if vulnDB == nil || scans == nil {
+		return nil, ErrScannerNotConfigured
+	}
+
+	details, err := m.GetImageDetails(serverID, imageID)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := m.SaveImage(serverID, []string{details.ID})
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	inventory, err := readImageInventory(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.ScanResult{
+		ServerID: serverID,
+		ImageID:  details.ID,
+		RepoTags: details.RepoTags,
+		OS:       inventory.OS(),
+		Packages: inventory.Packages(),
+		Warnings: inventory.Warnings(),
+	}
+
+	result.Vulnerabilities, result.DatabaseDate, err = vulnDB.Match(result.OS, result.Packages)
+	if err != nil {
+		if !errors.Is(err, ErrVulnDBEmpty) {
+			return nil, err
+		}
+		result.Vulnerabilities = make([]models.Vulnerability, 0)
+		result.Warnings = append(result.Warnings, err.Error())
+	}
+
+	result.Summary = models.ScanSummary{
+		ScannedAt: time.Now(),
+		Packages:  len(result.Packages),
+	}
+	for _, v := range result.Vulnerabilities {
+		result.Summary.Count(v.Severity)
+	}
+
+	if err := scans.Save(result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetScanResult trả về kết quả scan gần nhất của image
+func (m *ServerManager) GetScanResult(serverID, imageID string) (*models.ScanResult, error) {
+	_, scans := m.scanner()
+	if scans == nil {
+		return nil, ErrScannerNotConfigured
+	}
+
+	// Cho phép dùng tên image thay cho ID
+	if details, err := m.GetImageDetails(serverID, imageID); err == nil {
+		imageID = details.ID
+	}
+	return scans.Get(serverID, imageID)
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"appdock/internal/models"
+)
+
+var ErrScanNotFound = errors.New("image has not been scanned yet")
+
+// ScanStore lưu kết quả scan image, mỗi image một file trong thư mục scans/
+// vì danh sách package có thể lớn. Summary được giữ trong bộ nhớ để gắn vào danh sách image.
+type ScanStore struct {
+	dir       string
+	summaries map[string]models.ScanSummary // serverID|imageID -> summary
+	mu        sync.RWMutex
+}
+
+func NewScanStore(dataDir string) (*ScanStore, error) {
+	dir := filepath.Join(dataDir, "scans")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	store := &ScanStore{
+		dir:       dir,
+		summaries: make(map[string]models.ScanSummary),
+	}
+
+	if err := store.load(); err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+func (s *ScanStore) load() error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		var result models.ScanResult
+		if err := json.Unmarshal(data, &result); err != nil {
+			// Bỏ qua file hỏng, image có thể scan lại
+			continue
+		}
+		s.summaries[scanKey(result.ServerID, result.ImageID)] = result.Summary
+	}
+
+	return nil
+}
+
+func scanKey(serverID, imageID string) string {
+	return normalizeServerID(serverID) + "|" + shortImageID(imageID)
+}
+
+// shortImageID chuẩn hóa image ID về 12 ký tự giống ImageInfo.ID
+func shortImageID(id string) string {
+	return shortID(strings.TrimPrefix(id, "sha256:"))
+}
+
+func (s *ScanStore) filePath(serverID, imageID string) string {
+	return filepath.Join(s.dir, normalizeServerID(serverID)+"_"+shortImageID(imageID)+".json")
+}
+
+// Save lưu kết quả scan, ghi đè kết quả cũ của cùng image
+func (s *ScanStore) Save(result *models.ScanResult) error {
+	result.ServerID = normalizeServerID(result.ServerID)
+	result.ImageID = shortImageID(result.ImageID)
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.WriteFile(s.filePath(result.ServerID, result.ImageID), data, 0644); err != nil {
+		return err
+	}
+	s.summaries[scanKey(result.ServerID, result.ImageID)] = result.Summary
+	return nil
+}
+
+// Get trả về kết quả scan đầy đủ của image
+func (s *ScanStore) Get(serverID, imageID string) (*models.ScanResult, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, exists := s.summaries[scanKey(serverID, imageID)]; !exists {
+		return nil, ErrScanNotFound
+	}
+	data, err := os.ReadFile(s.filePath(serverID, imageID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrScanNotFound
+		}
+		return nil, err
+	}
+
+	var result models.ScanResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Summary trả về summary của lần scan gần nhất, nil nếu image chưa được scan
+func (s *ScanStore) Summary(serverID, imageID string) *models.ScanSummary {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	summary, exists := s.summaries[scanKey(serverID, imageID)]
+	if !exists {
+		return nil
+	}
+	return &summary
+}
@@ -2,18 +2,30 @@ package services
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
 	"sync"
 	"time"
 
 	"appdock/internal/models"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/registry"
 )
 
 type ServerManager struct {
-	store        *ServerStore
-	localDocker  *DockerService
-	localNginx   *NginxService
-	agentClients map[string]*AgentClient
-	mu           sync.RWMutex
+	store         *ServerStore
+	localDocker   *DockerService
+	localNginx    *NginxService
+	agentClients  map[string]*AgentClient
+	registries    *RegistryStore
+	vulnDB        *VulnDatabase
+	scans         *ScanStore
+	updateChecker *UpdateChecker
+	mu            sync.RWMutex
 }
 
 func NewServerManager(store *ServerStore, localDocker *DockerService, localNginx *NginxService) *ServerManager {
@@ -37,6 +49,25 @@ func NewServerManager(store *ServerStore, localDocker *DockerService, localNginx
 	return sm
 }
 
+// SetRegistryStore bật dùng credentials đã lưu khi pull/create trên mọi server
+func (m *ServerManager) SetRegistryStore(registries *RegistryStore) {
+	m.mu.Lock()
+	m.registries = registries
+	m.mu.Unlock()
+	m.localDocker.SetRegistryAuth(registries.AuthForImage)
+}
+
+// registryAuth trả về X-Registry-Auth cho image (rỗng nếu không có credentials)
+func (m *ServerManager) registryAuth(ref string) string {
+	m.mu.RLock()
+	registries := m.registries
+	m.mu.RUnlock()
+	if registries == nil {
+		return ""
+	}
+	return registries.AuthForImage(ref)
+}
+
 func (m *ServerManager) healthCheckLoop() {
 	ticker := time.NewTicker(30 * time.Second)
 	defer ticker.Stop()
@@ -245,9 +276,19 @@ func (m *ServerManager) GetSystemStats(serverID string) (*CombinedSystemStats, e
 
 // ==================== Containers ====================
 
-func (m *ServerManager) ListContainers(serverID string, all bool) (interface{}, error) {
+// ListContainers trả về danh sách container, kèm cờ updateAvailable từ lần kiểm tra registry gần nhất
+func (m *ServerManager) ListContainers(serverID string, all bool) ([]ContainerInfo, error) {
+	containers, err := m.listContainerInfos(serverID, all)
+	if err != nil {
+		return nil, err
+	}
+	m.attachUpdateStatus(serverID, containers)
+	return containers, nil
+}
+
+func (m *ServerManager) GetContainer(serverID, containerID string) (interface{}, error) {
 	if m.IsLocal(serverID) {
-		return m.localDocker.ListContainers(all)
+		return m.localDocker.GetContainer(containerID)
 	}
 
 	client := m.getAgentClient(serverID)
@@ -255,7 +296,7 @@ func (m *ServerManager) ListContainers(serverID string, all bool) (interface{},
 		return nil, ErrServerNotFound
 	}
 
-	data, err := client.ListContainers(all)
+	data, err := client.GetContainer(containerID)
 	if err != nil {
 		return nil, err
 	}
@@ -265,9 +306,56 @@ func (m *ServerManager) ListContainers(serverID string, all bool) (interface{},
 	return result, nil
 }
 
-func (m *ServerManager) GetContainer(serverID, containerID string) (interface{}, error) {
+// CreateContainer tạo container trên server và start nếu req.Start = true
+func (m *ServerManager) CreateContainer(serverID string, req CreateContainerRequest) (*CreateContainerResult, error) {
+	spec, err := req.ToSpec()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := m.CreateContainerFromSpec(serverID, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Start {
+		if err := m.StartContainer(serverID, result.ID); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// CreateContainerFromSpec tạo container từ cấu hình Docker gốc
+func (m *ServerManager) CreateContainerFromSpec(serverID string, spec *ContainerSpec) (*CreateContainerResult, error) {
 	if m.IsLocal(serverID) {
-		return m.localDocker.GetContainer(containerID)
+		return m.localDocker.CreateContainer(spec)
+	}
+
+	client := m.getAgentClient(serverID)
+	if client == nil {
+		return nil, ErrServerNotFound
+	}
+
+	if spec == nil || spec.Config == nil {
+		return nil, fmt.Errorf("image is required")
+	}
+	data, err := client.CreateContainer(spec, m.registryAuth(spec.Config.Image))
+	if err != nil {
+		return nil, err
+	}
+
+	var result CreateContainerResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// InspectContainer trả về toàn bộ cấu hình inspect của container
+func (m *ServerManager) InspectContainer(serverID, containerID string) (*container.InspectResponse, error) {
+	if m.IsLocal(serverID) {
+		return m.localDocker.InspectContainer(containerID)
 	}
 
 	client := m.getAgentClient(serverID)
@@ -280,9 +368,27 @@ func (m *ServerManager) GetContainer(serverID, containerID string) (interface{},
 		return nil, err
 	}
 
-	var result interface{}
-	json.Unmarshal(data, &result)
-	return result, nil
+	var result container.InspectResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	if result.ContainerJSONBase == nil || result.Config == nil {
+		return nil, fmt.Errorf("invalid inspect response for container %s", containerID)
+	}
+	return &result, nil
+}
+
+func (m *ServerManager) RenameContainer(serverID, containerID, name string) error {
+	if m.IsLocal(serverID) {
+		return m.localDocker.RenameContainer(containerID, name)
+	}
+
+	client := m.getAgentClient(serverID)
+	if client == nil {
+		return ErrServerNotFound
+	}
+
+	return client.RenameContainer(containerID, name)
 }
 
 func (m *ServerManager) StartContainer(serverID, containerID string) error {
@@ -298,9 +404,10 @@ func (m *ServerManager) StartContainer(serverID, containerID string) error {
 	return client.StartContainer(containerID)
 }
 
-func (m *ServerManager) StopContainer(serverID, containerID string) error {
+// StopContainer dừng container, timeout nil dùng stop timeout của container
+func (m *ServerManager) StopContainer(serverID, containerID string, timeout *int) error {
 	if m.IsLocal(serverID) {
-		return m.localDocker.StopContainer(containerID)
+		return m.localDocker.StopContainer(containerID, timeout)
 	}
 
 	client := m.getAgentClient(serverID)
@@ -308,12 +415,12 @@ func (m *ServerManager) StopContainer(serverID, containerID string) error {
 		return ErrServerNotFound
 	}
 
-	return client.StopContainer(containerID)
+	return client.StopContainer(containerID, timeout)
 }
 
-func (m *ServerManager) RestartContainer(serverID, containerID string) error {
+func (m *ServerManager) RestartContainer(serverID, containerID string, timeout *int) error {
 	if m.IsLocal(serverID) {
-		return m.localDocker.RestartContainer(containerID)
+		return m.localDocker.RestartContainer(containerID, timeout)
 	}
 
 	client := m.getAgentClient(serverID)
@@ -321,7 +428,79 @@ func (m *ServerManager) RestartContainer(serverID, containerID string) error {
 		return ErrServerNotFound
 	}
 
-	return client.RestartContainer(containerID)
+	return client.RestartContainer(containerID, timeout)
+}
+
+// UpdateContainer đổi giới hạn tài nguyên và restart policy của container đang chạy (local và agent).
+// Trả về cảnh báo của Docker, e.g. kernel không hỗ trợ giới hạn swap.
+func (m *ServerManager) UpdateContainer(serverID, containerID string, req UpdateContainerRequest) ([]string, error) {
+	update, err := req.ToUpdateConfig()
+	if err != nil {
+		return nil, err
+	}
+	if m.IsLocal(serverID) {
+		return m.localDocker.UpdateContainer(containerID, update)
+	}
+
+	client := m.getAgentClient(serverID)
+	if client == nil {
+		return nil, ErrServerNotFound
+	}
+
+	data, err := client.UpdateContainer(containerID, update)
+	if err != nil {
+		return nil, err
+	}
+	var resp container.UpdateResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Warnings, nil
+}
+
+// KillContainer gửi signal tới process chính của container, trả về ErrInvalidSignal
+// nếu signal không hợp lệ
+func (m *ServerManager) KillContainer(serverID, containerID, signal string) error {
+	signal, err := normalizeSignal(signal)
+	if err != nil {
+		return err
+	}
+	if m.IsLocal(serverID) {
+		return m.localDocker.KillContainer(containerID, signal)
+	}
+
+	client := m.getAgentClient(serverID)
+	if client == nil {
+		return ErrServerNotFound
+	}
+
+	return client.KillContainer(containerID, signal)
+}
+
+func (m *ServerManager) PauseContainer(serverID, containerID string) error {
+	if m.IsLocal(serverID) {
+		return m.localDocker.PauseContainer(containerID)
+	}
+
+	client := m.getAgentClient(serverID)
+	if client == nil {
+		return ErrServerNotFound
+	}
+
+	return client.PauseContainer(containerID)
+}
+
+func (m *ServerManager) UnpauseContainer(serverID, containerID string) error {
+	if m.IsLocal(serverID) {
+		return m.localDocker.UnpauseContainer(containerID)
+	}
+
+	client := m.getAgentClient(serverID)
+	if client == nil {
+		return ErrServerNotFound
+	}
+
+	return client.UnpauseContainer(containerID)
 }
 
 func (m *ServerManager) RemoveContainer(serverID, containerID string, force bool) error {
@@ -359,6 +538,68 @@ func (m *ServerManager) GetContainerLogs(serverID, containerID, tail string) (st
 	return result.Logs, nil
 }
 
+// StreamContainerLogs returns the raw Docker log stream of a container on any server.
+// The caller must close the returned reader.
+func (m *ServerManager) StreamContainerLogs(serverID, containerID string, opts LogStreamOptions) (io.ReadCloser, error) {
+	if m.IsLocal(serverID) {
+		return m.localDocker.StreamContainerLogs(containerID, opts)
+	}
+
+	client := m.getAgentClient(serverID)
+	if client == nil {
+		return nil, ErrServerNotFound
+	}
+
+	return client.StreamContainerLogs(containerID, opts.Follow, opts.Tail, opts.Since)
+}
+
+// ContainerHasTTY reports whether a container was started with a TTY, which
+// determines whether its log stream is multiplexed.
+func (m *ServerManager) ContainerHasTTY(serverID, containerID string) (bool, error) {
+	if m.IsLocal(serverID) {
+		return m.localDocker.ContainerHasTTY(containerID)
+	}
+
+	client := m.getAgentClient(serverID)
+	if client == nil {
+		return false, ErrServerNotFound
+	}
+
+	data, err := client.GetContainer(containerID)
+	if err != nil {
+		return false, err
+	}
+
+	var inspect struct {
+		Config struct {
+			Tty bool `json:"Tty"`
+		} `json:"Config"`
+	}
+	if err := json.Unmarshal(data, &inspect); err != nil {
+		return false, err
+	}
+	return inspect.Config.Tty, nil
+}
+
+// OpenExecSession starts an interactive command in a container on any server.
+// The caller must close the returned session.
+func (m *ServerManager) OpenExecSession(serverID, containerID string, opts ExecOptions) (ExecSession, error) {
+	if m.IsLocal(serverID) {
+		execID, err := m.localDocker.CreateExec(containerID, opts)
+		if err != nil {
+			return nil, err
+		}
+		return m.localDocker.AttachExec(execID)
+	}
+
+	client := m.getAgentClient(serverID)
+	if client == nil {
+		return nil, ErrServerNotFound
+	}
+
+	return client.OpenExec(containerID, opts)
+}
+
 func (m *ServerManager) GetContainerStats(serverID, containerID string) (interface{}, error) {
 	if m.IsLocal(serverID) {
 		return m.localDocker.GetContainerStats(containerID)
@@ -379,11 +620,219 @@ func (m *ServerManager) GetContainerStats(serverID, containerID string) (interfa
 	return result, nil
 }
 
+// ==================== Container Recreate ====================
+
+// recreateStartCheckDelay là thời gian chờ trước khi kiểm tra container mới còn chạy
+const recreateStartCheckDelay = 3 * time.Second
+
+// Thời gian chờ mặc định để container có healthcheck chuyển sang healthy
+const defaultRecreateHealthTimeout = 2 * time.Minute
+
+// ErrRecreateRolledBack được wrap khi container mới lỗi và container cũ đã được khôi phục
+var ErrRecreateRolledBack = errors.New("rolled back to the previous container")
+
+type RecreateOptions struct {
+	Image         string `json:"image"`         // image/tag mới, mặc định giữ image hiện tại
+	SkipPull      bool   `json:"skipPull"`      // không pull image trước khi tạo lại
+	KeepOld       bool   `json:"keepOld"`       // giữ container cũ (đã đổi tên) sau khi thành công
+	HealthTimeout int    `json:"healthTimeout"` // số giây chờ healthcheck báo healthy, mặc định 120
+}
+
+type RecreateResult struct {
+	ID      string `json:"id"`
+	OldID   string `json:"oldId"`
+	Name    string `json:"name"`
+	Image   string `json:"image"`
+	OldName string `json:"oldName,omitempty"` // chỉ có khi KeepOld = true
+}
+
+// RecreateContainer tạo lại container với image mới nhưng giữ nguyên cấu hình.
+// Quy trình: inspect -> pull -> stop -> rename container cũ -> create -> start.
+// Nếu container mới không tạo/khởi động được, hoặc healthcheck báo unhealthy,
+// thì container cũ được khôi phục.
+func (m *ServerManager) RecreateContainer(serverID, containerID string, opts RecreateOptions) (*RecreateResult, error) {
+	inspect, err := m.InspectContainer(serverID, containerID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cấu hình mặc định của image cũ, để image mới áp dụng mặc định của nó
+	oldImage, err := m.GetImageDetails(serverID, inspect.Image)
+	if err != nil {
+		return nil, fmt.Errorf("inspect image: %w", err)
+	}
+	spec, err := SpecFromInspect(inspect, &oldImage.Config)
+	if err != nil {
+		return nil, err
+	}
+	KeepAnonymousVolumes(spec, inspect)
+	if opts.Image != "" {
+		spec.Config.Image = opts.Image
+	}
+	name := spec.Name
+	oldID := inspect.ID
+	wasRunning := inspect.State != nil && inspect.State.Running
+
+	if !opts.SkipPull {
+		if err := m.PullImage(serverID, spec.Config.Image); err != nil {
+			return nil, fmt.Errorf("pull image %s: %w", spec.Config.Image, err)
+		}
+	}
+
+	if wasRunning {
+		if err := m.StopContainer(serverID, oldID, nil); err != nil {
+			return nil, fmt.Errorf("stop container: %w", err)
+		}
+	}
+
+	oldName := fmt.Sprintf("%s_appdock_old_%d", name, time.Now().Unix())
+	if err := m.RenameContainer(serverID, oldID, oldName); err != nil {
+		if restoreErr := m.restoreContainer(serverID, oldID, "", wasRunning); restoreErr != nil {
+			return nil, fmt.Errorf("rename container: %w (%v)", err, restoreErr)
+		}
+		return nil, fmt.Errorf("rename container: %w", err)
+	}
+
+	created, err := m.CreateContainerFromSpec(serverID, spec)
+	if err != nil {
+		if restoreErr := m.restoreContainer(serverID, oldID, name, wasRunning); restoreErr != nil {
+			return nil, fmt.Errorf("create container: %w (%v)", err, restoreErr)
+		}
+		return nil, fmt.Errorf("create container: %w", err)
+	}
+
+	if wasRunning {
+		healthTimeout := defaultRecreateHealthTimeout
+		if opts.HealthTimeout > 0 {
+			healthTimeout = time.Duration(opts.HealthTimeout) * time.Second
+		}
+		if err := m.startAndVerify(serverID, created.ID, healthTimeout); err != nil {
+			m.RemoveContainer(serverID, created.ID, true)
+			// Chỉ báo rollback khi container cũ thực sự đã được khôi phục
+			if restoreErr := m.restoreContainer(serverID, oldID, name, wasRunning); restoreErr != nil {
+				return nil, fmt.Errorf("start new container: %w (%v)", err, restoreErr)
+			}
+			return nil, fmt.Errorf("start new container: %w: %w", err, ErrRecreateRolledBack)
+		}
+	}
+
+	result := &RecreateResult{
+		ID:    created.ID,
+		OldID: shortID(oldID),
+		Name:  name,
+		Image: spec.Config.Image,
+	}
+	if opts.KeepOld {
+		result.OldName = oldName
+	} else {
+		m.RemoveContainer(serverID, oldID, true)
+	}
+	return result, nil
+}
+
+// startAndVerify start container rồi kiểm tra nó vẫn đang chạy sau một khoảng ngắn.
+// Container có healthcheck phải chuyển sang healthy trong healthTimeout.
+func (m *ServerManager) startAndVerify(serverID, containerID string, healthTimeout time.Duration) error {
+	if err := m.StartContainer(serverID, containerID); err != nil {
+		return err
+	}
+	time.Sleep(recreateStartCheckDelay)
+
+	deadline := time.Now().Add(healthTimeout)
+	for {
+		inspect, err := m.InspectContainer(serverID, containerID)
+		if err != nil {
+			return err
+		}
+		if inspect.State == nil || !inspect.State.Running {
+			exitCode := 0
+			if inspect.State != nil {
+				exitCode = inspect.State.ExitCode
+			}
+			return fmt.Errorf("container exited with code %d", exitCode)
+		}
+
+		health := inspect.State.Health
+		if health == nil || health.Status == container.Healthy || health.Status == container.NoHealthcheck {
+			return nil
+		}
+		if health.Status == container.Unhealthy {
+			return fmt.Errorf("container is unhealthy%s", lastHealthOutput(health))
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("container did not become healthy within %s", healthTimeout)
+		}
+		time.Sleep(2 * time.Second)
+	}
+}
+
+// lastHealthOutput trả về output của lần healthcheck gần nhất để đưa vào thông báo lỗi
+func lastHealthOutput(health *container.Health) string {
+	if len(health.Log) == 0 {
+		return ""
+	}
+	output := strings.TrimSpace(health.Log[len(health.Log)-1].Output)
+	if output == "" {
+		return ""
+	}
+	return ": " + firstLine(output, 200)
+}
+
+// restoreContainer khôi phục tên và trạng thái của container cũ khi rollback
+func (m *ServerManager) restoreContainer(serverID, containerID, name string, start bool) error {
+	if name != "" {
+		if err := m.RenameContainer(serverID, containerID, name); err != nil {
+			return fmt.Errorf("restore previous container name %s: %w", name, err)
+		}
+	}
+	if start {
+		if err := m.StartContainer(serverID, containerID); err != nil {
+			return fmt.Errorf("restart previous container: %w", err)
+		}
+	}
+	return nil
+}
+
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
 // ==================== Images ====================
 
-func (m *ServerManager) ListImages(serverID string) (interface{}, error) {
+// ListImages trả về danh sách image, kèm kết quả scan gần nhất nếu có
+func (m *ServerManager) ListImages(serverID string) ([]ImageInfo, error) {
+	var images []ImageInfo
+	if m.IsLocal(serverID) {
+		var err error
+		images, err = m.localDocker.ListImages()
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		client := m.getAgentClient(serverID)
+		if client == nil {
+			return nil, ErrServerNotFound
+		}
+
+		data, err := client.ListImages()
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &images); err != nil {
+			return nil, err
+		}
+	}
+
+	m.attachScanSummaries(serverID, images)
+	return images, nil
+}
+
+func (m *ServerManager) GetImage(serverID, imageID string) (interface{}, error) {
 	if m.IsLocal(serverID) {
-		return m.localDocker.ListImages()
+		return m.localDocker.GetImage(imageID)
 	}
 
 	client := m.getAgentClient(serverID)
@@ -391,7 +840,7 @@ func (m *ServerManager) ListImages(serverID string) (interface{}, error) {
 		return nil, ErrServerNotFound
 	}
 
-	data, err := client.ListImages()
+	data, err := client.GetImage(imageID)
 	if err != nil {
 		return nil, err
 	}
@@ -401,9 +850,10 @@ func (m *ServerManager) ListImages(serverID string) (interface{}, error) {
 	return result, nil
 }
 
-func (m *ServerManager) GetImage(serverID, imageID string) (interface{}, error) {
+// GetImageDetails trả về cấu hình và lịch sử layer của image (local và agent)
+func (m *ServerManager) GetImageDetails(serverID, imageID string) (*ImageDetails, error) {
 	if m.IsLocal(serverID) {
-		return m.localDocker.GetImage(imageID)
+		return m.localDocker.GetImageDetails(imageID)
 	}
 
 	client := m.getAgentClient(serverID)
@@ -415,10 +865,152 @@ func (m *ServerManager) GetImage(serverID, imageID string) (interface{}, error)
 	if err != nil {
 		return nil, err
 	}
+	var img image.InspectResponse
+	if err := json.Unmarshal(data, &img); err != nil {
+		return nil, err
+	}
 
-	var result interface{}
-	json.Unmarshal(data, &result)
-	return result, nil
+	data, err = client.GetImageHistory(img.ID)
+	if err != nil {
+		return nil, err
+	}
+	var history []image.HistoryResponseItem
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, err
+	}
+
+	return newImageDetails(img, history), nil
+}
+
+func (m *ServerManager) PullImage(serverID, ref string) error {
+	if m.IsLocal(serverID) {
+		return m.localDocker.PullImage(ref)
+	}
+
+	client := m.getAgentClient(serverID)
+	if client == nil {
+		return ErrServerNotFound
+	}
+
+	return client.PullImage(ref, m.registryAuth(ref))
+}
+
+// StreamPullImage bắt đầu pull image và trả về stream JSON progress (local và agent)
+func (m *ServerManager) StreamPullImage(serverID, ref string) (io.ReadCloser, error) {
+	if m.IsLocal(serverID) {
+		return m.localDocker.StreamPullImage(ref)
+	}
+
+	client := m.getAgentClient(serverID)
+	if client == nil {
+		return nil, ErrServerNotFound
+	}
+
+	return client.StreamPullImage(ref, m.registryAuth(ref))
+}
+
+// TagImage gắn thêm tag cho image (local và agent)
+func (m *ServerManager) TagImage(serverID, source, target string) error {
+	if m.IsLocal(serverID) {
+		return m.localDocker.TagImage(source, target)
+	}
+
+	client := m.getAgentClient(serverID)
+	if client == nil {
+		return ErrServerNotFound
+	}
+
+	return client.TagImage(source, target)
+}
+
+// StreamPushImage bắt đầu push image và trả về stream JSON progress (local và agent).
+// auth là credentials gửi kèm request; nil thì dùng registry đã lưu theo host của image.
+func (m *ServerManager) StreamPushImage(serverID, ref string, auth *registry.AuthConfig) (io.ReadCloser, error) {
+	registryAuth, err := m.pushAuth(ref, auth)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.IsLocal(serverID) {
+		return m.localDocker.StreamPushImage(ref, registryAuth)
+	}
+
+	client := m.getAgentClient(serverID)
+	if client == nil {
+		return nil, ErrServerNotFound
+	}
+
+	return client.StreamPushImage(ref, registryAuth)
+}
+
+// PushImage push image và chờ tới khi hoàn tất
+func (m *ServerManager) PushImage(serverID, ref string, auth *registry.AuthConfig) error {
+	reader, err := m.StreamPushImage(serverID, ref, auth)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	return DecodeProgress(reader, nil)
+}
+
+// pushAuth trả về X-Registry-Auth cho push. Daemon luôn cần header này,
+// nên khi không có credentials sẽ gửi một AuthConfig rỗng.
+func (m *ServerManager) pushAuth(ref string, auth *registry.AuthConfig) (string, error) {
+	if auth != nil {
+		return registry.EncodeAuthConfig(*auth)
+	}
+	if encoded := m.registryAuth(ref); encoded != "" {
+		return encoded, nil
+	}
+	return registry.EncodeAuthConfig(registry.AuthConfig{})
+}
+
+// RemoveImages xóa nhiều images cùng lúc (local và agent)
+func (m *ServerManager) RemoveImages(serverID string, ids []string, force bool) (*BulkDeleteResult, error) {
+	if m.IsLocal(serverID) {
+		return m.localDocker.RemoveImages(ids, force)
+	}
+
+	client := m.getAgentClient(serverID)
+	if client == nil {
+		return nil, ErrServerNotFound
+	}
+
+	data, err := client.RemoveImages(ids, force)
+	if err != nil {
+		return nil, err
+	}
+
+	var result BulkDeleteResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// RegistryLogin kiểm tra đăng nhập registry từ Docker daemon của server
+func (m *ServerManager) RegistryLogin(serverID string, auth registry.AuthConfig) (string, error) {
+	if m.IsLocal(serverID) {
+		return m.localDocker.RegistryLogin(auth)
+	}
+
+	client := m.getAgentClient(serverID)
+	if client == nil {
+		return "", ErrServerNotFound
+	}
+
+	data, err := client.RegistryLogin(auth)
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		Status string `json:"status"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "", err
+	}
+	return result.Status, nil
 }
 
 func (m *ServerManager) RemoveImage(serverID, imageID string, force bool) error {
@@ -0,0 +1,387 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Thay đổi sẽ áp dụng cho từng service khi deploy stack
+const (
+	StackChangeCreate    = "create"
+	StackChangeRecreate  = "recreate"
+	StackChangeRemove    = "remove"
+	StackChangeUnchanged = "unchanged"
+)
+
+type StackServiceChange struct {
+	Service   string `json:"service"`
+	Container string `json:"container"`
+	Image     string `json:"image,omitempty"`
+	Action    string `json:"action"`
+	Running   bool   `json:"running"` // trạng thái hiện tại của container (nếu có)
+}
+
+// StackDiff so sánh trạng thái mong muốn (compose file) với trạng thái đang chạy trên server
+type StackDiff struct {
+	Project  string               `json:"project"`
+	Services []StackServiceChange `json:"services"`
+	Networks []string             `json:"networks"` // network sẽ được tạo
+	Volumes  []string             `json:"volumes"`  // volume sẽ được tạo
+}
+
+// HasChanges cho biết deploy có làm thay đổi gì trên server hay không
+func (d *StackDiff) HasChanges() bool {
+	if len(d.Networks) > 0 || len(d.Volumes) > 0 {
+		return true
+	}
+	for _, change := range d.Services {
+		if change.Action != StackChangeUnchanged || !change.Running {
+			return true
+		}
+	}
+	return false
+}
+
+// DiffStack tính các thay đổi cần thiết để đưa project về đúng plan
+func (m *ServerManager) DiffStack(serverID string, plan *StackPlan) (*StackDiff, error) {
+	diff, _, err := m.diffStack(serverID, plan)
+	return diff, err
+}
+
+// diffStack trả về diff kèm danh sách container hiện có của từng service
+func (m *ServerManager) diffStack(serverID string, plan *StackPlan) (*StackDiff, map[string][]ContainerInfo, error) {
+	containers, err := m.listContainerInfos(serverID, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	existing := make(map[string][]ContainerInfo)
+	for _, c := range containers {
+		if c.Labels[ComposeProjectLabel] == plan.Project {
+			svc := c.Labels[ComposeServiceLabel]
+			existing[svc] = append(existing[svc], c)
+		}
+	}
+
+	diff := &StackDiff{
+		Project:  plan.Project,
+		Services: make([]StackServiceChange, 0, len(plan.Services)),
+		Networks: make([]string, 0),
+		Volumes:  make([]string, 0),
+	}
+
+	planned := make(map[string]bool, len(plan.Services))
+	for _, svc := range plan.Services {
+		planned[svc.Name] = true
+		change := StackServiceChange{
+			Service:   svc.Name,
+			Container: svc.ContainerName,
+			Image:     svc.Image,
+			Action:    StackChangeCreate,
+		}
+
+		current := existing[svc.Name]
+		if len(current) > 0 {
+			change.Action = StackChangeUnchanged
+			change.Running = current[0].State == "running"
+			if len(current) > 1 || current[0].Name != svc.ContainerName ||
+				current[0].Labels[ComposeConfigHashLabel] != svc.ConfigHash {
+				change.Action = StackChangeRecreate
+			}
+		}
+		diff.Services = append(diff.Services, change)
+	}
+
+	// Service đã bị xóa khỏi compose file
+	orphans := make([]string, 0)
+	for svc := range existing {
+		if !planned[svc] {
+			orphans = append(orphans, svc)
+		}
+	}
+	sort.Strings(orphans)
+	for _, svc := range orphans {
+		for _, c := range existing[svc] {
+			diff.Services = append(diff.Services, StackServiceChange{
+				Service:   svc,
+				Container: c.Name,
+				Image:     c.Image,
+				Action:    StackChangeRemove,
+				Running:   c.State == "running",
+			})
+		}
+	}
+
+	networkNames, err := m.listNetworkNames(serverID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, n := range plan.Networks {
+		if !n.External && !networkNames[n.Name] {
+			diff.Networks = append(diff.Networks, n.Name)
+		}
+	}
+
+	volumeNames, err := m.listVolumeNames(serverID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, v := range plan.Volumes {
+		if !v.External && !volumeNames[v.Name] {
+			diff.Volumes = append(diff.Volumes, v.Name)
+		}
+	}
+
+	return diff, existing, nil
+}
+
+// DeployStack tạo/cập nhật project theo plan: tạo network và volume còn thiếu, xóa container
+// của service không còn trong compose file, tạo lại container có cấu hình thay đổi và
+// khởi động các service theo thứ tự phụ thuộc. Diff đã áp dụng được trả về kể cả khi lỗi.
+func (m *ServerManager) DeployStack(serverID string, plan *StackPlan) (*StackDiff, error) {
+	diff, existing, err := m.diffStack(serverID, plan)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.ensureStackResources(serverID, plan, diff); err != nil {
+		return diff, err
+	}
+
+	for _, change := range diff.Services {
+		if change.Action != StackChangeRemove {
+			continue
+		}
+		for _, c := range existing[change.Service] {
+			if c.Name == change.Container {
+				if err := m.RemoveContainer(serverID, c.ID, true); err != nil {
+					return diff, fmt.Errorf("remove %s: %w", change.Container, err)
+				}
+			}
+		}
+	}
+
+	for i, svc := range plan.Services {
+		change := diff.Services[i]
+		current := existing[svc.Name]
+
+		switch change.Action {
+		case StackChangeUnchanged:
+			if !change.Running {
+				if err := m.StartContainer(serverID, current[0].ID); err != nil {
+					return diff, fmt.Errorf("service %s: %w", svc.Name, err)
+				}
+			}
+			continue
+		case StackChangeRecreate:
+			// Giữ anonymous volume của container cũ trước khi xóa nó
+			inspect, err := m.InspectContainer(serverID, current[0].ID)
+			if err != nil {
+				return diff, fmt.Errorf("service %s: %w", svc.Name, err)
+			}
+			KeepAnonymousVolumes(svc.Spec, inspect)
+			for _, c := range current {
+				if err := m.RemoveContainer(serverID, c.ID, true); err != nil {
+					return diff, fmt.Errorf("service %s: %w", svc.Name, err)
+				}
+			}
+		}
+
+		created, err := m.CreateContainerFromSpec(serverID, svc.Spec)
+		if err != nil {
+			return diff, fmt.Errorf("service %s: %w", svc.Name, err)
+		}
+		if err := m.StartContainer(serverID, created.ID); err != nil {
+			return diff, fmt.Errorf("service %s: %w", svc.Name, err)
+		}
+	}
+
+	return diff, nil
+}
+
+// ensureStackResources tạo network/volume còn thiếu và kiểm tra tài nguyên external
+func (m *ServerManager) ensureStackResources(serverID string, plan *StackPlan, diff *StackDiff) error {
+	missingNetworks := make(map[string]bool, len(diff.Networks))
+	for _, name := range diff.Networks {
+		missingNetworks[name] = true
+	}
+	networkNames, err := m.listNetworkNames(serverID)
+	if err != nil {
+		return err
+	}
+	for _, n := range plan.Networks {
+		if n.External {
+			if !networkNames[n.Name] {
+				return fmt.Errorf("external network %q not found", n.Name)
+			}
+			continue
+		}
+		if !missingNetworks[n.Name] {
+			continue
+		}
+		_, err := m.CreateNetwork(serverID, CreateNetworkRequest{
+			Name:       n.Name,
+			Driver:     n.Driver,
+			Internal:   n.Internal,
+			Attachable: n.Attachable,
+			Labels:     n.Labels,
+		})
+		if err != nil {
+			return fmt.Errorf("create network %s: %w", n.Name, err)
+		}
+	}
+
+	missingVolumes := make(map[string]bool, len(diff.Volumes))
+	for _, name := range diff.Volumes {
+		missingVolumes[name] = true
+	}
+	volumeNames, err := m.listVolumeNames(serverID)
+	if err != nil {
+		return err
+	}
+	for _, v := range plan.Volumes {
+		if v.External {
+			if !volumeNames[v.Name] {
+				return fmt.Errorf("external volume %q not found", v.Name)
+			}
+			continue
+		}
+		if !missingVolumes[v.Name] {
+			continue
+		}
+		_, err := m.CreateVolume(serverID, CreateVolumeRequest{
+			Name:   v.Name,
+			Driver: v.Driver,
+			Labels: v.Labels,
+		})
+		if err != nil {
+			return fmt.Errorf("create volume %s: %w", v.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// RemoveStack xóa toàn bộ container và network của project.
+// Volume chỉ bị xóa khi removeVolumes = true để tránh mất dữ liệu.
+func (m *ServerManager) RemoveStack(serverID, project string, removeVolumes bool) (*ComposeActionResult, error) {
+	result, err := m.ComposeProjectAction(serverID, project, ComposeActionRemove)
+	if err == ErrComposeProjectNotFound {
+		result = &ComposeActionResult{
+			Project: project,
+			Action:  ComposeActionRemove,
+			Success: make([]string, 0),
+			Failed:  make([]FailedItem, 0),
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	networks, err := m.listNetworkInfos(serverID)
+	if err != nil {
+		return result, err
+	}
+	for _, n := range networks {
+		if n.Labels[ComposeProjectLabel] != project {
+			continue
+		}
+		result.Total++
+		if err := m.RemoveNetwork(serverID, n.Name); err != nil {
+			result.Failed = append(result.Failed, FailedItem{ID: n.Name, Error: err.Error()})
+		} else {
+			result.Success = append(result.Success, n.Name)
+		}
+	}
+
+	if removeVolumes {
+		volumes, err := m.listVolumeInfos(serverID)
+		if err != nil {
+			return result, err
+		}
+		for _, v := range volumes {
+			if v.Labels[ComposeProjectLabel] != project {
+				continue
+			}
+			result.Total++
+			if err := m.RemoveVolume(serverID, v.Name, false); err != nil {
+				result.Failed = append(result.Failed, FailedItem{ID: v.Name, Error: err.Error()})
+			} else {
+				result.Success = append(result.Success, v.Name)
+			}
+		}
+	}
+
+	result.Succeeded = len(result.Success)
+	return result, nil
+}
+
+// listNetworkInfos trả về danh sách network dạng typed cho cả local và agent
+func (m *ServerManager) listNetworkInfos(serverID string) ([]NetworkInfo, error) {
+	if m.IsLocal(serverID) {
+		return m.localDocker.ListNetworks()
+	}
+
+	client := m.getAgentClient(serverID)
+	if client == nil {
+		return nil, ErrServerNotFound
+	}
+
+	data, err := client.ListNetworks()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []NetworkInfo
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// listVolumeInfos trả về danh sách volume dạng typed cho cả local và agent
+func (m *ServerManager) listVolumeInfos(serverID string) ([]VolumeInfo, error) {
+	if m.IsLocal(serverID) {
+		return m.localDocker.ListVolumes()
+	}
+
+	client := m.getAgentClient(serverID)
+	if client == nil {
+		return nil, ErrServerNotFound
+	}
+
+	data, err := client.ListVolumes()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []VolumeInfo
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (m *ServerManager) listNetworkNames(serverID string) (map[string]bool, error) {
+	networks, err := m.listNetworkInfos(serverID)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool, len(networks))
+	for _, n := range networks {
+		names[n.Name] = true
+	}
+	return names, nil
+}
+
+func (m *ServerManager) listVolumeNames(serverID string) (map[string]bool, error) {
+	volumes, err := m.listVolumeInfos(serverID)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool, len(volumes))
+	for _, v := range volumes {
+		names[v.Name] = true
+	}
+	return names, nil
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"appdock/internal/models"
+)
+
+var (
+	ErrStackNotFound      = errors.New("stack not found")
+	ErrStackAlreadyExists = errors.New("stack already exists on this server")
+	ErrInvalidStackName   = errors.New("stack name must contain only lowercase letters, digits, '-' and '_', and start with a letter or digit")
+)
+
+// Tên stack được dùng làm compose project name nên phải theo quy tắc của Compose
+var stackNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+type StackStore struct {
+	stacks   map[string]*models.Stack
+	filePath string
+	mu       sync.RWMutex
+}
+
+func NewStackStore(dataDir string) (*StackStore, error) {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, err
+	}
+
+	store := &StackStore{
+		stacks:   make(map[string]*models.Stack),
+		filePath: filepath.Join(dataDir, "stacks.json"),
+	}
+
+	if err := store.load(); err != nil {
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	return store, nil
+}
+
+func (s *StackStore) load() error {
+	data, err := os.ReadFile(s.filePath)
+	if err != nil {
+		return err
+	}
+
+	var stacks []*models.Stack
+	if err := json.Unmarshal(data, &stacks); err != nil {
+		return err
+	}
+
+	s.stacks = make(map[string]*models.Stack)
+	for _, stack := range stacks {
+		s.stacks[stack.ID] = stack
+	}
+
+	return nil
+}
+
+func (s *StackStore) save() error {
+	stacks := make([]*models.Stack, 0, len(s.stacks))
+	for _, stack := range s.stacks {
+		stacks = append(stacks, stack)
+	}
+
+	data, err := json.MarshalIndent(stacks, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.filePath, data, 0644)
+}
+
+// List trả về các stack của một server
+func (s *StackStore) List(serverID string) []*models.Stack {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	serverID = normalizeServerID(serverID)
+	stacks := make([]*models.Stack, 0, len(s.stacks))
+	for _, stack := range s.stacks {
+		if stack.ServerID == serverID {
+			stacks = append(stacks, copyStack(stack))
+		}
+	}
+
+	return stacks
+}
+
+func (s *StackStore) Get(id string) (*models.Stack, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stack, exists := s.stacks[id]
+	if !exists {
+		return nil, ErrStackNotFound
+	}
+
+	return copyStack(stack), nil
+}
+
+func (s *StackStore) Create(serverID string, req models.CreateStackRequest) (*models.Stack, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !stackNamePattern.MatchString(req.Name) {
+		return nil, ErrInvalidStackName
+	}
+
+	serverID = normalizeServerID(serverID)
+	for _, existing := range s.stacks {
+		if existing.ServerID == serverID && existing.Name == req.Name {
+			return nil, ErrStackAlreadyExists
+		}
+	}
+
+	stack := models.NewStack(serverID, req)
+	s.stacks[stack.ID] = stack
+
+	if err := s.save(); err != nil {
+		delete(s.stacks, stack.ID)
+		return nil, err
+	}
+
+	return copyStack(stack), nil
+}
+
+func (s *StackStore) Update(id string, req models.UpdateStackRequest) (*models.Stack, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stack, exists := s.stacks[id]
+	if !exists {
+		return nil, ErrStackNotFound
+	}
+
+	if req.Compose != nil {
+		stack.Compose = *req.Compose
+	}
+	if req.Env != nil {
+		stack.Env = req.Env
+	}
+	stack.UpdatedAt = time.Now()
+
+	if err := s.save(); err != nil {
+		return nil, err
+	}
+
+	return copyStack(stack), nil
+}
+
+// SetStatus ghi lại kết quả deploy/teardown gần nhất
+func (s *StackStore) SetStatus(id string, status models.StackStatus, deployErr error) (*models.Stack, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stack, exists := s.stacks[id]
+	if !exists {
+		return nil, ErrStackNotFound
+	}
+
+	now := time.Now()
+	stack.Status = status
+	stack.LastError = ""
+	if deployErr != nil {
+		stack.LastError = deployErr.Error()
+	}
+	if status == models.StackStatusDeployed {
+		stack.DeployedAt = &now
+	}
+	stack.UpdatedAt = now
+
+	if err := s.save(); err != nil {
+		return nil, err
+	}
+
+	return copyStack(stack), nil
+}
+
+func (s *StackStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stack, exists := s.stacks[id]
+	if !exists {
+		return ErrStackNotFound
+	}
+
+	delete(s.stacks, id)
+
+	if err := s.save(); err != nil {
+		s.stacks[id] = stack
+		return err
+	}
+
+	return nil
+}
+
+// copyStack trả về bản sao để caller không sửa trực tiếp dữ liệu trong store
+func copyStack(stack *models.Stack) *models.Stack {
+	copied := *stack
+	if stack.Env != nil {
+		copied.Env = make(map[string]string, len(stack.Env))
+		for k, v := range stack.Env {
+			copied.Env[k] = v
+		}
+	}
+	if stack.DeployedAt != nil {
+		deployedAt := *stack.DeployedAt
+		copied.DeployedAt = &deployedAt
+	}
+	return &copied
+}
+
+func normalizeServerID(serverID string) string {
+	if serverID == "" {
+		return "local"
+	}
+	return serverID
+}
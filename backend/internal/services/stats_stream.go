@@ -0,0 +1,287 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Chu kỳ cập nhật danh sách container đang chạy khi stream stats của mọi container
+const statsRefreshInterval = 10 * time.Second
+
+// ContainerStatsSample là mẫu stats mới nhất của một container trong stream
+type ContainerStatsSample struct {
+	ID   string    `json:"id"`
+	Name string    `json:"name"`
+	Read time.Time `json:"read"`
+	ContainerStats
+}
+
+// ContainerStatsBatch gom các mẫu mới trong một chu kỳ gửi
+type ContainerStatsBatch struct {
+	Stats   []ContainerStatsSample `json:"stats"`
+	Removed []string               `json:"removed,omitempty"` // container đã dừng, không còn stream
+}
+
+// StreamContainerStats trả về stream stats của Docker cho container trên server (local và agent).
+// Caller phải đóng reader để dừng stream.
+func (m *ServerManager) StreamContainerStats(serverID, containerID string) (io.ReadCloser, error) {
+	if m.IsLocal(serverID) {
+		return m.localDocker.StreamContainerStats(containerID)
+	}
+
+	client := m.getAgentClient(serverID)
+	if client == nil {
+		return nil, ErrServerNotFound
+	}
+
+	return client.StreamContainerStats(containerID)
+}
+
+// DecodeContainerStats đọc stream stats của Docker và gọi fn với từng mẫu đã tính.
+// Dừng khi stream kết thúc hoặc fn trả về lỗi.
+func DecodeContainerStats(r io.Reader, fn func(read time.Time, stats *ContainerStats) error) error {
+	decoder := json.NewDecoder(r)
+	for {
+		var statsJSON StatsJSON
+		if err := decoder.Decode(&statsJSON); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if err := fn(statsJSON.Read, statsJSON.ContainerStats()); err != nil {
+			return err
+		}
+	}
+}
+
+// statsWatcher giữ mẫu mới nhất của các container đang được stream
+type statsWatcher struct {
+	m        *ServerManager
+	ctx      context.Context
+	serverID string
+
+	mu      sync.Mutex
+	streams map[string]*statsStream
+	latest  map[string]ContainerStatsSample
+	removed []string
+	// Container có stream đã kết thúc, chờ refresh xác định còn chạy hay không
+	pending map[string]bool
+	ended   chan statsStreamEnd
+}
+
+// statsStream là stream stats của một container, cancel để dừng
+type statsStream struct {
+	id     string
+	cancel context.CancelFunc
+}
+
+// statsStreamEnd báo stream đã kết thúc, err khác nil nếu stream lỗi
+type statsStreamEnd struct {
+	stream *statsStream
+	err    error
+}
+
+func (w *statsWatcher) start(id, name string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.streams[id]; ok {
+		return
+	}
+	delete(w.pending, id)
+	ctx, cancel := context.WithCancel(w.ctx)
+	stream := &statsStream{id: id, cancel: cancel}
+	w.streams[id] = stream
+
+	go func() {
+		var streamErr error
+		defer func() {
+			select {
+			case w.ended <- statsStreamEnd{stream: stream, err: streamErr}:
+			case <-w.ctx.Done():
+			}
+		}()
+		reader, err := w.m.StreamContainerStats(w.serverID, id)
+		if err != nil {
+			streamErr = err
+			return
+		}
+		stop := context.AfterFunc(ctx, func() { reader.Close() })
+		defer stop()
+		defer reader.Close()
+
+		err = DecodeContainerStats(reader, func(read time.Time, stats *ContainerStats) error {
+			w.mu.Lock()
+			defer w.mu.Unlock()
+			// Stream đã bị dừng (container dừng rồi chạy lại sẽ có stream mới)
+			if w.streams[id] != stream {
+				return context.Canceled
+			}
+			w.latest[id] = ContainerStatsSample{ID: id, Name: name, Read: read, ContainerStats: *stats}
+			return nil
+		})
+		// Lỗi do chính watcher dừng stream không cần báo
+		if err != nil && ctx.Err() == nil && !errors.Is(err, context.Canceled) {
+			streamErr = err
+		}
+	}()
+}
+
+// stop dừng stream nếu nó vẫn là stream hiện tại của container và báo container đã dừng
+func (w *statsWatcher) stop(stream *statsStream) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	stream.cancel()
+	if w.streams[stream.id] != stream {
+		return
+	}
+	delete(w.streams, stream.id)
+	delete(w.latest, stream.id)
+	w.removed = append(w.removed, stream.id)
+}
+
+// detach bỏ stream đã kết thúc nhưng chưa báo container đã dừng: stream có thể lỗi tạm thời
+// trong khi container vẫn chạy. Lần refresh sau sẽ mở lại stream hoặc báo container đã dừng.
+func (w *statsWatcher) detach(stream *statsStream) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	stream.cancel()
+	if w.streams[stream.id] != stream {
+		return
+	}
+	delete(w.streams, stream.id)
+	w.pending[stream.id] = true
+}
+
+func (w *statsWatcher) active() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.streams)
+}
+
+// refresh bắt đầu stream cho container mới chạy và dừng stream của container đã dừng
+func (w *statsWatcher) refresh() error {
+	containers, err := w.m.listContainerInfos(w.serverID, false)
+	if err != nil {
+		return err
+	}
+	running := make(map[string]bool, len(containers))
+	for _, c := range containers {
+		if c.State != "running" {
+			continue
+		}
+		running[c.ID] = true
+		w.start(c.ID, c.Name)
+	}
+
+	w.mu.Lock()
+	var stopped []*statsStream
+	for id, stream := range w.streams {
+		if !running[id] {
+			stopped = append(stopped, stream)
+		}
+	}
+	for id := range w.pending {
+		if !running[id] {
+			delete(w.pending, id)
+			delete(w.latest, id)
+			w.removed = append(w.removed, id)
+		}
+	}
+	w.mu.Unlock()
+	for _, stream := range stopped {
+		w.stop(stream)
+	}
+	return nil
+}
+
+// flush lấy các mẫu nhận được từ lần flush trước, sắp xếp theo tên container
+func (w *statsWatcher) flush() ContainerStatsBatch {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	batch := ContainerStatsBatch{
+		Stats:   make([]ContainerStatsSample, 0, len(w.latest)),
+		Removed: w.removed,
+	}
+	for id, sample := range w.latest {
+		batch.Stats = append(batch.Stats, sample)
+		delete(w.latest, id)
+	}
+	w.removed = nil
+	sort.Slice(batch.Stats, func(i, j int) bool {
+		return batch.Stats[i].Name < batch.Stats[j].Name
+	})
+	return batch
+}
+
+// WatchContainerStats stream stats của một container (containerID khác rỗng) hoặc mọi container
+// đang chạy trên server, dựa trên streaming stats của Docker. Mỗi interval, emit nhận mẫu mới nhất
+// của các container có dữ liệu mới. Dừng khi ctx bị hủy, emit trả về lỗi, hoặc stream của
+// container duy nhất kết thúc (trả về lỗi của stream nếu có).
+func (m *ServerManager) WatchContainerStats(ctx context.Context, serverID, containerID string, interval time.Duration, emit func(ContainerStatsBatch) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	w := &statsWatcher{
+		m:        m,
+		ctx:      ctx,
+		serverID: serverID,
+		streams:  make(map[string]*statsStream),
+		latest:   make(map[string]ContainerStatsSample),
+		pending:  make(map[string]bool),
+		ended:    make(chan statsStreamEnd),
+	}
+
+	if containerID != "" {
+		inspect, err := m.InspectContainer(serverID, containerID)
+		if err != nil {
+			return err
+		}
+		w.start(inspect.ID, strings.TrimPrefix(inspect.Name, "/"))
+	} else if err := w.refresh(); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	refreshTicker := time.NewTicker(statsRefreshInterval)
+	defer refreshTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case end := <-w.ended:
+			if containerID == "" {
+				w.detach(end.stream)
+				continue
+			}
+			// Stream của container duy nhất kết thúc: container đã dừng hoặc stream lỗi
+			w.stop(end.stream)
+			if w.active() == 0 {
+				if end.err != nil {
+					return end.err
+				}
+				return emit(w.flush())
+			}
+		case <-refreshTicker.C:
+			if containerID == "" {
+				w.refresh()
+			}
+		case <-ticker.C:
+			batch := w.flush()
+			if len(batch.Stats) == 0 && len(batch.Removed) == 0 {
+				continue
+			}
+			if err := emit(batch); err != nil {
+				return err
+			}
+		}
+	}
+}
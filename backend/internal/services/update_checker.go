@@ -0,0 +1,392 @@
+package services
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/distribution/reference"
+	"github.com/docker/docker/api/types/registry"
+)
+
+// Lần kiểm tra đầu tiên chờ một lúc để agent kịp kết nối sau khi AppDock khởi động
+const updateCheckStartDelay = time.Minute
+
+// ImageUpdate là kết quả so sánh digest của image đang chạy với tag trên registry
+type ImageUpdate struct {
+	ServerID        string    `json:"serverId"`
+	Image           string    `json:"image"`      // tag đã chuẩn hóa, e.g. "docker.io/library/nginx:latest"
+	Containers      []string  `json:"containers"` // container đang chạy image này
+	LocalDigest     string    `json:"localDigest,omitempty"`
+	RemoteDigest    string    `json:"remoteDigest,omitempty"`
+	UpdateAvailable bool      `json:"updateAvailable"`
+	CheckedAt       time.Time `json:"checkedAt"`
+	Error           string    `json:"error,omitempty"`
+}
+
+// ServerUpdateResult kết quả kiểm tra trên một server
+type ServerUpdateResult struct {
+	ServerID   string        `json:"serverId"`
+	ServerName string        `json:"serverName"`
+	Updates    []ImageUpdate `json:"updates"`
+	Error      string        `json:"error,omitempty"`
+}
+
+// UpdateChecker định kỳ kiểm tra image của các container đang chạy trên mọi server
+// có digest mới trên registry hay chưa. Kết quả được lưu trong image_updates.json.
+type UpdateChecker struct {
+	serverManager *ServerManager
+	registry      *RegistryClient
+	updates       map[string]*ImageUpdate // serverID|image -> kết quả
+	filePath      string
+	interval      time.Duration
+	checkMu       sync.Mutex // chỉ chạy một lượt kiểm tra tại một thời điểm
+	mu            sync.RWMutex
+}
+
+// NewUpdateChecker tạo checker; interval = 0 tắt kiểm tra định kỳ (vẫn kiểm tra được qua API)
+func NewUpdateChecker(dataDir string, sm *ServerManager, interval time.Duration) (*UpdateChecker, error) {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, err
+	}
+
+	checker := &UpdateChecker{
+		serverManager: sm,
+		registry:      NewRegistryClient(),
+		updates:       make(map[string]*ImageUpdate),
+		filePath:      filepath.Join(dataDir, "image_updates.json"),
+		interval:      interval,
+	}
+
+	if err := checker.load(); err != nil {
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	if interval > 0 {
+		go checker.loop()
+	}
+
+	return checker, nil
+}
+
+func (u *UpdateChecker) load() error {
+	data, err := os.ReadFile(u.filePath)
+	if err != nil {
+		return err
+	}
+
+	var updates []*ImageUpdate
+	if err := json.Unmarshal(data, &updates); err != nil {
+		return err
+	}
+	for _, update := range updates {
+		u.updates[updateKey(update.ServerID, update.Image)] = update
+	}
+	return nil
+}
+
+func (u *UpdateChecker) save() error {
+	updates := make([]*ImageUpdate, 0, len(u.updates))
+	for _, update := range u.updates {
+		updates = append(updates, update)
+	}
+
+	data, err := json.MarshalIndent(updates, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(u.filePath, data, 0644)
+}
+
+func updateKey(serverID, image string) string {
+	return normalizeServerID(serverID) + "|" + image
+}
+
+func (u *UpdateChecker) loop() {
+	time.Sleep(updateCheckStartDelay)
+	u.CheckAll()
+
+	ticker := time.NewTicker(u.interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		u.CheckAll()
+	}
+}
+
+// CheckAll kiểm tra mọi server; digest của cùng một tag chỉ hỏi registry một lần
+func (u *UpdateChecker) CheckAll() []ServerUpdateResult {
+	u.checkMu.Lock()
+	defer u.checkMu.Unlock()
+
+	servers := u.serverManager.store.List()
+	results := make([]ServerUpdateResult, len(servers))
+	digests := newDigestCache()
+
+	var wg sync.WaitGroup
+	for i, server := range servers {
+		wg.Add(1)
+		go func(i int, serverID, name string) {
+			defer wg.Done()
+			item := ServerUpdateResult{ServerID: serverID, ServerName: name}
+			updates, err := u.checkServer(serverID, digests)
+			if err != nil {
+				item.Error = err.Error()
+				log.Printf("Image update check failed for server %s: %v", name, err)
+			} else {
+				item.Updates = updates
+			}
+			results[i] = item
+		}(i, server.ID, server.Name)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// CheckServer kiểm tra ngay các container đang chạy trên một server
+func (u *UpdateChecker) CheckServer(serverID string) ([]ImageUpdate, error) {
+	u.checkMu.Lock()
+	defer u.checkMu.Unlock()
+	return u.checkServer(serverID, newDigestCache())
+}
+
+func (u *UpdateChecker) checkServer(serverID string, digests *digestCache) ([]ImageUpdate, error) {
+	serverID = normalizeServerID(serverID)
+
+	containers, err := u.serverManager.listContainerInfos(serverID, false)
+	if err != nil {
+		return nil, err
+	}
+	images, err := u.serverManager.ListImages(serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	imagesByID := make(map[string]*ImageInfo, len(images))
+	imagesByTag := make(map[string]*ImageInfo)
+	for i := range images {
+		imagesByID[images[i].ID] = &images[i]
+		for _, tag := range images[i].RepoTags {
+			imagesByTag[tag] = &images[i]
+		}
+	}
+
+	// Gom container theo tag để mỗi image chỉ kiểm tra một lần
+	type imageUsage struct {
+		ref        reference.NamedTagged
+		image      *ImageInfo
+		containers []string
+	}
+	usages := make(map[string]*imageUsage)
+	for _, c := range containers {
+		ref := normalizeImageTag(c.Image)
+		if ref == nil {
+			// Container chạy theo digest hoặc image ID, không có tag để so sánh
+			continue
+		}
+		usage := usages[ref.String()]
+		if usage == nil {
+			img := imagesByID[shortImageID(c.ImageID)]
+			if img == nil {
+				img = imagesByTag[reference.FamiliarString(ref)]
+			}
+			usage = &imageUsage{ref: ref, image: img}
+			usages[ref.String()] = usage
+		}
+		usage.containers = append(usage.containers, c.Name)
+	}
+
+	now := time.Now()
+	updates := make([]ImageUpdate, 0, len(usages))
+	for image, usage := range usages {
+		update := ImageUpdate{
+			ServerID:   serverID,
+			Image:      image,
+			Containers: usage.containers,
+			CheckedAt:  now,
+		}
+		u.compareDigests(&update, usage.ref, usage.image, digests)
+		updates = append(updates, update)
+	}
+	sort.Slice(updates, func(i, j int) bool { return updates[i].Image < updates[j].Image })
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	for key := range u.updates {
+		if strings.HasPrefix(key, serverID+"|") {
+			delete(u.updates, key)
+		}
+	}
+	for i := range updates {
+		update := updates[i]
+		u.updates[updateKey(serverID, update.Image)] = &update
+	}
+	if err := u.save(); err != nil {
+		log.Printf("Could not save image update results: %v", err)
+	}
+
+	return updates, nil
+}
+
+// compareDigests so sánh digest của image local với digest hiện tại của tag trên registry
+func (u *UpdateChecker) compareDigests(update *ImageUpdate, ref reference.NamedTagged, img *ImageInfo, digests *digestCache) {
+	localDigests := repoDigestsFor(img, ref)
+	if len(localDigests) == 0 {
+		update.Error = "image has no registry digest (built locally or loaded from an archive)"
+		return
+	}
+	update.LocalDigest = localDigests[0]
+
+	remoteDigest, err := digests.get(ref, func() (string, error) {
+		return u.registry.ManifestDigest(ref, u.serverManager.registryAuthConfig(ref.String()))
+	})
+	if err != nil {
+		update.Error = err.Error()
+		return
+	}
+	update.RemoteDigest = remoteDigest
+	update.UpdateAvailable = true
+	for _, digest := range localDigests {
+		if digest == remoteDigest {
+			update.LocalDigest = digest
+			update.UpdateAvailable = false
+			break
+		}
+	}
+}
+
+// List trả về kết quả kiểm tra gần nhất của một server
+func (u *UpdateChecker) List(serverID string) []ImageUpdate {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	serverID = normalizeServerID(serverID)
+	updates := make([]ImageUpdate, 0)
+	for _, update := range u.updates {
+		if update.ServerID == serverID {
+			updates = append(updates, *update)
+		}
+	}
+	sort.Slice(updates, func(i, j int) bool { return updates[i].Image < updates[j].Image })
+	return updates
+}
+
+// UpdateAvailable cho biết image (tên như trong ContainerInfo.Image) có bản mới trên registry
+func (u *UpdateChecker) UpdateAvailable(serverID, image string) bool {
+	ref := normalizeImageTag(image)
+	if ref == nil {
+		return false
+	}
+
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	update := u.updates[updateKey(serverID, ref.String())]
+	return update != nil && update.UpdateAvailable
+}
+
+// normalizeImageTag chuẩn hóa "nginx" thành "docker.io/library/nginx:latest".
+// Trả về nil với image ID hoặc ref theo digest.
+func normalizeImageTag(image string) reference.NamedTagged {
+	if strings.HasPrefix(image, "sha256:") {
+		return nil
+	}
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return nil
+	}
+	if _, ok := named.(reference.Digested); ok {
+		return nil
+	}
+	tagged, ok := reference.TagNameOnly(named).(reference.NamedTagged)
+	if !ok {
+		return nil
+	}
+	return tagged
+}
+
+// repoDigestsFor trả về các digest của image thuộc cùng repository với ref
+func repoDigestsFor(img *ImageInfo, ref reference.Named) []string {
+	if img == nil {
+		return nil
+	}
+	digests := make([]string, 0, len(img.RepoDigests))
+	for _, repoDigest := range img.RepoDigests {
+		named, err := reference.ParseNormalizedNamed(repoDigest)
+		if err != nil || named.Name() != ref.Name() {
+			continue
+		}
+		if canonical, ok := named.(reference.Canonical); ok {
+			digests = append(digests, canonical.Digest().String())
+		}
+	}
+	return digests
+}
+
+// digestCache giữ digest của tag trong một lượt kiểm tra
+type digestCache struct {
+	results map[string]digestResult
+	mu      sync.Mutex
+}
+
+type digestResult struct {
+	digest string
+	err    error
+}
+
+func newDigestCache() *digestCache {
+	return &digestCache{results: make(map[string]digestResult)}
+}
+
+func (c *digestCache) get(ref reference.NamedTagged, fetch func() (string, error)) (string, error) {
+	c.mu.Lock()
+	result, ok := c.results[ref.String()]
+	c.mu.Unlock()
+	if ok {
+		return result.digest, result.err
+	}
+
+	result.digest, result.err = fetch()
+	c.mu.Lock()
+	c.results[ref.String()] = result
+	c.mu.Unlock()
+	return result.digest, result.err
+}
+
+// ==================== ServerManager ====================
+
+// SetUpdateChecker bật gắn cờ updateAvailable vào danh sách container
+func (m *ServerManager) SetUpdateChecker(checker *UpdateChecker) {
+	m.mu.Lock()
+	m.updateChecker = checker
+	m.mu.Unlock()
+}
+
+func (m *ServerManager) attachUpdateStatus(serverID string, containers []ContainerInfo) {
+	m.mu.RLock()
+	checker := m.updateChecker
+	m.mu.RUnlock()
+	if checker == nil {
+		return
+	}
+	for i := range containers {
+		containers[i].UpdateAvailable = checker.UpdateAvailable(serverID, containers[i].Image)
+	}
+}
+
+// registryAuthConfig trả về credentials đã lưu cho registry của image (nil nếu không có)
+func (m *ServerManager) registryAuthConfig(ref string) *registry.AuthConfig {
+	m.mu.RLock()
+	registries := m.registries
+	m.mu.RUnlock()
+	if registries == nil {
+		return nil
+	}
+	return registries.AuthConfigForImage(ref)
+}
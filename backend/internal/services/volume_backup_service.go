@@ -0,0 +1,131 @@
+package services
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+)
+
+// Image của container tạm dùng để đọc/ghi volume. Backup/restore không start container
+// nên image nào cũng dùng được; file browser cần sh, stat, readlink và rm (busybox).
+const defaultVolumeHelperImage = "busybox:latest"
+
+// Thư mục mount volume trong container tạm
+const volumeHelperMount = "/volume"
+
+// Label đánh dấu container tạm của AppDock
+const volumeHelperLabel = "appdock.helper"
+
+func volumeHelperImage() string {
+	if image := strings.TrimSpace(os.Getenv("APPDOCK_VOLUME_HELPER_IMAGE")); image != "" {
+		return image
+	}
+	return defaultVolumeHelperImage
+}
+
+// createVolumeHelper tạo (không start) container tạm mount volume tại /volume để đọc/ghi
+// dữ liệu qua archive API. entrypoint chỉ dùng khi container được start, mặc định "true".
+// Caller phải xóa container sau khi dùng.
+func (m *ServerManager) createVolumeHelper(serverID, volumeName string, readOnly bool, entrypoint ...string) (string, error) {
+	if len(entrypoint) == 0 {
+		entrypoint = []string{"true"}
+	}
+	created, err := m.CreateContainerFromSpec(serverID, &ContainerSpec{
+		Config: &container.Config{
+			Image:      volumeHelperImage(),
+			Entrypoint: entrypoint,
+			Labels:     map[string]string{volumeHelperLabel: "volume:" + volumeName},
+		},
+		HostConfig: &container.HostConfig{
+			NetworkMode: network.NetworkNone,
+			// Output của script được đọc qua logs, không phụ thuộc log driver mặc định của daemon
+			LogConfig: container.LogConfig{Type: "json-file"},
+			Mounts: []mount.Mount{{
+				Type:     mount.TypeVolume,
+				Source:   volumeName,
+				Target:   volumeHelperMount,
+				ReadOnly: readOnly,
+			}},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("create helper container: %w", err)
+	}
+	return created.ID, nil
+}
+
+// OpenVolumeBackup trả về nội dung volume dạng tar.gz, được nén trong lúc stream.
+// Đóng reader sẽ xóa container tạm trên server.
+func (m *ServerManager) OpenVolumeBackup(serverID, volumeName string) (io.ReadCloser, error) {
+	if err := m.requireVolume(serverID, volumeName); err != nil {
+		return nil, err
+	}
+
+	helperID, err := m.createVolumeHelper(serverID, volumeName, true)
+	if err != nil {
+		return nil, err
+	}
+	// "/volume/." lấy nội dung thư mục, entry trong tar có dạng "./path"
+	archive, _, err := m.CopyFromContainer(serverID, helperID, volumeHelperMount+"/.")
+	if err != nil {
+		m.RemoveContainer(serverID, helperID, true)
+		return nil, err
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		gz := gzip.NewWriter(pw)
+		_, err := io.Copy(gz, archive)
+		if closeErr := gz.Close(); err == nil {
+			err = closeErr
+		}
+		archive.Close()
+		m.RemoveContainer(serverID, helperID, true)
+		pw.CloseWithError(err)
+	}()
+	return pr, nil
+}
+
+// RestoreVolume giải nén tar (hoặc tar.gz) vào volume, tạo volume nếu chưa có.
+// File đã có trong volume bị ghi đè, file không có trong tar được giữ nguyên.
+func (m *ServerManager) RestoreVolume(serverID, volumeName string, content io.Reader) (created bool, err error) {
+	if _, err := m.GetVolume(serverID, volumeName); err != nil {
+		if _, err := m.CreateVolume(serverID, CreateVolumeRequest{Name: volumeName}); err != nil {
+			return false, fmt.Errorf("create volume %s: %w", volumeName, err)
+		}
+		created = true
+	}
+
+	helperID, err := m.createVolumeHelper(serverID, volumeName, false)
+	if err != nil {
+		m.removeRestoredVolume(serverID, volumeName, created)
+		return created, err
+	}
+
+	// Docker tự nhận diện tar nén gzip/bzip2/xz
+	err = m.CopyToContainer(serverID, helperID, volumeHelperMount, content, false)
+	m.RemoveContainer(serverID, helperID, true)
+	if err != nil {
+		m.removeRestoredVolume(serverID, volumeName, created)
+		return created, err
+	}
+	return created, nil
+}
+
+// removeRestoredVolume xóa volume vừa được RestoreVolume tạo khi restore lỗi,
+// tránh để lại volume rỗng hoặc chỉ có một phần dữ liệu
+func (m *ServerManager) removeRestoredVolume(serverID, volumeName string, created bool) {
+	if !created {
+		return
+	}
+	if err := m.RemoveVolume(serverID, volumeName, true); err != nil {
+		log.Printf("Could not remove volume %s after failed restore: %v", volumeName, err)
+	}
+}
@@ -0,0 +1,436 @@
+package services
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	ErrVolumeNotFound     = errors.New("volume not found")
+	ErrInvalidVolumePath  = errors.New("invalid path")
+	ErrVolumePathNotFound = errors.New("file or directory not found")
+	ErrNotADirectory      = errors.New("not a directory")
+	ErrIsADirectory       = errors.New("is a directory")
+	ErrVolumeFileTooLarge = errors.New("file is too large to preview, download it instead")
+)
+
+// Kích thước tối đa của file được đọc nội dung trực tiếp (preview/chỉnh config)
+const maxVolumeFilePreview = 1 << 20
+
+// Thời gian tối đa chờ script trong container tạm chạy xong
+const volumeHelperTimeout = time.Minute
+
+// Exit code của các script bên dưới, tránh trùng với 1/2/126/127 của sh
+const (
+	volumeExitNotFound     = 10
+	volumeExitNotDirectory = 11
+	volumeExitIsDirectory  = 12
+)
+
+// volumeListScript in mỗi entry của thư mục $1 trên một dòng: "<mode hex> <size> <mtime>\t<name>\t<link target>"
+const volumeListScript = `[ -e "$1" ] || exit 10
+[ -d "$1" ] || exit 11
+cd "$1" || exit 1
+for f in * .[!.]* ..?*; do
+	[ -e "$f" ] || [ -L "$f" ] || continue
+	printf '%s\t%s\t%s\n' "$(stat -c '%f %s %Y' "./$f" 2>/dev/null)" "$f" "$(readlink "./$f" 2>/dev/null)"
+done`
+
+// volumeFileScript kiểm tra $1 là file và in path thật (đã resolve symlink)
+const volumeFileScript = `[ -e "$1" ] || exit 10
+[ -d "$1" ] && exit 12
+readlink -f "$1"`
+
+// volumeUploadScript kiểm tra có thể ghi file $1: thư mục cha gần nhất đang tồn tại phải là thư mục.
+// Nếu file đã tồn tại thì in "mode uid gid" để file mới giữ nguyên quyền và owner.
+const volumeUploadScript = `d=$(dirname "$1")
+while [ "$d" != / ] && [ ! -e "$d" ]; do d=$(dirname "$d"); done
+[ -d "$d" ] || exit 11
+[ -d "$1" ] && exit 12
+[ -e "$1" ] && stat -L -c '%a %u %g' "$1"
+exit 0`
+
+const volumeDeleteScript = `[ -e "$1" ] || [ -L "$1" ] || exit 10
+rm -rf "$1"`
+
+// VolumeFileEntry là một file/thư mục trong volume
+type VolumeFileEntry struct {
+	Name       string    `json:"name"`
+	Path       string    `json:"path"` // tính từ gốc volume, e.g. "/conf/app.yml"
+	Type       string    `json:"type"` // "file", "dir", "symlink" hoặc "other"
+	Size       int64     `json:"size"`
+	Mode       string    `json:"mode"` // e.g. "-rw-r--r--"
+	ModTime    time.Time `json:"modTime"`
+	LinkTarget string    `json:"linkTarget,omitempty"`
+}
+
+type VolumeDirectory struct {
+	Path    string            `json:"path"`
+	Entries []VolumeFileEntry `json:"entries"`
+}
+
+type VolumeFileContent struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+	Binary  bool      `json:"binary"`
+	Content string    `json:"content,omitempty"` // bỏ trống khi file là binary
+}
+
+// cleanVolumePath kiểm tra path người dùng gửi và trả về path tương đối tính từ gốc volume
+// ("" là gốc). Path chứa ".." bị từ chối để không thoát ra ngoài volume.
+func cleanVolumePath(p string) (string, error) {
+	if strings.ContainsRune(p, 0) {
+		return "", ErrInvalidVolumePath
+	}
+	for _, part := range strings.Split(strings.ReplaceAll(p, `\`, "/"), "/") {
+		if part == ".." {
+			return "", ErrInvalidVolumePath
+		}
+	}
+	return strings.TrimPrefix(path.Clean("/"+p), "/"), nil
+}
+
+func (m *ServerManager) requireVolume(serverID, volumeName string) error {
+	// Mount volume chưa tồn tại sẽ tạo volume rỗng, nên phải kiểm tra trước
+	if _, err := m.GetVolume(serverID, volumeName); err != nil {
+		return fmt.Errorf("%w: %s", ErrVolumeNotFound, volumeName)
+	}
+	return nil
+}
+
+// runVolumeHelper chạy script sh trong container tạm mount volume và chờ container dừng.
+// Container đã dừng vẫn dùng được với archive API; caller phải xóa nó sau khi dùng.
+func (m *ServerManager) runVolumeHelper(serverID, volumeName string, readOnly bool, script string, args ...string) (id string, output string, err error) {
+	entrypoint := append([]string{"sh", "-c", script, "sh"}, args...)
+	id, err = m.createVolumeHelper(serverID, volumeName, readOnly, entrypoint...)
+	if err != nil {
+		return "", "", err
+	}
+	defer func() {
+		if err != nil {
+			m.RemoveContainer(serverID, id, true)
+			id = ""
+		}
+	}()
+
+	if err := m.StartContainer(serverID, id); err != nil {
+		return id, "", fmt.Errorf("start helper container: %w", err)
+	}
+	exitCode := 0
+	deadline := time.Now().Add(volumeHelperTimeout)
+	for {
+		inspect, err := m.InspectContainer(serverID, id)
+		if err != nil {
+			return id, "", err
+		}
+		if inspect.State == nil || !inspect.State.Running {
+			if inspect.State != nil {
+				exitCode = inspect.State.ExitCode
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			return id, "", fmt.Errorf("helper container did not finish within %s", volumeHelperTimeout)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+
+	logs, err := m.GetContainerLogs(serverID, id, "all")
+	if err != nil {
+		return id, "", err
+	}
+	// Mỗi dòng log có timestamp ở đầu (RFC3339Nano + dấu cách)
+	lines := strings.Split(strings.TrimSuffix(logs, "\n"), "\n")
+	for i, line := range lines {
+		if idx := strings.IndexByte(line, ' '); idx >= 0 {
+			lines[i] = line[idx+1:]
+		}
+	}
+	output = strings.Join(lines, "\n")
+
+	switch exitCode {
+	case 0:
+		return id, output, nil
+	case volumeExitNotFound:
+		return id, "", ErrVolumePathNotFound
+	case volumeExitNotDirectory:
+		return id, "", ErrNotADirectory
+	case volumeExitIsDirectory:
+		return id, "", ErrIsADirectory
+	default:
+		return id, "", fmt.Errorf("helper container exited with code %d: %s", exitCode, strings.TrimSpace(output))
+	}
+}
+
+// ListVolumeFiles liệt kê một cấp của thư mục trong volume, thư mục trước rồi theo tên
+func (m *ServerManager) ListVolumeFiles(serverID, volumeName, dirPath string) (*VolumeDirectory, error) {
+	rel, err := cleanVolumePath(dirPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.requireVolume(serverID, volumeName); err != nil {
+		return nil, err
+	}
+
+	id, output, err := m.runVolumeHelper(serverID, volumeName, true, volumeListScript, path.Join(volumeHelperMount, rel))
+	if err != nil {
+		return nil, err
+	}
+	m.RemoveContainer(serverID, id, true)
+
+	entries := make([]VolumeFileEntry, 0)
+	for _, line := range strings.Split(output, "\n") {
+		if entry, ok := parseVolumeFileEntry(line, "/"+rel); ok {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if (entries[i].Type == "dir") != (entries[j].Type == "dir") {
+			return entries[i].Type == "dir"
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	return &VolumeDirectory{Path: "/" + rel, Entries: entries}, nil
+}
+
+// parseVolumeFileEntry đọc một dòng output của volumeListScript
+func parseVolumeFileEntry(line, dir string) (VolumeFileEntry, bool) {
+	statPart, rest, ok := strings.Cut(line, "\t")
+	if !ok {
+		return VolumeFileEntry{}, false
+	}
+	fields := strings.Fields(statPart)
+	if len(fields) != 3 {
+		return VolumeFileEntry{}, false
+	}
+	rawMode, err := strconv.ParseUint(fields[0], 16, 32)
+	if err != nil {
+		return VolumeFileEntry{}, false
+	}
+	size, _ := strconv.ParseInt(fields[1], 10, 64)
+	mtime, _ := strconv.ParseInt(fields[2], 10, 64)
+
+	name, target := rest, ""
+	if idx := strings.LastIndexByte(rest, '\t'); idx >= 0 {
+		name, target = rest[:idx], rest[idx+1:]
+	}
+	if name == "" {
+		return VolumeFileEntry{}, false
+	}
+
+	mode := os.FileMode(rawMode & 0777)
+	entryType := "other"
+	switch rawMode & 0170000 {
+	case 0040000:
+		mode |= os.ModeDir
+		entryType = "dir"
+	case 0120000:
+		mode |= os.ModeSymlink
+		entryType = "symlink"
+	case 0100000:
+		entryType = "file"
+	}
+	if entryType != "symlink" {
+		target = ""
+	}
+
+	return VolumeFileEntry{
+		Name:       name,
+		Path:       path.Join(dir, name),
+		Type:       entryType,
+		Size:       size,
+		Mode:       mode.String(),
+		ModTime:    time.Unix(mtime, 0),
+		LinkTarget: target,
+	}, true
+}
+
+// volumeFileReader đọc nội dung một file trong tar, Close xóa container tạm
+type volumeFileReader struct {
+	io.Reader
+	close func() error
+}
+
+func (r *volumeFileReader) Close() error {
+	return r.close()
+}
+
+// OpenVolumeFile mở một file trong volume để đọc, symlink được resolve (phải nằm trong volume).
+// Đóng reader sẽ xóa container tạm trên server.
+func (m *ServerManager) OpenVolumeFile(serverID, volumeName, filePath string) (io.ReadCloser, *VolumeFileEntry, error) {
+	rel, err := cleanVolumePath(filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if rel == "" {
+		return nil, nil, ErrIsADirectory
+	}
+	if err := m.requireVolume(serverID, volumeName); err != nil {
+		return nil, nil, err
+	}
+
+	id, output, err := m.runVolumeHelper(serverID, volumeName, true, volumeFileScript, path.Join(volumeHelperMount, rel))
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() { m.RemoveContainer(serverID, id, true) }
+
+	resolved := strings.TrimSpace(output)
+	if !strings.HasPrefix(resolved, volumeHelperMount+"/") {
+		// Symlink trỏ ra ngoài volume (vào filesystem của container tạm)
+		cleanup()
+		return nil, nil, ErrVolumePathNotFound
+	}
+
+	archive, _, err := m.CopyFromContainer(serverID, id, resolved)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	tr := tar.NewReader(archive)
+	header, err := tr.Next()
+	if err == nil && header.Typeflag != tar.TypeReg {
+		err = fmt.Errorf("%s is not a regular file", "/"+rel)
+	}
+	if err != nil {
+		archive.Close()
+		cleanup()
+		return nil, nil, err
+	}
+
+	entry := &VolumeFileEntry{
+		Name:    path.Base(rel),
+		Path:    "/" + rel,
+		Type:    "file",
+		Size:    header.Size,
+		Mode:    header.FileInfo().Mode().String(),
+		ModTime: header.ModTime,
+	}
+	reader := &volumeFileReader{
+		Reader: tr,
+		close: func() error {
+			err := archive.Close()
+			cleanup()
+			return err
+		},
+	}
+	return reader, entry, nil
+}
+
+// ReadVolumeFile đọc nội dung file nhỏ trong volume. File binary chỉ trả về thông tin, không có nội dung.
+func (m *ServerManager) ReadVolumeFile(serverID, volumeName, filePath string) (*VolumeFileContent, error) {
+	reader, entry, err := m.OpenVolumeFile(serverID, volumeName, filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	if entry.Size > maxVolumeFilePreview {
+		return nil, ErrVolumeFileTooLarge
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &VolumeFileContent{
+		Path:    entry.Path,
+		Size:    entry.Size,
+		ModTime: entry.ModTime,
+	}
+	if !utf8.Valid(data) || bytes.IndexByte(data, 0) >= 0 {
+		result.Binary = true
+	} else {
+		result.Content = string(data)
+	}
+	return result, nil
+}
+
+// WriteVolumeFile ghi file vào volume (tạo thư mục cha nếu chưa có, ghi đè file đã có).
+// size phải đúng bằng số byte của content vì được ghi vào header tar trước khi stream.
+func (m *ServerManager) WriteVolumeFile(serverID, volumeName, filePath string, content io.Reader, size int64) error {
+	rel, err := cleanVolumePath(filePath)
+	if err != nil {
+		return err
+	}
+	if rel == "" {
+		return ErrIsADirectory
+	}
+	if err := m.requireVolume(serverID, volumeName); err != nil {
+		return err
+	}
+
+	id, output, err := m.runVolumeHelper(serverID, volumeName, false, volumeUploadScript, path.Join(volumeHelperMount, rel))
+	if err != nil {
+		return err
+	}
+	defer m.RemoveContainer(serverID, id, true)
+
+	// File mới: root, 0644; file đã có: giữ mode và owner hiện tại
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     rel,
+		Mode:     0644,
+		Size:     size,
+		ModTime:  time.Now(),
+	}
+	if fields := strings.Fields(output); len(fields) == 3 {
+		mode, modeErr := strconv.ParseInt(fields[0], 8, 64)
+		uid, uidErr := strconv.Atoi(fields[1])
+		gid, gidErr := strconv.Atoi(fields[2])
+		if modeErr != nil || uidErr != nil || gidErr != nil {
+			return fmt.Errorf("unexpected file attributes from volume helper: %q", output)
+		}
+		header.Mode, header.Uid, header.Gid = mode, uid, gid
+	}
+
+	// Entry tên "dir/file" giải nén vào gốc volume, Docker tự tạo thư mục cha còn thiếu
+	pr, pw := io.Pipe()
+	go func() {
+		tw := tar.NewWriter(pw)
+		err := tw.WriteHeader(header)
+		if err == nil {
+			_, err = io.Copy(tw, content)
+		}
+		if closeErr := tw.Close(); err == nil {
+			err = closeErr
+		}
+		pw.CloseWithError(err)
+	}()
+
+	err = m.CopyToContainer(serverID, id, volumeHelperMount, pr, false)
+	pr.Close()
+	return err
+}
+
+// DeleteVolumeFile xóa file hoặc thư mục (đệ quy) trong volume. Không cho xóa gốc volume.
+func (m *ServerManager) DeleteVolumeFile(serverID, volumeName, filePath string) error {
+	rel, err := cleanVolumePath(filePath)
+	if err != nil {
+		return err
+	}
+	if rel == "" {
+		return ErrInvalidVolumePath
+	}
+	if err := m.requireVolume(serverID, volumeName); err != nil {
+		return err
+	}
+
+	id, _, err := m.runVolumeHelper(serverID, volumeName, false, volumeDeleteScript, path.Join(volumeHelperMount, rel))
+	if err != nil {
+		return err
+	}
+	m.RemoveContainer(serverID, id, true)
+	return nil
+}
@@ -0,0 +1,720 @@
+package services
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"appdock/internal/models"
+)
+
+var ErrVulnDBEmpty = errors.New("vulnerability database is empty, import an OSV export first")
+
+// VulnDatabase là cơ sở dữ liệu lỗ hổng offline, import từ dữ liệu OSV (https://osv.dev)
+// đã tải sẵn: file .json, file .zip (all.zip của từng ecosystem) hoặc thư mục chứa chúng.
+// Dữ liệu được lưu gọn trong vulndb.json để dùng được trên máy không có internet.
+type VulnDatabase struct {
+	filePath   string
+	advisories map[string]*vulnAdvisory
+	index      map[string][]vulnMatchTarget // ecosystem|package -> advisories
+	importedAt *time.Time
+	sources    []string
+	mu         sync.RWMutex
+}
+
+// VulnDBStats thông tin về vulnerability DB hiện tại
+type VulnDBStats struct {
+	ImportedAt *time.Time     `json:"importedAt,omitempty"`
+	Sources    []string       `json:"sources"`
+	Advisories int            `json:"advisories"`
+	Ecosystems map[string]int `json:"ecosystems"` // số package theo ecosystem
+}
+
+type vulnAdvisory struct {
+	ID       string          `json:"id"`
+	Aliases  []string        `json:"aliases,omitempty"`
+	Summary  string          `json:"summary,omitempty"`
+	Severity models.Severity `json:"severity"`
+	Score    float64         `json:"score,omitempty"`
+	Affected []vulnAffected  `json:"affected"`
+}
+
+type vulnAffected struct {
+	Ecosystem string      `json:"ecosystem"` // đã chuẩn hóa, e.g., "debian:12", "alpine:3.19", "npm"
+	Package   string      `json:"package"`
+	Ranges    []vulnRange `json:"ranges,omitempty"`
+	Versions  []string    `json:"versions,omitempty"`
+}
+
+type vulnRange struct {
+	Type   string      `json:"type"`
+	Events []vulnEvent `json:"events"`
+}
+
+type vulnEvent struct {
+	Introduced   string `json:"introduced,omitempty"`
+	Fixed        string `json:"fixed,omitempty"`
+	LastAffected string `json:"lastAffected,omitempty"`
+}
+
+type vulnMatchTarget struct {
+	advisory *vulnAdvisory
+	affected *vulnAffected
+}
+
+type vulnDBFile struct {
+	ImportedAt *time.Time      `json:"importedAt,omitempty"`
+	Sources    []string        `json:"sources"`
+	Advisories []*vulnAdvisory `json:"advisories"`
+}
+
+// osvRecord là một advisory theo OSV schema, chỉ lấy các trường cần cho việc đối chiếu
+type osvRecord struct {
+	ID        string   `json:"id"`
+	Aliases   []string `json:"aliases"`
+	Summary   string   `json:"summary"`
+	Details   string   `json:"details"`
+	Withdrawn string   `json:"withdrawn"`
+	Severity  []struct {
+		Type  string `json:"type"`
+		Score string `json:"score"`
+	} `json:"severity"`
+	Affected []struct {
+		Package struct {
+			Ecosystem string `json:"ecosystem"`
+			Name      string `json:"name"`
+		} `json:"package"`
+		Ranges []struct {
+			Type   string              `json:"type"`
+			Events []map[string]string `json:"events"`
+		} `json:"ranges"`
+		Versions []string `json:"versions"`
+	} `json:"affected"`
+	DatabaseSpecific map[string]interface{} `json:"database_specific"`
+}
+
+func NewVulnDatabase(dataDir string) (*VulnDatabase, error) {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, err
+	}
+
+	db := &VulnDatabase{
+		filePath:   filepath.Join(dataDir, "vulndb.json"),
+		advisories: make(map[string]*vulnAdvisory),
+	}
+
+	if err := db.load(); err != nil {
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	db.rebuildIndex()
+
+	return db, nil
+}
+
+func (db *VulnDatabase) load() error {
+	data, err := os.ReadFile(db.filePath)
+	if err != nil {
+		return err
+	}
+
+	var stored vulnDBFile
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return err
+	}
+
+	db.importedAt = stored.ImportedAt
+	db.sources = stored.Sources
+	for _, adv := range stored.Advisories {
+		db.advisories[adv.ID] = adv
+	}
+	return nil
+}
+
+func (db *VulnDatabase) save() error {
+	stored := vulnDBFile{
+		ImportedAt: db.importedAt,
+		Sources:    db.sources,
+		Advisories: make([]*vulnAdvisory, 0, len(db.advisories)),
+	}
+	for _, adv := range db.advisories {
+		stored.Advisories = append(stored.Advisories, adv)
+	}
+	sort.Slice(stored.Advisories, func(i, j int) bool { return stored.Advisories[i].ID < stored.Advisories[j].ID })
+
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+
+	// Ghi ra file tạm rồi rename để không làm hỏng DB cũ nếu bị ngắt giữa chừng
+	tmpPath := db.filePath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, db.filePath)
+}
+
+func (db *VulnDatabase) rebuildIndex() {
+	db.index = make(map[string][]vulnMatchTarget)
+	for _, adv := range db.advisories {
+		for i := range adv.Affected {
+			aff := &adv.Affected[i]
+			key := aff.Ecosystem + "|" + aff.Package
+			db.index[key] = append(db.index[key], vulnMatchTarget{advisory: adv, affected: aff})
+		}
+	}
+}
+
+// Import đọc dữ liệu OSV từ path trên host. replace = true sẽ xóa dữ liệu cũ trước khi import,
+// ngược lại advisory cùng ID được ghi đè. Trả về số advisory đã import.
+func (db *VulnDatabase) Import(path string, replace bool) (int, error) {
+	imported := make(map[string]*vulnAdvisory)
+	collect := func(r io.Reader) error {
+		records, err := decodeOSVRecords(r)
+		if err != nil {
+			return err
+		}
+		for _, record := range records {
+			if adv := newVulnAdvisory(record); adv != nil {
+				imported[adv.ID] = adv
+			}
+		}
+		return nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	if info.IsDir() {
+		err = filepath.Walk(path, func(p string, fi os.FileInfo, err error) error {
+			if err != nil || fi.IsDir() {
+				return err
+			}
+			return importOSVFile(p, collect)
+		})
+	} else {
+		err = importOSVFile(path, collect)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if len(imported) == 0 {
+		return 0, errors.New("no OSV advisories found in " + path)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	previous, previousSources, previousImportedAt := db.advisories, db.sources, db.importedAt
+	if replace {
+		db.advisories = make(map[string]*vulnAdvisory)
+		db.sources = nil
+	} else {
+		db.advisories = make(map[string]*vulnAdvisory, len(previous)+len(imported))
+		for id, adv := range previous {
+			db.advisories[id] = adv
+		}
+	}
+	for id, adv := range imported {
+		db.advisories[id] = adv
+	}
+	now := time.Now()
+	db.importedAt = &now
+	db.sources = appendUnique(db.sources, filepath.Base(path))
+
+	if err := db.save(); err != nil {
+		db.advisories, db.sources, db.importedAt = previous, previousSources, previousImportedAt
+		return 0, err
+	}
+	db.rebuildIndex()
+
+	return len(imported), nil
+}
+
+// importOSVFile đọc một file .json hoặc .zip, các file khác bị bỏ qua
+func importOSVFile(path string, collect func(io.Reader) error) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return collect(f)
+	case ".zip":
+		zr, err := zip.OpenReader(path)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		for _, file := range zr.File {
+			if file.FileInfo().IsDir() || !strings.HasSuffix(strings.ToLower(file.Name), ".json") {
+				continue
+			}
+			rc, err := file.Open()
+			if err != nil {
+				return err
+			}
+			err = collect(rc)
+			rc.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// decodeOSVRecords chấp nhận một advisory, mảng advisory hoặc {"vulns": [...]} (kết quả của OSV API)
+func decodeOSVRecords(r io.Reader) ([]osvRecord, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	trimmed := strings.TrimSpace(string(data))
+	if strings.HasPrefix(trimmed, "[") {
+		var records []osvRecord
+		err := json.Unmarshal(data, &records)
+		return records, err
+	}
+
+	var wrapper struct {
+		Vulns []osvRecord `json:"vulns"`
+	}
+	if err := json.Unmarshal(data, &wrapper); err == nil && len(wrapper.Vulns) > 0 {
+		return wrapper.Vulns, nil
+	}
+	var record osvRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, err
+	}
+	return []osvRecord{record}, nil
+}
+
+func newVulnAdvisory(record osvRecord) *vulnAdvisory {
+	if record.ID == "" || record.Withdrawn != "" {
+		return nil
+	}
+
+	adv := &vulnAdvisory{
+		ID:       record.ID,
+		Aliases:  record.Aliases,
+		Summary:  record.Summary,
+		Severity: models.SeverityUnknown,
+	}
+	if adv.Summary == "" {
+		adv.Summary = firstLine(record.Details, 200)
+	}
+
+	for _, sev := range record.Severity {
+		switch sev.Type {
+		case "CVSS_V3":
+			if score, ok := cvss3BaseScore(sev.Score); ok && score > adv.Score {
+				adv.Score = score
+				adv.Severity = severityFromScore(score)
+			}
+		case "Ubuntu":
+			if adv.Severity == models.SeverityUnknown {
+				adv.Severity = normalizeSeverity(sev.Score)
+			}
+		}
+	}
+	if adv.Severity == models.SeverityUnknown {
+		if severity, ok := record.DatabaseSpecific["severity"].(string); ok {
+			adv.Severity = normalizeSeverity(severity)
+		}
+	}
+
+	for _, affected := range record.Affected {
+		ecosystem := normalizeEcosystem(affected.Package.Ecosystem)
+		if ecosystem == "" || affected.Package.Name == "" {
+			continue
+		}
+		aff := vulnAffected{
+			Ecosystem: ecosystem,
+			Package:   normalizePackageName(ecosystem, affected.Package.Name),
+			Versions:  affected.Versions,
+		}
+		for _, r := range affected.Ranges {
+			// Range GIT dùng commit hash, không so với version của package được
+			if r.Type != "ECOSYSTEM" && r.Type != "SEMVER" {
+				continue
+			}
+			vr := vulnRange{Type: r.Type}
+			for _, event := range r.Events {
+				vr.Events = append(vr.Events, vulnEvent{
+					Introduced:   event["introduced"],
+					Fixed:        event["fixed"],
+					LastAffected: event["last_affected"],
+				})
+			}
+			aff.Ranges = append(aff.Ranges, vr)
+		}
+		if len(aff.Ranges) > 0 || len(aff.Versions) > 0 {
+			adv.Affected = append(adv.Affected, aff)
+		}
+	}
+	if len(adv.Affected) == 0 {
+		return nil
+	}
+	return adv
+}
+
+// Clear xóa toàn bộ vulnerability DB
+func (db *VulnDatabase) Clear() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.advisories = make(map[string]*vulnAdvisory)
+	db.sources = nil
+	db.importedAt = nil
+	db.rebuildIndex()
+
+	if err := os.Remove(db.filePath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (db *VulnDatabase) Stats() VulnDBStats {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	stats := VulnDBStats{
+		ImportedAt: db.importedAt,
+		Sources:    append([]string{}, db.sources...),
+		Advisories: len(db.advisories),
+		Ecosystems: make(map[string]int),
+	}
+	for key := range db.index {
+		ecosystem, _, _ := strings.Cut(key, "|")
+		stats.Ecosystems[ecosystem]++
+	}
+	return stats
+}
+
+// Match đối chiếu package với DB. osInfo dùng để chọn ecosystem cho package của distro.
+func (db *VulnDatabase) Match(osInfo *models.ImageOS, pkgs []models.Package) ([]models.Vulnerability, *time.Time, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if len(db.advisories) == 0 {
+		return nil, nil, ErrVulnDBEmpty
+	}
+
+	result := make([]models.Vulnerability, 0)
+	for _, pkg := range pkgs {
+		ecosystem := packageEcosystem(pkg, osInfo)
+		if ecosystem == "" {
+			continue
+		}
+
+		// Advisory của distro dùng tên source package, thử cả tên binary package
+		names := []string{pkg.Name}
+		if pkg.Source != "" && pkg.Source != pkg.Name {
+			names = append([]string{pkg.Source}, names...)
+		}
+		seen := make(map[string]bool)
+		for _, name := range names {
+			for _, target := range db.index[ecosystem+"|"+normalizePackageName(ecosystem, name)] {
+				if seen[target.advisory.ID] {
+					continue
+				}
+				affected, fixed := isAffected(ecosystem, pkg.Version, target.affected)
+				if !affected {
+					continue
+				}
+				seen[target.advisory.ID] = true
+				result = append(result, models.Vulnerability{
+					ID:           target.advisory.ID,
+					Aliases:      target.advisory.Aliases,
+					Package:      pkg.Name,
+					Version:      pkg.Version,
+					Type:         pkg.Type,
+					PURL:         pkg.PURL,
+					FixedVersion: fixed,
+					Severity:     target.advisory.Severity,
+					Score:        target.advisory.Score,
+					Summary:      target.advisory.Summary,
+					Location:     pkg.Location,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if severityRank(result[i].Severity) != severityRank(result[j].Severity) {
+			return severityRank(result[i].Severity) > severityRank(result[j].Severity)
+		}
+		if result[i].Package != result[j].Package {
+			return result[i].Package < result[j].Package
+		}
+		return result[i].ID < result[j].ID
+	})
+
+	return result, db.importedAt, nil
+}
+
+// isAffected kiểm tra version theo danh sách versions và các range của OSV.
+// Trả về kèm version sửa lỗi gần nhất nếu có.
+func isAffected(ecosystem, version string, aff *vulnAffected) (bool, string) {
+	affected := false
+	for _, v := range aff.Versions {
+		if compareVersions(ecosystem, version, v) == 0 {
+			affected = true
+			break
+		}
+	}
+
+	fixed := ""
+	for _, r := range aff.Ranges {
+		events := append([]vulnEvent{}, r.Events...)
+		sort.SliceStable(events, func(i, j int) bool {
+			return compareVersions(ecosystem, eventVersion(events[i]), eventVersion(events[j])) < 0
+		})
+
+		inRange := false
+		for _, event := range events {
+			switch {
+			case event.Introduced != "":
+				if event.Introduced == "0" || compareVersions(ecosystem, version, event.Introduced) >= 0 {
+					inRange = true
+				}
+			case event.Fixed != "":
+				if compareVersions(ecosystem, version, event.Fixed) >= 0 {
+					inRange = false
+				} else if inRange && fixed == "" {
+					fixed = event.Fixed
+				}
+			case event.LastAffected != "":
+				if compareVersions(ecosystem, version, event.LastAffected) > 0 {
+					inRange = false
+				}
+			}
+		}
+		if inRange {
+			affected = true
+		}
+	}
+
+	if !affected {
+		return false, ""
+	}
+	return true, fixed
+}
+
+func eventVersion(event vulnEvent) string {
+	switch {
+	case event.Introduced == "0":
+		return ""
+	case event.Introduced != "":
+		return event.Introduced
+	case event.Fixed != "":
+		return event.Fixed
+	}
+	return event.LastAffected
+}
+
+// normalizeEcosystem chuẩn hóa tên ecosystem OSV: "Debian:12" -> "debian:12",
+// "Alpine:v3.19" -> "alpine:3.19", "Ubuntu:22.04:LTS" -> "ubuntu:22.04".
+// Ecosystem không hỗ trợ trả về chuỗi rỗng.
+func normalizeEcosystem(ecosystem string) string {
+	parts := strings.Split(strings.ToLower(ecosystem), ":")
+	switch parts[0] {
+	case "go", "npm", "pypi":
+		return parts[0]
+	case "debian", "alpine", "ubuntu":
+		if len(parts) < 2 || parts[1] == "" || parts[1] == "pro" {
+			return ""
+		}
+		return parts[0] + ":" + strings.TrimPrefix(parts[1], "v")
+	}
+	return ""
+}
+
+// packageEcosystem trả về ecosystem (đã chuẩn hóa) để tra cứu package
+func packageEcosystem(pkg models.Package, osInfo *models.ImageOS) string {
+	switch pkg.Type {
+	case models.PackageTypeGolang:
+		return "go"
+	case models.PackageTypeNpm:
+		return "npm"
+	case models.PackageTypePypi:
+		return "pypi"
+	case models.PackageTypeDeb, models.PackageTypeApk:
+		if osInfo == nil || osInfo.Version == "" {
+			return ""
+		}
+		version := osInfo.Version
+		switch osInfo.ID {
+		case "debian":
+			// OSV dùng major version: "Debian:12"
+			version, _, _ = strings.Cut(version, ".")
+		case "alpine":
+			// "3.19.1" -> "3.19"
+			if parts := strings.Split(version, "."); len(parts) > 2 {
+				version = parts[0] + "." + parts[1]
+			}
+		case "ubuntu":
+		default:
+			return ""
+		}
+		return osInfo.ID + ":" + version
+	}
+	return ""
+}
+
+func normalizePackageName(ecosystem, name string) string {
+	if ecosystem == "pypi" {
+		return strings.ToLower(pypiNameSeparators.ReplaceAllString(name, "-"))
+	}
+	return name
+}
+
+func normalizeSeverity(severity string) models.Severity {
+	switch strings.ToUpper(strings.TrimSpace(severity)) {
+	case "CRITICAL":
+		return models.SeverityCritical
+	case "HIGH", "IMPORTANT":
+		return models.SeverityHigh
+	case "MEDIUM", "MODERATE":
+		return models.SeverityMedium
+	case "LOW", "NEGLIGIBLE":
+		return models.SeverityLow
+	}
+	return models.SeverityUnknown
+}
+
+func severityFromScore(score float64) models.Severity {
+	switch {
+	case score >= 9.0:
+		return models.SeverityCritical
+	case score >= 7.0:
+		return models.SeverityHigh
+	case score >= 4.0:
+		return models.SeverityMedium
+	case score > 0:
+		return models.SeverityLow
+	}
+	return models.SeverityUnknown
+}
+
+func severityRank(severity models.Severity) int {
+	switch severity {
+	case models.SeverityCritical:
+		return 4
+	case models.SeverityHigh:
+		return 3
+	case models.SeverityMedium:
+		return 2
+	case models.SeverityLow:
+		return 1
+	}
+	return 0
+}
+
+// cvss3BaseScore tính base score từ CVSS v3.x vector, e.g. "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"
+func cvss3BaseScore(vector string) (float64, bool) {
+	if !strings.HasPrefix(vector, "CVSS:3.") {
+		return 0, false
+	}
+	metrics := make(map[string]string)
+	for _, part := range strings.Split(vector, "/")[1:] {
+		key, value, ok := strings.Cut(part, ":")
+		if ok {
+			metrics[key] = value
+		}
+	}
+
+	weights := map[string]map[string]float64{
+		"AV": {"N": 0.85, "A": 0.62, "L": 0.55, "P": 0.2},
+		"AC": {"L": 0.77, "H": 0.44},
+		"UI": {"N": 0.85, "R": 0.62},
+		"C":  {"H": 0.56, "L": 0.22, "N": 0},
+		"I":  {"H": 0.56, "L": 0.22, "N": 0},
+		"A":  {"H": 0.56, "L": 0.22, "N": 0},
+	}
+	values := make(map[string]float64)
+	for metric, table := range weights {
+		value, ok := table[metrics[metric]]
+		if !ok {
+			return 0, false
+		}
+		values[metric] = value
+	}
+
+	changed := metrics["S"] == "C"
+	if !changed && metrics["S"] != "U" {
+		return 0, false
+	}
+	privileges := map[string]float64{"N": 0.85, "L": 0.62, "H": 0.27}
+	if changed {
+		privileges = map[string]float64{"N": 0.85, "L": 0.68, "H": 0.5}
+	}
+	pr, ok := privileges[metrics["PR"]]
+	if !ok {
+		return 0, false
+	}
+
+	iss := 1 - (1-values["C"])*(1-values["I"])*(1-values["A"])
+	impact := 6.42 * iss
+	if changed {
+		impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+	}
+	if impact <= 0 {
+		return 0, true
+	}
+	exploitability := 8.22 * values["AV"] * values["AC"] * pr * values["UI"]
+
+	base := impact + exploitability
+	if changed {
+		base *= 1.08
+	}
+	return cvssRoundUp(math.Min(base, 10)), true
+}
+
+// cvssRoundUp làm tròn lên 1 chữ số thập phân theo đặc tả CVSS v3.1
+func cvssRoundUp(value float64) float64 {
+	scaled := int(math.Round(value * 100000))
+	if scaled%10000 == 0 {
+		return float64(scaled) / 100000
+	}
+	return (math.Floor(float64(scaled)/10000) + 1) / 10
+}
+
+func firstLine(text string, max int) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	if len(line) > max {
+		line = line[:max] + "..."
+	}
+	return line
+}
+
+func appendUnique(list []string, value string) []string {
+	for _, item := range list {
+		if item == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
+
+// formatScore hiển thị score CVSS, bỏ trống nếu không có
+func formatScore(score float64) string {
+	if score == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(score, 'f', 1, 64)
+}
@@ -0,0 +1,151 @@
+package services
+
+import "testing"
+
+func TestIsAffected(t *testing.T) {
+	semverRange := func(events ...vulnEvent) *vulnAffected {
+		return &vulnAffected{Ranges: []vulnRange{{Type: "SEMVER", Events: events}}}
+	}
+
+	tests := []struct {
+		name         string
+		ecosystem    string
+		version      string
+		affected     *vulnAffected
+		wantAffected bool
+		wantFixed    string
+	}{
+		{
+			name:         "introduced 0 before fix",
+			ecosystem:    "npm",
+			version:      "1.1.0",
+			affected:     semverRange(vulnEvent{Introduced: "0"}, vulnEvent{Fixed: "1.2.0"}),
+			wantAffected: true,
+			wantFixed:    "1.2.0",
+		},
+		{
+			name:      "fixed version",
+			ecosystem: "npm",
+			version:   "1.2.0",
+			affected:  semverRange(vulnEvent{Introduced: "0"}, vulnEvent{Fixed: "1.2.0"}),
+		},
+		{
+			name:      "before introduced",
+			ecosystem: "npm",
+			version:   "0.9.0",
+			affected:  semverRange(vulnEvent{Introduced: "1.0.0"}, vulnEvent{Fixed: "1.2.0"}),
+		},
+		{
+			name:         "events out of order",
+			ecosystem:    "npm",
+			version:      "1.0.5",
+			affected:     semverRange(vulnEvent{Fixed: "1.2.0"}, vulnEvent{Introduced: "1.0.0"}),
+			wantAffected: true,
+			wantFixed:    "1.2.0",
+		},
+		{
+			name:         "last affected version",
+			ecosystem:    "npm",
+			version:      "1.5.0",
+			affected:     semverRange(vulnEvent{Introduced: "1.0.0"}, vulnEvent{LastAffected: "1.5.0"}),
+			wantAffected: true,
+		},
+		{
+			name:      "after last affected",
+			ecosystem: "npm",
+			version:   "1.5.1",
+			affected:  semverRange(vulnEvent{Introduced: "1.0.0"}, vulnEvent{LastAffected: "1.5.0"}),
+		},
+		{
+			name:      "between two ranges",
+			ecosystem: "npm",
+			version:   "1.5.0",
+			affected: semverRange(
+				vulnEvent{Introduced: "1.0.0"}, vulnEvent{Fixed: "1.1.0"},
+				vulnEvent{Introduced: "2.0.0"}, vulnEvent{Fixed: "2.1.0"},
+			),
+		},
+		{
+			name:      "second range",
+			ecosystem: "npm",
+			version:   "2.0.5",
+			affected: semverRange(
+				vulnEvent{Introduced: "1.0.0"}, vulnEvent{Fixed: "1.1.0"},
+				vulnEvent{Introduced: "2.0.0"}, vulnEvent{Fixed: "2.1.0"},
+			),
+			wantAffected: true,
+			wantFixed:    "2.1.0",
+		},
+		{
+			name:         "no fix yet",
+			ecosystem:    "npm",
+			version:      "3.0.0",
+			affected:     semverRange(vulnEvent{Introduced: "2.0.0"}),
+			wantAffected: true,
+		},
+		{
+			name:         "explicit versions list",
+			ecosystem:    "pypi",
+			version:      "1.3",
+			affected:     &vulnAffected{Versions: []string{"1.2.0", "1.3.0"}},
+			wantAffected: true,
+		},
+		{
+			name:      "not in versions list",
+			ecosystem: "pypi",
+			version:   "1.4",
+			affected:  &vulnAffected{Versions: []string{"1.2.0", "1.3.0"}},
+		},
+		{
+			name:         "debian tilde version before fix",
+			ecosystem:    "debian:12",
+			version:      "3.0.11-1~deb12u1",
+			affected:     semverRange(vulnEvent{Introduced: "0"}, vulnEvent{Fixed: "3.0.11-1~deb12u2"}),
+			wantAffected: true,
+			wantFixed:    "3.0.11-1~deb12u2",
+		},
+		{
+			name:      "alpine patched revision",
+			ecosystem: "alpine:3.19",
+			version:   "3.1.4-r5",
+			affected:  semverRange(vulnEvent{Introduced: "0"}, vulnEvent{Fixed: "3.1.4-r5"}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			affected, fixed := isAffected(tt.ecosystem, tt.version, tt.affected)
+			if affected != tt.wantAffected || fixed != tt.wantFixed {
+				t.Errorf("isAffected(%q) = (%v, %q), want (%v, %q)", tt.version, affected, fixed, tt.wantAffected, tt.wantFixed)
+			}
+		})
+	}
+}
+
+func TestCvss3BaseScore(t *testing.T) {
+	tests := []struct {
+		vector string
+		want   float64
+		ok     bool
+	}{
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", 9.8, true},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H", 10.0, true},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N", 6.1, true},
+		{"CVSS:3.1/AV:L/AC:L/PR:L/UI:N/S:U/C:H/I:H/A:H", 7.8, true},
+		{"CVSS:3.1/AV:N/AC:H/PR:N/UI:N/S:U/C:H/I:N/A:N", 5.9, true},
+		{"CVSS:3.0/AV:N/AC:L/PR:L/UI:N/S:U/C:H/I:N/A:N", 6.5, true},
+		{"CVSS:3.1/AV:P/AC:H/PR:H/UI:R/S:U/C:L/I:N/A:N", 1.6, true},
+		{"CVSS:3.1/AV:N/AC:L/PR:L/UI:N/S:C/C:L/I:L/A:N", 6.4, true},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:N", 0, true},
+		{"AV:N/AC:L/Au:N/C:P/I:P/A:P", 0, false},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H", 0, false},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:X/C:H/I:H/A:H", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := cvss3BaseScore(tt.vector)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("cvss3BaseScore(%q) = (%v, %v), want (%v, %v)", tt.vector, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,373 @@
+package services
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// compareVersions so sánh hai version theo quy tắc của ecosystem (OSV), trả về -1, 0 hoặc 1
+func compareVersions(ecosystem, a, b string) int {
+	switch {
+	case strings.HasPrefix(ecosystem, "debian"), strings.HasPrefix(ecosystem, "ubuntu"):
+		return compareDebianVersions(a, b)
+	case strings.HasPrefix(ecosystem, "alpine"):
+		return compareApkVersions(a, b)
+	case ecosystem == "pypi":
+		return comparePythonVersions(a, b)
+	default:
+		return compareSemver(a, b)
+	}
+}
+
+// ==================== Debian (dpkg) ====================
+
+// compareDebianVersions so sánh [epoch:]upstream[-revision] theo thuật toán của dpkg
+func compareDebianVersions(a, b string) int {
+	epochA, upstreamA, revisionA := splitDebianVersion(a)
+	epochB, upstreamB, revisionB := splitDebianVersion(b)
+	if epochA != epochB {
+		if epochA < epochB {
+			return -1
+		}
+		return 1
+	}
+	if c := compareDebianPart(upstreamA, upstreamB); c != 0 {
+		return c
+	}
+	return compareDebianPart(revisionA, revisionB)
+}
+
+func splitDebianVersion(v string) (int, string, string) {
+	epoch := 0
+	if idx := strings.IndexByte(v, ':'); idx >= 0 {
+		epoch, _ = strconv.Atoi(v[:idx])
+		v = v[idx+1:]
+	}
+	revision := ""
+	if idx := strings.LastIndexByte(v, '-'); idx >= 0 {
+		revision = v[idx+1:]
+		v = v[:idx]
+	}
+	return epoch, v, revision
+}
+
+// compareDebianPart xen kẽ so sánh phần chữ (~ đứng trước mọi thứ, chữ cái trước ký tự khác)
+// và phần số
+func compareDebianPart(a, b string) int {
+	for a != "" || b != "" {
+		var nonDigitA, nonDigitB string
+		nonDigitA, a = splitLeading(a, false)
+		nonDigitB, b = splitLeading(b, false)
+		if c := compareDebianLexical(nonDigitA, nonDigitB); c != 0 {
+			return c
+		}
+
+		var digitA, digitB string
+		digitA, a = splitLeading(a, true)
+		digitB, b = splitLeading(b, true)
+		if c := compareNumeric(digitA, digitB); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func compareDebianLexical(a, b string) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var ca, cb int
+		if i < len(a) {
+			ca = debianCharOrder(a[i])
+		}
+		if i < len(b) {
+			cb = debianCharOrder(b[i])
+		}
+		if ca != cb {
+			if ca < cb {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func debianCharOrder(c byte) int {
+	switch {
+	case c == '~':
+		return -1
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		return int(c)
+	default:
+		return int(c) + 256
+	}
+}
+
+// ==================== Alpine (apk) ====================
+
+var apkSuffixOrder = map[string]int{
+	"alpha": -4, "beta": -3, "pre": -2, "rc": -1,
+	"":    0,
+	"cvs": 1, "svn": 2, "git": 3, "hg": 4, "p": 5,
+}
+
+// compareApkVersions so sánh version kiểu 1.2.3a_rc1_p2-r4
+func compareApkVersions(a, b string) int {
+	baseA, revisionA := splitApkRevision(a)
+	baseB, revisionB := splitApkRevision(b)
+
+	partsA := strings.Split(baseA, "_")
+	partsB := strings.Split(baseB, "_")
+	if c := compareApkNumbers(partsA[0], partsB[0]); c != 0 {
+		return c
+	}
+
+	for i := 1; i < len(partsA) || i < len(partsB); i++ {
+		var suffixA, suffixB, numA, numB string
+		if i < len(partsA) {
+			suffixA, numA = splitLeading(partsA[i], false)
+		}
+		if i < len(partsB) {
+			suffixB, numB = splitLeading(partsB[i], false)
+		}
+		if apkSuffixOrder[suffixA] != apkSuffixOrder[suffixB] {
+			if apkSuffixOrder[suffixA] < apkSuffixOrder[suffixB] {
+				return -1
+			}
+			return 1
+		}
+		if c := compareNumeric(numA, numB); c != 0 {
+			return c
+		}
+	}
+
+	return compareNumeric(revisionA, revisionB)
+}
+
+func splitApkRevision(v string) (string, string) {
+	if idx := strings.LastIndex(v, "-r"); idx >= 0 {
+		return v[:idx], v[idx+2:]
+	}
+	return v, ""
+}
+
+// compareApkNumbers so sánh "1.2.3a": từng số theo dấu chấm, chữ cái cuối (nếu có) so sau cùng
+func compareApkNumbers(a, b string) int {
+	numsA, letterA := splitTrailingLetter(a)
+	numsB, letterB := splitTrailingLetter(b)
+	fieldsA := strings.Split(numsA, ".")
+	fieldsB := strings.Split(numsB, ".")
+	for i := 0; i < len(fieldsA) || i < len(fieldsB); i++ {
+		if i >= len(fieldsA) {
+			return -1
+		}
+		if i >= len(fieldsB) {
+			return 1
+		}
+		if c := compareNumeric(fieldsA[i], fieldsB[i]); c != 0 {
+			return c
+		}
+	}
+	return strings.Compare(letterA, letterB)
+}
+
+func splitTrailingLetter(v string) (string, string) {
+	if n := len(v); n > 0 && v[n-1] >= 'a' && v[n-1] <= 'z' {
+		return v[:n-1], v[n-1:]
+	}
+	return v, ""
+}
+
+// ==================== SemVer (Go, npm) ====================
+
+// compareSemver so sánh semver, chấp nhận tiền tố "v" và version thiếu minor/patch
+func compareSemver(a, b string) int {
+	coreA, preA := splitSemver(a)
+	coreB, preB := splitSemver(b)
+
+	fieldsA := strings.Split(coreA, ".")
+	fieldsB := strings.Split(coreB, ".")
+	for i := 0; i < len(fieldsA) || i < len(fieldsB); i++ {
+		var fa, fb string
+		if i < len(fieldsA) {
+			fa = fieldsA[i]
+		}
+		if i < len(fieldsB) {
+			fb = fieldsB[i]
+		}
+		if c := compareNumeric(fa, fb); c != 0 {
+			return c
+		}
+	}
+
+	// Version có pre-release đứng trước bản chính thức
+	switch {
+	case preA == preB:
+		return 0
+	case preA == "":
+		return 1
+	case preB == "":
+		return -1
+	}
+	idsA := strings.Split(preA, ".")
+	idsB := strings.Split(preB, ".")
+	for i := 0; i < len(idsA) && i < len(idsB); i++ {
+		numA, errA := strconv.ParseUint(idsA[i], 10, 64)
+		numB, errB := strconv.ParseUint(idsB[i], 10, 64)
+		switch {
+		case errA == nil && errB == nil:
+			if numA != numB {
+				if numA < numB {
+					return -1
+				}
+				return 1
+			}
+		case errA == nil:
+			return -1
+		case errB == nil:
+			return 1
+		default:
+			if c := strings.Compare(idsA[i], idsB[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return compareInts(len(idsA), len(idsB))
+}
+
+func splitSemver(v string) (string, string) {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if idx := strings.IndexByte(v, '+'); idx >= 0 {
+		v = v[:idx]
+	}
+	if idx := strings.IndexByte(v, '-'); idx >= 0 {
+		return v[:idx], v[idx+1:]
+	}
+	return v, ""
+}
+
+// ==================== PEP 440 (PyPI) ====================
+
+var pep440Pattern = regexp.MustCompile(`^v?(?:(\d+)!)?(\d+(?:\.\d+)*)` +
+	`(?:[-_.]?(a|b|c|rc|alpha|beta|pre|preview)[-_.]?(\d*))?` +
+	`(?:-(\d+)|[-_.]?(post|rev|r)[-_.]?(\d*))?` +
+	`(?:[-_.]?(dev)[-_.]?(\d*))?` +
+	`(?:\+.*)?$`)
+
+type pythonVersion struct {
+	epoch    int
+	release  []string
+	phase    int // dev < a < b < rc < bản chính thức
+	phaseNum string
+	post     string
+	hasPost  bool
+	dev      string
+	hasDev   bool
+}
+
+func parsePythonVersion(v string) (*pythonVersion, bool) {
+	m := pep440Pattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(v)))
+	if m == nil {
+		return nil, false
+	}
+	pv := &pythonVersion{release: strings.Split(m[2], "."), phase: 4}
+	pv.epoch, _ = strconv.Atoi(m[1])
+	switch m[3] {
+	case "a", "alpha":
+		pv.phase, pv.phaseNum = 1, m[4]
+	case "b", "beta":
+		pv.phase, pv.phaseNum = 2, m[4]
+	case "c", "rc", "pre", "preview":
+		pv.phase, pv.phaseNum = 3, m[4]
+	}
+	if m[5] != "" {
+		pv.post, pv.hasPost = m[5], true
+	} else if m[6] != "" {
+		pv.post, pv.hasPost = m[7], true
+	}
+	if m[8] != "" {
+		pv.dev, pv.hasDev = m[9], true
+		// 1.0.dev1 đứng trước 1.0a1
+		if m[3] == "" && !pv.hasPost {
+			pv.phase = 0
+		}
+	}
+	return pv, true
+}
+
+func comparePythonVersions(a, b string) int {
+	pa, okA := parsePythonVersion(a)
+	pb, okB := parsePythonVersion(b)
+	if !okA || !okB {
+		return compareSemver(a, b)
+	}
+	if c := compareInts(pa.epoch, pb.epoch); c != 0 {
+		return c
+	}
+	for i := 0; i < len(pa.release) || i < len(pb.release); i++ {
+		var fa, fb string
+		if i < len(pa.release) {
+			fa = pa.release[i]
+		}
+		if i < len(pb.release) {
+			fb = pb.release[i]
+		}
+		if c := compareNumeric(fa, fb); c != 0 {
+			return c
+		}
+	}
+	if c := compareInts(pa.phase, pb.phase); c != 0 {
+		return c
+	}
+	if c := compareNumeric(pa.phaseNum, pb.phaseNum); c != 0 {
+		return c
+	}
+	if pa.hasPost != pb.hasPost {
+		if pa.hasPost {
+			return 1
+		}
+		return -1
+	}
+	if c := compareNumeric(pa.post, pb.post); c != 0 {
+		return c
+	}
+	if pa.hasDev != pb.hasDev {
+		// Bản dev đứng trước bản không có dev
+		if pa.hasDev {
+			return -1
+		}
+		return 1
+	}
+	return compareNumeric(pa.dev, pb.dev)
+}
+
+// ==================== Helpers ====================
+
+// splitLeading tách phần đầu chỉ gồm chữ số (digits = true) hoặc không chứa chữ số
+func splitLeading(s string, digits bool) (string, string) {
+	i := 0
+	for i < len(s) && (s[i] >= '0' && s[i] <= '9') == digits {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+// compareNumeric so sánh hai chuỗi chữ số không giới hạn độ dài, chuỗi rỗng xem như 0
+func compareNumeric(a, b string) int {
+	a = strings.TrimLeft(a, "0")
+	b = strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		return compareInts(len(a), len(b))
+	}
+	return strings.Compare(a, b)
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
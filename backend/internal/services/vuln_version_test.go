@@ -0,0 +1,125 @@
+package services
+
+import "testing"
+
+type versionCase struct {
+	a, b string
+	want int
+}
+
+func runVersionCases(t *testing.T, compare func(a, b string) int, cases []versionCase) {
+	t.Helper()
+	for _, tc := range cases {
+		if got := compare(tc.a, tc.b); got != tc.want {
+			t.Errorf("compare(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+		if got := compare(tc.b, tc.a); got != -tc.want {
+			t.Errorf("compare(%q, %q) = %d, want %d", tc.b, tc.a, got, -tc.want)
+		}
+	}
+}
+
+func TestCompareDebianVersions(t *testing.T) {
+	runVersionCases(t, compareDebianVersions, []versionCase{
+		{"1.0", "1.0", 0},
+		{"1.0-1", "1.0-2", -1},
+		{"2.30-1", "2.4-1", 1},
+		// ~ đứng trước mọi thứ, kể cả chuỗi rỗng
+		{"1.0~rc1", "1.0", -1},
+		{"1.0~~", "1.0~", -1},
+		{"1.0~rc1-1", "1.0-1", -1},
+		{"1.0", "1.0+dfsg", -1},
+		{"1.0a", "1.0+", -1},
+		{"1.2.3-1", "1.2.3-1ubuntu1", -1},
+		{"1.2.3-1ubuntu1", "1.2.3-1ubuntu2", -1},
+		// epoch quan trọng hơn upstream version
+		{"1:0.9", "2.0", 1},
+		{"0:2.0", "2.0", 0},
+		{"1:1.0", "2:0.1", -1},
+		{"3.0.11-1~deb12u2", "3.0.11-1", -1},
+	})
+}
+
+func TestCompareApkVersions(t *testing.T) {
+	runVersionCases(t, compareApkVersions, []versionCase{
+		{"1.2.3", "1.2.3", 0},
+		{"1.2.3-r0", "1.2.3-r1", -1},
+		{"1.2.3-r10", "1.2.3-r9", 1},
+		{"1.2.10", "1.2.9", 1},
+		{"1.2", "1.2.1", -1},
+		{"1.2.3a", "1.2.3b", -1},
+		{"1.2.3", "1.2.3a", -1},
+		// _rc trước bản chính thức, _p sau bản chính thức
+		{"1.2.3_rc1", "1.2.3", -1},
+		{"1.2.3_rc1", "1.2.3_rc2", -1},
+		{"1.2.3_beta1", "1.2.3_rc1", -1},
+		{"1.2.3_alpha", "1.2.3_beta", -1},
+		{"1.2.3_p1", "1.2.3", 1},
+		{"1.2.3_p1", "1.2.3_p2", -1},
+		{"1.2.3_rc1-r5", "1.2.3-r0", -1},
+		{"1.2.3_p1-r0", "1.2.3-r5", 1},
+		{"1.2.3_git20240101", "1.2.3_p1", -1},
+	})
+}
+
+func TestComparePythonVersions(t *testing.T) {
+	runVersionCases(t, comparePythonVersions, []versionCase{
+		{"1.0", "1.0.0", 0},
+		{"1.0", "1.1", -1},
+		{"1.10", "1.9", 1},
+		// dev < a < b < rc < bản chính thức < post
+		{"1.0.dev1", "1.0a1", -1},
+		{"1.0a1", "1.0b1", -1},
+		{"1.0b1", "1.0rc1", -1},
+		{"1.0rc1", "1.0", -1},
+		{"1.0", "1.0.post1", -1},
+		{"1.0.dev1", "1.0.dev2", -1},
+		{"1.0a1.dev1", "1.0a1", -1},
+		{"1.0a1", "1.0a2", -1},
+		{"1.0.post1.dev1", "1.0.post1", -1},
+		{"1.0.post1", "1.0.post2", -1},
+		{"1.0-1", "1.0.post1", 0},
+		{"1.0alpha1", "1.0a1", 0},
+		{"1.0c1", "1.0rc1", 0},
+		{"1.0+local", "1.0", 0},
+		{"1!0.5", "2.0", 1},
+	})
+}
+
+func TestCompareSemver(t *testing.T) {
+	runVersionCases(t, compareSemver, []versionCase{
+		{"1.0.0", "1.0.0", 0},
+		{"v1.2", "1.2.0", 0},
+		{"1.0.0+build.1", "1.0.0", 0},
+		{"1.2.10", "1.2.9", 1},
+		{"2.0.0", "10.0.0", -1},
+		// Thứ tự pre-release theo semver.org
+		{"1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{"1.0.0-alpha.1", "1.0.0-alpha.beta", -1},
+		{"1.0.0-alpha.beta", "1.0.0-beta", -1},
+		{"1.0.0-beta", "1.0.0-beta.2", -1},
+		{"1.0.0-beta.2", "1.0.0-beta.11", -1},
+		{"1.0.0-beta.11", "1.0.0-rc.1", -1},
+		{"1.0.0-rc.1", "1.0.0", -1},
+		{"0.9.9", "1.0.0-alpha", -1},
+	})
+}
+
+func TestCompareVersionsByEcosystem(t *testing.T) {
+	tests := []struct {
+		ecosystem, a, b string
+		want            int
+	}{
+		{"debian:12", "1.0~rc1", "1.0", -1},
+		{"ubuntu:22.04", "1:0.1", "2.0", 1},
+		{"alpine:3.19", "1.2.3_p1", "1.2.3", 1},
+		{"pypi", "1.0.dev1", "1.0a1", -1},
+		{"npm", "1.0.0-rc.1", "1.0.0", -1},
+		{"go", "v1.2.3", "1.2.4", -1},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.ecosystem, tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q, %q) = %d, want %d", tt.ecosystem, tt.a, tt.b, got, tt.want)
+		}
+	}
+}
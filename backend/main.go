@@ -73,6 +73,61 @@ func main() {
 		log.Printf("⚠️  Warning: Could not initialize Nginx service: %v", err)
 	}
 	serverManager := services.NewServerManager(serverStore, dockerService, nginxService)
+	registryStore, err := services.NewRegistryStore(dataDir)
+	if err != nil {
+		log.Printf("⚠️  Warning: Could not initialize registry store: %v", err)
+	} else {
+		serverManager.SetRegistryStore(registryStore)
+	}
+	stackStore, err := services.NewStackStore(dataDir)
+	if err != nil {
+		log.Printf("⚠️  Warning: Could not initialize stack store: %v", err)
+	}
+	buildContextStore, err := services.NewBuildContextStore(dataDir)
+	if err != nil {
+		log.Printf("⚠️  Warning: Could not initialize build context store: %v", err)
+	}
+	vulnDatabase, err := services.NewVulnDatabase(dataDir)
+	if err != nil {
+		log.Printf("⚠️  Warning: Could not initialize vulnerability database: %v", err)
+	}
+	scanStore, err := services.NewScanStore(dataDir)
+	if err != nil {
+		log.Printf("⚠️  Warning: Could not initialize scan store: %v", err)
+	}
+	if vulnDatabase != nil && scanStore != nil {
+		serverManager.SetScanner(vulnDatabase, scanStore)
+	}
+	updateCheckInterval := 6 * time.Hour
+	if value := os.Getenv("APPDOCK_UPDATE_CHECK_INTERVAL"); value != "" {
+		if interval, err := time.ParseDuration(value); err == nil {
+			updateCheckInterval = interval
+		} else {
+			log.Printf("⚠️  Warning: Invalid APPDOCK_UPDATE_CHECK_INTERVAL %q: %v", value, err)
+		}
+	}
+	updateChecker, err := services.NewUpdateChecker(dataDir, serverManager, updateCheckInterval)
+	if err != nil {
+		log.Printf("⚠️  Warning: Could not initialize image update checker: %v", err)
+	} else {
+		serverManager.SetUpdateChecker(updateChecker)
+	}
+	autoUpdateStore, err := services.NewAutoUpdateStore(dataDir)
+	if err != nil {
+		log.Printf("⚠️  Warning: Could not initialize auto-update store: %v", err)
+	}
+	var autoUpdater *services.AutoUpdater
+	if autoUpdateStore != nil && updateChecker != nil {
+		autoUpdater = services.NewAutoUpdater(autoUpdateStore, serverManager, updateChecker)
+	}
+	backupStore, err := services.NewBackupStore(dataDir)
+	if err != nil {
+		log.Printf("⚠️  Warning: Could not initialize volume backup store: %v", err)
+	}
+	var backupScheduler *services.BackupScheduler
+	if backupStore != nil {
+		backupScheduler = services.NewBackupScheduler(backupStore, serverManager)
+	}
 	defer statsHistoryService.Close()
 
 	// Start stats collection goroutine (only for local server)
@@ -81,9 +136,16 @@ func main() {
 
 	// Khởi tạo handlers
 	containerHandler := handlers.NewContainerHandler(serverManager)
-	imageHandler := handlers.NewImageHandler(serverManager)
+	imageHandler := handlers.NewImageHandler(serverManager, buildContextStore)
 	networkHandler := handlers.NewNetworkHandler(serverManager)
 	volumeHandler := handlers.NewVolumeHandler(serverManager)
+	backupHandler := handlers.NewBackupHandler(serverManager, backupStore, backupScheduler)
+	composeHandler := handlers.NewComposeHandler(serverManager)
+	stackHandler := handlers.NewStackHandler(stackStore, serverManager)
+	registryHandler := handlers.NewRegistryHandler(registryStore, serverManager)
+	scannerHandler := handlers.NewScannerHandler(vulnDatabase, serverManager)
+	updateHandler := handlers.NewUpdateHandler(updateChecker, serverStore)
+	autoUpdateHandler := handlers.NewAutoUpdateHandler(autoUpdateStore, autoUpdater)
 	systemHandler := handlers.NewSystemHandler(serverManager, statsHistoryService)
 	authHandler := handlers.NewAuthHandler(authService)
 	serverHandler := handlers.NewServerHandler(serverStore, serverManager)
@@ -128,18 +190,31 @@ func main() {
 		api.GET("/system/stats", systemHandler.GetSystemStats)
 		api.GET("/system/stats/history", systemHandler.GetStatsHistory)
 		api.GET("/system/docker-status", systemHandler.GetDockerStatus)
+		api.POST("/system/prune", systemHandler.Prune)
+		api.POST("/system/prune/all", systemHandler.PruneAllServers)
 
 		// Containers
 		containers := api.Group("/containers")
 		{
 			containers.GET("", containerHandler.ListContainers)
+			containers.POST("", containerHandler.CreateContainer)
 			containers.GET("/:id", containerHandler.GetContainer)
 			containers.POST("/:id/start", containerHandler.StartContainer)
 			containers.POST("/:id/stop", containerHandler.StopContainer)
 			containers.POST("/:id/restart", containerHandler.RestartContainer)
+			containers.POST("/:id/update", containerHandler.UpdateContainer)
+			containers.POST("/:id/kill", containerHandler.KillContainer)
+			containers.POST("/:id/pause", containerHandler.PauseContainer)
+			containers.POST("/:id/unpause", containerHandler.UnpauseContainer)
+			containers.GET("/:id/top", containerHandler.TopContainer)
+			containers.POST("/:id/recreate", containerHandler.RecreateContainer)
+			containers.POST("/:id/migrate", containerHandler.MigrateContainer)
 			containers.DELETE("/:id", containerHandler.RemoveContainer)
 			containers.GET("/:id/logs", containerHandler.GetContainerLogs)
 			containers.GET("/:id/stats", containerHandler.GetContainerStats)
+			containers.GET("/:id/archive", containerHandler.DownloadArchive)
+			containers.PUT("/:id/archive", containerHandler.UploadArchive)
+			containers.GET("/:id/changes", containerHandler.GetContainerChanges)
 		}
 
 		// Images
@@ -147,9 +222,61 @@ func main() {
 		{
 			images.GET("", imageHandler.ListImages)
 			images.POST("/pull", imageHandler.PullImage)
+			images.POST("/build/context", imageHandler.UploadBuildContext)
+			images.POST("/push", imageHandler.PushImage)
+			images.GET("/save", imageHandler.SaveImage)
+			images.POST("/load", imageHandler.LoadImage)
+			images.POST("/transfer", imageHandler.TransferImage)
 			images.DELETE("/bulk", imageHandler.RemoveImages) // Bulk delete - phải đặt trước /:id
 			images.GET("/:id", imageHandler.GetImage)
+			images.GET("/:id/details", imageHandler.GetImageDetails)
 			images.DELETE("/:id", imageHandler.RemoveImage)
+			images.POST("/:id/tag", imageHandler.TagImage)
+			images.POST("/:id/scan", scannerHandler.ScanImage)
+			images.GET("/:id/scan", scannerHandler.GetScanResult)
+			images.GET("/:id/sbom", scannerHandler.GetSBOM)
+		}
+
+		// Image updates (so sánh digest với registry)
+		updates := api.Group("/updates")
+		{
+			updates.GET("", updateHandler.ListUpdates)
+			updates.GET("/all", updateHandler.ListAllUpdates)
+			updates.POST("/check", updateHandler.CheckUpdates)
+			updates.POST("/check/all", updateHandler.CheckAllUpdates)
+		}
+
+		// Auto-update (label appdock.autoupdate=true hoặc chính sách qua API)
+		autoUpdate := api.Group("/autoupdate")
+		{
+			autoUpdate.GET("/settings", autoUpdateHandler.GetSettings)
+			autoUpdate.PUT("/settings", autoUpdateHandler.UpdateSettings)
+			autoUpdate.POST("/settings/test-notification", autoUpdateHandler.TestNotification)
+			autoUpdate.GET("/containers", autoUpdateHandler.ListContainers)
+			autoUpdate.PUT("/containers/:name", autoUpdateHandler.SetPolicy)
+			autoUpdate.DELETE("/containers/:name", autoUpdateHandler.DeletePolicy)
+			autoUpdate.POST("/run", autoUpdateHandler.RunNow)
+			autoUpdate.GET("/history", autoUpdateHandler.GetHistory)
+		}
+
+		// Scanner (vulnerability DB offline dạng OSV)
+		scanner := api.Group("/scanner")
+		{
+			scanner.GET("/database", scannerHandler.GetDatabase)
+			scanner.POST("/database/import", scannerHandler.ImportDatabase)
+			scanner.DELETE("/database", scannerHandler.ClearDatabase)
+		}
+
+		// Registries (credentials cho private registry)
+		registries := api.Group("/registries")
+		{
+			registries.GET("", registryHandler.ListRegistries)
+			registries.POST("", registryHandler.CreateRegistry)
+			registries.POST("/test", registryHandler.TestCredentials) // phải đặt trước /:id
+			registries.GET("/:id", registryHandler.GetRegistry)
+			registries.PUT("/:id", registryHandler.UpdateRegistry)
+			registries.DELETE("/:id", registryHandler.DeleteRegistry)
+			registries.POST("/:id/test", registryHandler.TestRegistry)
 		}
 
 		// Networks
@@ -168,6 +295,52 @@ func main() {
 			volumes.GET("/:name", volumeHandler.GetVolume)
 			volumes.POST("", volumeHandler.CreateVolume)
 			volumes.DELETE("/:name", volumeHandler.RemoveVolume)
+			volumes.GET("/:name/backup", volumeHandler.BackupVolume)
+			volumes.POST("/:name/restore", volumeHandler.RestoreVolume)
+			volumes.GET("/:name/files", volumeHandler.ListVolumeFiles)
+			volumes.GET("/:name/files/content", volumeHandler.ReadVolumeFile)
+			volumes.GET("/:name/files/download", volumeHandler.DownloadVolumeFile)
+			volumes.POST("/:name/files", volumeHandler.UploadVolumeFile)
+			volumes.DELETE("/:name/files", volumeHandler.DeleteVolumeFile)
+			volumes.GET("/:name/backups", backupHandler.ListBackups)
+			volumes.POST("/:name/backups", backupHandler.CreateBackup)
+			volumes.GET("/:name/backups/:file", backupHandler.DownloadBackup)
+			volumes.DELETE("/:name/backups/:file", backupHandler.DeleteBackup)
+			volumes.POST("/:name/backups/:file/restore", backupHandler.RestoreBackup)
+		}
+
+		// Lịch backup volume định kỳ
+		backups := api.Group("/backups/schedules")
+		{
+			backups.GET("", backupHandler.ListSchedules)
+			backups.POST("", backupHandler.CreateSchedule)
+			backups.PUT("/:id", backupHandler.UpdateSchedule)
+			backups.DELETE("/:id", backupHandler.DeleteSchedule)
+			backups.POST("/:id/run", backupHandler.RunSchedule)
+		}
+
+		// Compose projects
+		compose := api.Group("/compose/projects")
+		{
+			compose.GET("", composeHandler.ListProjects)
+			compose.POST("/:project/start", composeHandler.StartProject)
+			compose.POST("/:project/stop", composeHandler.StopProject)
+			compose.POST("/:project/restart", composeHandler.RestartProject)
+			compose.POST("/:project/pull", composeHandler.PullProject)
+			compose.DELETE("/:project", composeHandler.RemoveProject)
+		}
+
+		// Stacks (compose files lưu trong AppDock)
+		stacks := api.Group("/stacks")
+		{
+			stacks.GET("", stackHandler.ListStacks)
+			stacks.GET("/:id", stackHandler.GetStack)
+			stacks.POST("", stackHandler.CreateStack)
+			stacks.PUT("/:id", stackHandler.UpdateStack)
+			stacks.DELETE("/:id", stackHandler.DeleteStack)
+			stacks.POST("/:id/deploy", stackHandler.DeployStack)
+			stacks.POST("/:id/diff", stackHandler.DiffStack)
+			stacks.POST("/:id/down", stackHandler.DownStack)
 		}
 
 		// Servers (multi-server management)
@@ -229,6 +402,11 @@ func main() {
 	{
 		ws.GET("/containers/:id/logs", containerHandler.StreamLogs)
 		ws.GET("/containers/:id/exec", containerHandler.ExecTerminal)
+		ws.GET("/containers/:id/stats", containerHandler.StreamStats)
+		ws.GET("/containers/stats", containerHandler.StreamStats)
+		ws.GET("/images/pull", imageHandler.PullImageStream)
+		ws.GET("/images/build", imageHandler.BuildImageStream)
+		ws.GET("/images/push", imageHandler.PushImageStream)
 	}
 
 	// Serve static files (Frontend)
@@ -143,7 +143,14 @@ export function LogViewer({ containerId, containerName }: LogViewerProps) {
           
           // Handle log message
           if (data.type === "log" && data.data && !isPausedRef.current) {
-            const newLog = parseLogLine(data.data);
+            const newLog: LogMessage | null = data.timestamp
+              ? {
+                  id: logIdRef.current++,
+                  timestamp: data.timestamp,
+                  content: data.data,
+                  type: data.stream === "stderr" ? "stderr" : "stdout",
+                }
+              : parseLogLine(data.data);
             if (newLog) {
               setLogs((prev) => [...prev, newLog].slice(-500));
             }
@@ -343,7 +350,11 @@ export function LogViewer({ containerId, containerName }: LogViewerProps) {
               <span className="text-gray-500 w-20 flex-shrink-0 select-none">
                 {formatTime(log.timestamp)}
               </span>
-              <span className="text-gray-200 whitespace-pre-wrap break-all">
+              <span
+                className={`whitespace-pre-wrap break-all ${
+                  log.type === "stderr" ? "text-red-400" : "text-gray-200"
+                }`}
+              >
                 {log.content}
               </span>
             </div>
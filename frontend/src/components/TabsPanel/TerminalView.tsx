@@ -100,6 +100,15 @@ export function TerminalView({ containerId, containerName, isActive = true }: Te
     const token = getAuthToken();
     const wsUrl = `${protocol}//${host}/ws/containers/${containerId}/exec`;
 
+    const sendResize = (cols: number, rows: number) => {
+      const ws = wsRef.current;
+      if (ws && ws.readyState === WebSocket.OPEN) {
+        ws.send(JSON.stringify({ type: "resize", cols, rows }));
+      }
+    };
+
+    const resizeDisposable = term.onResize(({ cols, rows }) => sendResize(cols, rows));
+
     const connect = () => {
       wsRef.current = token
         ? new WebSocket(wsUrl, token)
@@ -107,6 +116,7 @@ export function TerminalView({ containerId, containerName, isActive = true }: Te
 
       wsRef.current.onopen = () => {
         setIsConnected(true);
+        sendResize(term.cols, term.rows);
         term.clear();
         term.writeln(`✓ Đã kết nối tới container: ${containerName}`);
         term.write("\r\n$ ");
@@ -142,6 +152,7 @@ export function TerminalView({ containerId, containerName, isActive = true }: Te
     connect();
 
     return () => {
+      resizeDisposable.dispose();
       if (wsRef.current) {
         wsRef.current.close();
       }
@@ -10,11 +10,13 @@ export interface Container {
   id: string;
   name: string;
   image: string;
+  imageId: string;
   status: string;
   state: string;
   created: number;
   ports: PortMapping[];
   labels: Record<string, string>;
+  updateAvailable: boolean; // Registry has a newer digest for the image tag
 }
 
 export interface ContainerConfig {
@@ -67,6 +69,18 @@ export interface Image {
   labels: Record<string, string>;
   inUse: boolean;
   containers: string[]; // Container names using this image
+  scan?: ScanSummary; // Latest vulnerability scan
+}
+
+export interface ScanSummary {
+  scannedAt: string;
+  packages: number;
+  critical: number;
+  high: number;
+  medium: number;
+  low: number;
+  unknown: number;
+  total: number;
 }
 
 // Network types